@@ -1,23 +1,102 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
 
 	"pomodoro/backend/internal/config"
 	"pomodoro/backend/internal/db"
+	"pomodoro/backend/migrations"
 )
 
+const usage = "usage: migrate [up | down [N] | status | create <name>]"
+
 func main() {
 	cfg := config.Load()
-	database, err := db.OpenSQLite(cfg.DBPath)
+
+	command := "up"
+	args := os.Args[1:]
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	if command == "create" {
+		if len(args) != 1 {
+			log.Fatal(usage)
+		}
+		createMigration(cfg, args[0])
+		return
+	}
+
+	database, dialect, err := db.Open(cfg.DBDriver, cfg.DataSource())
 	if err != nil {
 		log.Fatalf("open database: %v", err)
 	}
 	defer database.Close()
 
-	if err := db.RunMigrations(database, cfg.MigrationsDir); err != nil {
-		log.Fatalf("run migrations: %v", err)
+	source, err := migrations.Source(cfg.DBDriver, cfg.DriverMigrationsDir())
+	if err != nil {
+		log.Fatalf("load migrations: %v", err)
 	}
+	migrator, err := db.NewMigrator(database, dialect, source)
+	if err != nil {
+		log.Fatalf("load migrations: %v", err)
+	}
+
+	switch command {
+	case "up":
+		versions, err := migrator.Up()
+		for _, version := range versions {
+			log.Printf("applied %s", version)
+		}
+		if err != nil {
+			log.Fatalf("run migrations: %v", err)
+		}
+		log.Printf("migrations applied successfully (%d new)", len(versions))
+	case "down":
+		steps := 1
+		if len(args) > 0 {
+			steps, err = strconv.Atoi(args[0])
+			if err != nil || steps < 1 {
+				log.Fatal(usage)
+			}
+		}
+		versions, err := migrator.Down(steps)
+		for _, version := range versions {
+			log.Printf("rolled back %s", version)
+		}
+		if err != nil {
+			log.Fatalf("roll back migrations: %v", err)
+		}
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatalf("migration status: %v", err)
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "VERSION\tSTATE\tAPPLIED AT")
+		for _, status := range statuses {
+			fmt.Fprintf(writer, "%s\t%s\t%s\n", status.Version, status.State, status.AppliedAt)
+		}
+		_ = writer.Flush()
+	default:
+		log.Fatal(usage)
+	}
+}
 
-	log.Println("migrations applied successfully")
+// createMigration adds the pair to both the SQLite and Postgres sets so they
+// stay in step.
+func createMigration(cfg config.Config, name string) {
+	dir := cfg.MigrationsDir
+	paths, err := db.CreateMigration(name, dir, filepath.Join(dir, db.DriverPostgres))
+	if err != nil {
+		log.Fatalf("create migration: %v", err)
+	}
+	for _, path := range paths {
+		log.Printf("created %s", path)
+	}
 }
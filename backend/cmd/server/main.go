@@ -1,41 +1,220 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata"
 
 	"pomodoro/backend/internal/config"
 	"pomodoro/backend/internal/db"
 	"pomodoro/backend/internal/handler"
+	"pomodoro/backend/internal/mailer"
 	"pomodoro/backend/internal/repository"
 	"pomodoro/backend/internal/router"
 	"pomodoro/backend/internal/service"
+	"pomodoro/backend/migrations"
 )
 
+// shutdownTimeout bounds how long in-flight requests may take to finish
+// once the server is asked to stop.
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	cfg := config.Load()
 
-	database, err := db.OpenSQLite(cfg.DBPath)
+	database, dialect, err := db.Open(cfg.DBDriver, cfg.DataSource())
 	if err != nil {
 		log.Fatalf("open database: %v", err)
 	}
 	defer database.Close()
 
-	if err := db.RunMigrations(database, cfg.MigrationsDir); err != nil {
+	source, err := migrations.Source(cfg.DBDriver, cfg.DriverMigrationsDir())
+	if err != nil {
+		log.Fatalf("load migrations: %v", err)
+	}
+	if err := db.RunMigrations(database, dialect, source); err != nil {
 		log.Fatalf("run migrations: %v", err)
 	}
 
-	userRepo := repository.NewUserRepository(database)
-	pomodoroRepo := repository.NewPomodoroRepository(database)
+	userRepo := repository.NewUserRepository(database, dialect)
+	pomodoroRepo := repository.NewPomodoroRepository(database, dialect)
+	taskRepo := repository.NewTaskRepository(database, dialect)
+	presetRepo := repository.NewPresetRepository(database, dialect)
+	roomRepo := repository.NewRoomRepository(database, dialect)
+	goalRepo := repository.NewGoalRepository(database, dialect)
+	modeRepo := repository.NewModeRepository(database, dialect)
+	webhookRepo := repository.NewWebhookRepository(database, dialect)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(database, dialect)
+	deviceRepo := repository.NewDeviceRepository(database, dialect)
+	idempotencyRepo := repository.NewIdempotencyRepository(database, dialect)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(database, dialect)
+	userTokenRepo := repository.NewUserTokenRepository(database, dialect)
+
+	// Postgres can back several instances, which then share state
+	// snapshots; a SQLite file serves a single one.
+	hub := service.NewStateHub()
+	if cfg.DBDriver == db.DriverPostgres {
+		hub = service.NewRelayedStateHub(repository.NewPostgresStateRelay(database, cfg.DataSource()))
+	}
+
+	mail, err := newMailer(cfg)
+	if err != nil {
+		log.Fatalf("configure mailer: %v", err)
+	}
 
-	authService := service.NewAuthService(userRepo, pomodoroRepo, cfg.JWTSecret, cfg.TokenTTL)
-	pomodoroService := service.NewPomodoroService(pomodoroRepo)
+	authService := service.NewAuthService(
+		userRepo,
+		pomodoroRepo,
+		refreshTokenRepo,
+		deviceRepo,
+		loginAttemptRepo,
+		service.LockoutPolicy{
+			Threshold: cfg.LoginLockoutThreshold,
+			Base:      cfg.LoginLockoutBase,
+			Max:       cfg.LoginLockoutMax,
+		},
+		cfg.JWTSecret,
+		cfg.AccessTokenTTL,
+		cfg.RefreshTokenTTL,
+	)
+	pomodoroService := service.NewPomodoroService(
+		pomodoroRepo,
+		taskRepo,
+		deviceRepo,
+		goalRepo,
+		modeRepo,
+		webhookRepo,
+		hub,
+		service.NewSessionFinalizer(),
+	)
+	taskService := service.NewTaskService(taskRepo, pomodoroService)
+	presetService := service.NewPresetService(presetRepo, pomodoroService)
+	modeService := service.NewModeService(modeRepo, pomodoroService)
+	roomService := service.NewRoomService(roomRepo, pomodoroService)
+	webhookService := service.NewWebhookService(webhookRepo, service.WebhookDeliverySettings{
+		PollInterval:         cfg.WebhookPollInterval,
+		Timeout:              cfg.WebhookTimeout,
+		MaxAttempts:          cfg.WebhookMaxAttempts,
+		RetryBase:            cfg.WebhookRetryBase,
+		RetryMax:             cfg.WebhookRetryMax,
+		AllowPrivateNetworks: cfg.WebhookAllowPrivate,
+	})
+	deviceService := service.NewDeviceService(deviceRepo, refreshTokenRepo)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, cfg.IdempotencyTTL)
+	accountService := service.NewAccountService(
+		userRepo,
+		userTokenRepo,
+		refreshTokenRepo,
+		loginAttemptRepo,
+		mail,
+		service.AccountMailSettings{
+			AppBaseURL:           cfg.AppBaseURL,
+			PasswordResetTTL:     cfg.PasswordResetTTL,
+			EmailVerificationTTL: cfg.EmailVerificationTTL,
+		},
+	)
 
-	authHandler := handler.NewAuthHandler(authService)
+	authHandler := handler.NewAuthHandler(authService, accountService)
 	pomodoroHandler := handler.NewPomodoroHandler(pomodoroService)
+	taskHandler := handler.NewTaskHandler(taskService)
+	presetHandler := handler.NewPresetHandler(presetService)
+	modeHandler := handler.NewModeHandler(modeService)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	roomHandler := handler.NewRoomHandler(roomService)
+	deviceHandler := handler.NewDeviceHandler(deviceService)
+	accountHandler := handler.NewAccountHandler(accountService)
+
+	engine := router.New(
+		authService,
+		authHandler,
+		pomodoroHandler,
+		taskHandler,
+		presetHandler,
+		modeHandler,
+		webhookHandler,
+		roomHandler,
+		deviceHandler,
+		accountHandler,
+		idempotencyService,
+		service.NewRateLimiter(cfg.AuthIPRatePerMinute, cfg.AuthIPBurst),
+		service.NewRateLimiter(cfg.AuthEmailRatePerMinute, cfg.AuthEmailBurst),
+		cfg.CORSOrigins,
+		cfg.StreamAuthInterval,
+	)
+	// Client IPs key the auth rate limits, so X-Forwarded-For is only
+	// honoured from the configured proxies.
+	if err := engine.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("trusted proxies: %v", err)
+	}
 
-	engine := router.New(authService, authHandler, pomodoroHandler, cfg.CORSOrigins)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	finalizerDone := make(chan struct{})
+	go func() {
+		defer close(finalizerDone)
+		pomodoroService.RunFinalizer(ctx, cfg.SessionRescanInterval, roomService)
+	}()
+
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		hub.RunRelay(ctx)
+	}()
+
+	deliveriesDone := make(chan struct{})
+	go func() {
+		defer close(deliveriesDone)
+		webhookService.RunDeliveries(ctx)
+	}()
+
+	server := &http.Server{Addr: ":" + cfg.Port, Handler: engine}
+	// Shutdown neither closes event streams and sockets nor waits them out,
+	// so they are ended directly while other requests drain.
+	server.RegisterOnShutdown(pomodoroService.CloseStreams)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
 	log.Printf("backend listening on :%s", cfg.Port)
-	if err := engine.Run(":" + cfg.Port); err != nil {
-		log.Fatalf("run server: %v", err)
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("run server: %v", err)
+		}
+	case <-ctx.Done():
+	}
+	stop()
+
+	log.Printf("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shut down server: %v", err)
+	}
+	<-finalizerDone
+	<-relayDone
+	<-deliveriesDone
+}
+
+func newMailer(cfg config.Config) (mailer.Mailer, error) {
+	switch cfg.MailDriver {
+	case mailer.DriverSMTP:
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required for the smtp mail driver")
+		}
+		return mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom), nil
+	case mailer.DriverOutbox:
+		return mailer.NewOutboxMailer(cfg.MailOutboxDir, cfg.MailFrom)
+	default:
+		return nil, fmt.Errorf("unsupported mail driver %q", cfg.MailDriver)
 	}
 }
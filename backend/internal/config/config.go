@@ -2,31 +2,110 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 )
 
 type Config struct {
-	Port          string
-	DBPath        string
-	JWTSecret     string
-	TokenTTL      time.Duration
-	CORSOrigins   []string
-	MigrationsDir string
+	Port                   string
+	DBDriver               string
+	DBPath                 string
+	DatabaseURL            string
+	JWTSecret              string
+	AccessTokenTTL         time.Duration
+	RefreshTokenTTL        time.Duration
+	CORSOrigins            []string
+	MigrationsDir          string
+	IdempotencyTTL         time.Duration
+	AuthIPRatePerMinute    int
+	AuthIPBurst            int
+	AuthEmailRatePerMinute int
+	AuthEmailBurst         int
+	LoginLockoutThreshold  int
+	LoginLockoutBase       time.Duration
+	LoginLockoutMax        time.Duration
+	TrustedProxies         []string
+	AppBaseURL             string
+	PasswordResetTTL       time.Duration
+	EmailVerificationTTL   time.Duration
+	MailDriver             string
+	MailFrom               string
+	MailOutboxDir          string
+	SMTPHost               string
+	SMTPPort               int
+	SMTPUsername           string
+	SMTPPassword           string
+	SessionRescanInterval  time.Duration
+	StreamAuthInterval     time.Duration
+	WebhookPollInterval    time.Duration
+	WebhookTimeout         time.Duration
+	WebhookMaxAttempts     int
+	WebhookRetryBase       time.Duration
+	WebhookRetryMax        time.Duration
+	WebhookAllowPrivate    bool
 }
 
 func Load() Config {
 	return Config{
-		Port:          getEnv("PORT", "8080"),
-		DBPath:        getEnv("DB_PATH", "./data/pomodoro.db"),
-		JWTSecret:     getEnv("JWT_SECRET", "change-this-secret"),
-		TokenTTL:      time.Duration(getEnvInt("TOKEN_TTL_HOURS", 72)) * time.Hour,
-		CORSOrigins:   getEnvList("CORS_ORIGINS", []string{"http://localhost:5173", "http://127.0.0.1:5173"}),
-		MigrationsDir: getEnv("MIGRATIONS_DIR", "./migrations"),
+		Port:                   getEnv("PORT", "8080"),
+		DBDriver:               getEnv("DB_DRIVER", "sqlite"),
+		DBPath:                 getEnv("DB_PATH", "./data/pomodoro.db"),
+		DatabaseURL:            getEnv("DATABASE_URL", ""),
+		JWTSecret:              getEnv("JWT_SECRET", "change-this-secret"),
+		AccessTokenTTL:         time.Duration(getEnvInt("ACCESS_TOKEN_TTL_MINUTES", 15)) * time.Minute,
+		RefreshTokenTTL:        time.Duration(getEnvInt("REFRESH_TOKEN_TTL_HOURS", 720)) * time.Hour,
+		CORSOrigins:            getEnvList("CORS_ORIGINS", []string{"http://localhost:5173", "http://127.0.0.1:5173"}),
+		MigrationsDir:          getEnv("MIGRATIONS_DIR", "./migrations"),
+		IdempotencyTTL:         time.Duration(getEnvInt("IDEMPOTENCY_TTL_HOURS", 24)) * time.Hour,
+		AuthIPRatePerMinute:    getEnvInt("AUTH_IP_RATE_PER_MINUTE", 20),
+		AuthIPBurst:            getEnvInt("AUTH_IP_BURST", 10),
+		AuthEmailRatePerMinute: getEnvInt("AUTH_EMAIL_RATE_PER_MINUTE", 5),
+		AuthEmailBurst:         getEnvInt("AUTH_EMAIL_BURST", 5),
+		LoginLockoutThreshold:  getEnvInt("LOGIN_LOCKOUT_THRESHOLD", 5),
+		LoginLockoutBase:       time.Duration(getEnvInt("LOGIN_LOCKOUT_BASE_SECONDS", 60)) * time.Second,
+		LoginLockoutMax:        time.Duration(getEnvInt("LOGIN_LOCKOUT_MAX_MINUTES", 60)) * time.Minute,
+		TrustedProxies:         getEnvList("TRUSTED_PROXIES", nil),
+		AppBaseURL:             getEnv("APP_BASE_URL", "http://localhost:5173"),
+		PasswordResetTTL:       time.Duration(getEnvInt("PASSWORD_RESET_TTL_MINUTES", 60)) * time.Minute,
+		EmailVerificationTTL:   time.Duration(getEnvInt("EMAIL_VERIFICATION_TTL_HOURS", 48)) * time.Hour,
+		MailDriver:             getEnv("MAIL_DRIVER", "outbox"),
+		MailFrom:               getEnv("MAIL_FROM", "Pomodoro <no-reply@localhost>"),
+		MailOutboxDir:          getEnv("MAIL_OUTBOX_DIR", "./data/outbox"),
+		SMTPHost:               getEnv("SMTP_HOST", ""),
+		SMTPPort:               getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:           getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:           getEnv("SMTP_PASSWORD", ""),
+		SessionRescanInterval:  time.Duration(getEnvPositiveInt("SESSION_RESCAN_SECONDS", 60)) * time.Second,
+		StreamAuthInterval:     time.Duration(getEnvPositiveInt("STREAM_AUTH_CHECK_SECONDS", 30)) * time.Second,
+		WebhookPollInterval:    time.Duration(getEnvPositiveInt("WEBHOOK_POLL_SECONDS", 2)) * time.Second,
+		WebhookTimeout:         time.Duration(getEnvInt("WEBHOOK_TIMEOUT_SECONDS", 10)) * time.Second,
+		WebhookMaxAttempts:     getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookRetryBase:       time.Duration(getEnvInt("WEBHOOK_RETRY_BASE_SECONDS", 30)) * time.Second,
+		WebhookRetryMax:        time.Duration(getEnvInt("WEBHOOK_RETRY_MAX_MINUTES", 60)) * time.Minute,
+		WebhookAllowPrivate:    getEnvBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
 	}
 }
 
+// DataSource is the database file for SQLite and the connection URL for
+// Postgres.
+func (c Config) DataSource() string {
+	if c.DBDriver == "postgres" {
+		return c.DatabaseURL
+	}
+	return c.DBPath
+}
+
+// DriverMigrationsDir points Postgres at its own migration set, kept in a
+// subdirectory of the SQLite migrations.
+func (c Config) DriverMigrationsDir() string {
+	if c.DBDriver == "postgres" {
+		return filepath.Join(c.MigrationsDir, "postgres")
+	}
+	return c.MigrationsDir
+}
+
 func getEnv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok && value != "" {
 		return value
@@ -47,6 +126,29 @@ func getEnvInt(key string, fallback int) int {
 	return parsed
 }
 
+// getEnvPositiveInt is getEnvInt for settings that must be greater than zero,
+// such as ticker intervals; zero or negative values use the fallback.
+func getEnvPositiveInt(key string, fallback int) int {
+	value := getEnvInt(key, fallback)
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
+
 func getEnvList(key string, fallback []string) []string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// Dialect hides the SQL differences between the supported databases.
+// Queries are written with ? placeholders and rebound per dialect.
+type Dialect interface {
+	Name() string
+	Rebind(query string) string
+	// ForUpdate is appended to reads whose row is about to be written in
+	// the same transaction.
+	ForUpdate() string
+	// ShiftedDate is the YYYY-MM-DD date of a stored timestamp moved by
+	// shift, an expression yielding a modifier such as '+3600 seconds'.
+	ShiftedDate(timestamp, shift string) string
+	IsUniqueViolation(err error) bool
+}
+
+func DialectFor(driver string) (Dialect, error) {
+	switch driver {
+	case DriverSQLite:
+		return SQLiteDialect{}, nil
+	case DriverPostgres:
+		return PostgresDialect{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+}
+
+type SQLiteDialect struct{}
+
+func (SQLiteDialect) Name() string { return DriverSQLite }
+
+func (SQLiteDialect) Rebind(query string) string { return query }
+
+// ForUpdate is empty because SQLite serializes writers on a single
+// connection already.
+func (SQLiteDialect) ForUpdate() string { return "" }
+
+func (SQLiteDialect) ShiftedDate(timestamp, shift string) string {
+	return fmt.Sprintf("date(substr(%s, 1, 19), %s)", timestamp, shift)
+}
+
+// IsUniqueViolation covers primary keys too, which SQLite reports with their
+// own extended code while Postgres uses 23505 for both.
+func (SQLiteDialect) IsUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique ||
+		sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+}
+
+type PostgresDialect struct{}
+
+func (PostgresDialect) Name() string { return DriverPostgres }
+
+// Rebind rewrites ? placeholders to $1, $2, ... leaving quoted literals alone.
+func (PostgresDialect) Rebind(query string) string {
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	inLiteral := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inLiteral = !inLiteral
+			b.WriteRune(r)
+		case r == '?' && !inLiteral:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func (PostgresDialect) ForUpdate() string { return " FOR UPDATE" }
+
+func (PostgresDialect) ShiftedDate(timestamp, shift string) string {
+	return fmt.Sprintf("to_char(CAST(substr(%s, 1, 19) AS timestamp) + CAST(%s AS interval), 'YYYY-MM-DD')", timestamp, shift)
+}
+
+// IsUniqueViolation checks SQLSTATE 23505 through the interface exposed by
+// the common Postgres drivers, falling back to the server message.
+func (PostgresDialect) IsUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	var stateErr interface{ SQLState() string }
+	if errors.As(err, &stateErr) {
+		return stateErr.SQLState() == "23505"
+	}
+	return strings.Contains(err.Error(), "duplicate key value violates unique constraint")
+}
@@ -0,0 +1,133 @@
+package db_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+
+	"pomodoro/backend/internal/db"
+)
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "pq: error " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestDialectFor(t *testing.T) {
+	for _, driver := range []string{db.DriverSQLite, db.DriverPostgres} {
+		dialect, err := db.DialectFor(driver)
+		if err != nil {
+			t.Fatalf("dialect for %s: %v", driver, err)
+		}
+		if dialect.Name() != driver {
+			t.Fatalf("expected dialect %s, got %s", driver, dialect.Name())
+		}
+	}
+	if _, err := db.DialectFor("mysql"); err == nil {
+		t.Fatal("expected an error for an unsupported driver")
+	}
+}
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		postgres string
+	}{
+		{"no placeholders", "SELECT 1", "SELECT 1"},
+		{"placeholders", "SELECT id FROM tasks WHERE user_id = ? AND id = ?", "SELECT id FROM tasks WHERE user_id = $1 AND id = $2"},
+		{"quoted literal", "UPDATE t SET note = 'why?' WHERE id = ?", "UPDATE t SET note = 'why?' WHERE id = $1"},
+		{"escaped quote", "SELECT 'it''s ?', ? FROM t", "SELECT 'it''s ?', $1 FROM t"},
+		{"many", "VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", "VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (db.SQLiteDialect{}).Rebind(tt.query); got != tt.query {
+				t.Fatalf("sqlite rebind changed the query: %q", got)
+			}
+			if got := (db.PostgresDialect{}).Rebind(tt.query); got != tt.postgres {
+				t.Fatalf("postgres rebind: expected %q, got %q", tt.postgres, got)
+			}
+		})
+	}
+}
+
+func TestForUpdate(t *testing.T) {
+	if got := (db.SQLiteDialect{}).ForUpdate(); got != "" {
+		t.Fatalf("expected no lock clause for sqlite, got %q", got)
+	}
+	if got := (db.PostgresDialect{}).ForUpdate(); got != " FOR UPDATE" {
+		t.Fatalf("expected FOR UPDATE for postgres, got %q", got)
+	}
+}
+
+func TestShiftedDate(t *testing.T) {
+	if got, want := (db.PostgresDialect{}).ShiftedDate("started_at", "?"),
+		"to_char(CAST(substr(started_at, 1, 19) AS timestamp) + CAST(? AS interval), 'YYYY-MM-DD')"; got != want {
+		t.Fatalf("postgres shifted date: expected %q, got %q", want, got)
+	}
+
+	// SQLite evaluates the expression in process, so its results are
+	// checked directly.
+	database, err := db.OpenSQLite(filepath.Join(t.TempDir(), "dialect.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+
+	tests := []struct {
+		name      string
+		timestamp string
+		shift     string
+		want      string
+	}{
+		{"no shift", "2024-03-09T12:00:00.000000000Z", "+0 seconds", "2024-03-09"},
+		{"behind utc", "2024-03-10T03:30:00.000000000Z", "-18000 seconds", "2024-03-09"},
+		{"ahead of utc", "2024-03-09T20:00:00.000000000Z", "+28800 seconds", "2024-03-10"},
+		{"seconds precision", "2024-03-09T23:59:59Z", "+1 seconds", "2024-03-10"},
+	}
+	query := fmt.Sprintf("SELECT %s", (db.SQLiteDialect{}).ShiftedDate("?", "?"))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if err := database.QueryRow(query, tt.timestamp, tt.shift).Scan(&got); err != nil {
+				t.Fatalf("evaluate shifted date: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  db.Dialect
+		err      error
+		expected bool
+	}{
+		{"sqlite nil", db.SQLiteDialect{}, nil, false},
+		{"sqlite unique", db.SQLiteDialect{}, sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintUnique}, true},
+		{"sqlite primary key", db.SQLiteDialect{}, sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintPrimaryKey}, true},
+		{"sqlite wrapped", db.SQLiteDialect{}, fmt.Errorf("insert: %w", sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintUnique}), true},
+		{"sqlite foreign key", db.SQLiteDialect{}, sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintForeignKey}, false},
+		{"sqlite other error", db.SQLiteDialect{}, errors.New("UNIQUE constraint failed: users.email"), false},
+		{"postgres nil", db.PostgresDialect{}, nil, false},
+		{"postgres sqlstate", db.PostgresDialect{}, sqlStateError("23505"), true},
+		{"postgres wrapped", db.PostgresDialect{}, fmt.Errorf("insert: %w", sqlStateError("23505")), true},
+		{"postgres other sqlstate", db.PostgresDialect{}, sqlStateError("23503"), false},
+		{"postgres message", db.PostgresDialect{}, errors.New(`ERROR: duplicate key value violates unique constraint "users_email_key"`), true},
+		{"postgres other error", db.PostgresDialect{}, errors.New("connection refused"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dialect.IsUniqueViolation(tt.err); got != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,497 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	migrationUpSuffix   = ".up.sql"
+	migrationDownSuffix = ".down.sql"
+	// Rows written before up/down pairs existed are keyed by file name.
+	legacyMigrationSuffix = ".sql"
+	migrationVersionWidth = 3
+)
+
+const (
+	MigrationApplied  = "applied"
+	MigrationPending  = "pending"
+	MigrationModified = "modified"
+	MigrationMissing  = "missing"
+)
+
+var migrationNameCleaner = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Migration is one NNN_name.up.sql / NNN_name.down.sql pair. Checksum covers
+// the up file, which is what ends up in the schema.
+type Migration struct {
+	Version  string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type MigrationStatus struct {
+	Version   string
+	State     string
+	AppliedAt string
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt string
+}
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+func NewMigrator(database *sql.DB, dialect Dialect, source fs.FS) (*Migrator, error) {
+	migrations, err := LoadMigrations(source)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: database, dialect: dialect, migrations: migrations}, nil
+}
+
+// RunMigrations applies every pending migration from source.
+func RunMigrations(database *sql.DB, dialect Dialect, source fs.FS) error {
+	migrator, err := NewMigrator(database, dialect, source)
+	if err != nil {
+		return err
+	}
+	_, err = migrator.Up()
+	return err
+}
+
+// LoadMigrations reads the up/down pairs at the root of source, sorted by
+// version. Every up file needs a matching down file and vice versa.
+func LoadMigrations(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations dir: %w", err)
+	}
+
+	byVersion := map[string]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, legacyMigrationSuffix) {
+			continue
+		}
+
+		var version string
+		var up bool
+		switch {
+		case strings.HasSuffix(name, migrationUpSuffix):
+			version, up = strings.TrimSuffix(name, migrationUpSuffix), true
+		case strings.HasSuffix(name, migrationDownSuffix):
+			version = strings.TrimSuffix(name, migrationDownSuffix)
+		default:
+			return nil, fmt.Errorf("migration %s must be named <version>.up.sql or <version>.down.sql", name)
+		}
+
+		content, err := fs.ReadFile(source, name)
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", name, err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version}
+			byVersion[version] = migration
+		}
+		if up {
+			migration.Up = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for version, migration := range byVersion {
+		if migration.Checksum == "" {
+			return nil, fmt.Errorf("migration %s has no up file", version)
+		}
+		if !hasFile(source, version+migrationDownSuffix) {
+			return nil, fmt.Errorf("migration %s has no down file", version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Up applies pending migrations in version order and returns their versions.
+func (m *Migrator) Up() ([]string, error) {
+	applied, err := m.prepare()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []string{}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := m.apply(migration); err != nil {
+			return versions, err
+		}
+		versions = append(versions, migration.Version)
+	}
+	return versions, nil
+}
+
+// Down rolls back the most recently applied steps migrations, newest first.
+func (m *Migrator) Down(steps int) ([]string, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("down steps must be at least 1")
+	}
+
+	applied, err := m.prepare()
+	if err != nil {
+		return nil, err
+	}
+
+	appliedVersions := make([]string, 0, len(applied))
+	for version := range applied {
+		appliedVersions = append(appliedVersions, version)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(appliedVersions)))
+
+	versions := []string{}
+	for _, version := range appliedVersions {
+		if len(versions) == steps {
+			break
+		}
+		migration, ok := m.find(version)
+		if !ok {
+			return versions, fmt.Errorf("migration %s is applied but missing from the migration source", version)
+		}
+		if err := m.revert(migration); err != nil {
+			return versions, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+// Status lists every known and applied migration in version order.
+// Applied migrations without a file are reported as missing.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, State: MigrationPending}
+		if record, ok := applied[migration.Version]; ok {
+			status.State = MigrationApplied
+			status.AppliedAt = record.appliedAt
+			if record.checksum != migration.Checksum {
+				status.State = MigrationModified
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	for version, record := range applied {
+		if _, ok := m.find(version); !ok {
+			statuses = append(statuses, MigrationStatus{
+				Version:   version,
+				State:     MigrationMissing,
+				AppliedAt: record.appliedAt,
+			})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// prepare loads the applied set and refuses to continue if any applied
+// migration file has been edited since.
+func (m *Migrator) prepare() (map[string]appliedMigration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	for _, migration := range m.migrations {
+		record, ok := applied[migration.Version]
+		if ok && record.checksum != migration.Checksum {
+			return nil, fmt.Errorf(
+				"migration %s was modified after it was applied (recorded checksum %s, file checksum %s)",
+				migration.Version,
+				record.checksum,
+				migration.Checksum,
+			)
+		}
+	}
+	return applied, nil
+}
+
+func (m *Migrator) apply(migration Migration) error {
+	conn, tx, err := m.begin()
+	if err != nil {
+		return fmt.Errorf("begin migration tx %s: %w", migration.Version, err)
+	}
+	defer m.release(conn)
+
+	if _, err := tx.Exec(migration.Up); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("execute migration %s: %w", migration.Version, err)
+	}
+
+	if _, err := tx.Exec(
+		m.dialect.Rebind(`INSERT INTO schema_migrations (name, checksum, applied_at) VALUES (?, ?, ?)`),
+		migration.Version,
+		migration.Checksum,
+		time.Now().UTC().Format(time.RFC3339Nano),
+	); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("record migration %s: %w", migration.Version, err)
+	}
+
+	if err := m.commit(tx); err != nil {
+		return fmt.Errorf("commit migration %s: %w", migration.Version, err)
+	}
+	return nil
+}
+
+func (m *Migrator) revert(migration Migration) error {
+	conn, tx, err := m.begin()
+	if err != nil {
+		return fmt.Errorf("begin rollback tx %s: %w", migration.Version, err)
+	}
+	defer m.release(conn)
+
+	if _, err := tx.Exec(migration.Down); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("roll back migration %s: %w", migration.Version, err)
+	}
+
+	if _, err := tx.Exec(
+		m.dialect.Rebind(`DELETE FROM schema_migrations WHERE name = ?`),
+		migration.Version,
+	); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("unrecord migration %s: %w", migration.Version, err)
+	}
+
+	if err := m.commit(tx); err != nil {
+		return fmt.Errorf("commit rollback %s: %w", migration.Version, err)
+	}
+	return nil
+}
+
+// begin starts a migration transaction on a dedicated connection. SQLite
+// changes a constraint by rebuilding the table, and dropping the old table
+// with foreign keys on would cascade into the tables that reference it, so
+// they are switched off for the connection; the pragma has no effect once
+// a transaction is open. commit checks the keys before committing instead.
+func (m *Migrator) begin() (*sql.Conn, *sql.Tx, error) {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if m.dialect.Name() == DriverSQLite {
+		if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+			m.release(conn)
+			return nil, nil, err
+		}
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		m.release(conn)
+		return nil, nil, err
+	}
+	return conn, tx, nil
+}
+
+func (m *Migrator) commit(tx *sql.Tx) error {
+	if m.dialect.Name() == DriverSQLite {
+		var table string
+		err := tx.QueryRow(`SELECT "table" FROM pragma_foreign_key_check`).Scan(&table)
+		if err == nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("foreign key violation in %s", table)
+		}
+		if err != sql.ErrNoRows {
+			_ = tx.Rollback()
+			return fmt.Errorf("check foreign keys: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) release(conn *sql.Conn) {
+	if m.dialect.Name() == DriverSQLite {
+		_, _ = conn.ExecContext(context.Background(), `PRAGMA foreign_keys = ON`)
+	}
+	_ = conn.Close()
+}
+
+func (m *Migrator) ensureTable() error {
+	if _, err := m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			name TEXT PRIMARY KEY,
+			checksum TEXT NOT NULL DEFAULT '',
+			applied_at TEXT NOT NULL
+		)
+	`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	// Tables created before checksums were tracked lack the column.
+	if _, err := m.db.Exec(`SELECT checksum FROM schema_migrations WHERE 1 = 0`); err != nil {
+		if _, err := m.db.Exec(`ALTER TABLE schema_migrations ADD COLUMN checksum TEXT NOT NULL DEFAULT ''`); err != nil {
+			return fmt.Errorf("add schema_migrations checksum: %w", err)
+		}
+	}
+	return m.adoptLegacy()
+}
+
+// adoptLegacy renames rows recorded as "NNN_name.sql" to their version and
+// trusts the current file's checksum for rows that never had one.
+func (m *Migrator) adoptLegacy() error {
+	rows, err := m.db.Query(`SELECT name, checksum FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("list schema_migrations: %w", err)
+	}
+	type legacyRow struct {
+		name     string
+		checksum string
+	}
+	legacy := []legacyRow{}
+	for rows.Next() {
+		var row legacyRow
+		if err := rows.Scan(&row.name, &row.checksum); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("scan schema_migrations: %w", err)
+		}
+		if strings.HasSuffix(row.name, legacyMigrationSuffix) || row.checksum == "" {
+			legacy = append(legacy, row)
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("close schema_migrations rows: %w", err)
+	}
+
+	for _, row := range legacy {
+		version := strings.TrimSuffix(row.name, legacyMigrationSuffix)
+		checksum := row.checksum
+		if migration, ok := m.find(version); ok && checksum == "" {
+			checksum = migration.Checksum
+		}
+		if _, err := m.db.Exec(
+			m.dialect.Rebind(`UPDATE schema_migrations SET name = ?, checksum = ? WHERE name = ?`),
+			version,
+			checksum,
+			row.name,
+		); err != nil {
+			return fmt.Errorf("adopt legacy migration %s: %w", row.name, err)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) applied() (map[string]appliedMigration, error) {
+	rows, err := m.db.Query(`SELECT name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("list applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[string]appliedMigration{}
+	for rows.Next() {
+		var name string
+		var record appliedMigration
+		if err := rows.Scan(&name, &record.checksum, &record.appliedAt); err != nil {
+			return nil, fmt.Errorf("scan applied migration: %w", err)
+		}
+		applied[name] = record
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate applied migrations: %w", err)
+	}
+	return applied, nil
+}
+
+func (m *Migrator) find(version string) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// CreateMigration writes an empty up/down pair named after the next free
+// version into each of dirs, so every dialect's set gets the same version.
+func CreateMigration(name string, dirs ...string) ([]string, error) {
+	slug := strings.Trim(migrationNameCleaner.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if slug == "" {
+		return nil, fmt.Errorf("migration name must contain letters or digits")
+	}
+
+	next := 1
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("read migrations dir: %w", err)
+		}
+		for _, entry := range entries {
+			prefix, _, _ := strings.Cut(entry.Name(), "_")
+			if number, err := strconv.Atoi(prefix); err == nil && number >= next {
+				next = number + 1
+			}
+		}
+	}
+
+	version := fmt.Sprintf("%0*d_%s", migrationVersionWidth, next, slug)
+	paths := []string{}
+	for _, dir := range dirs {
+		for _, suffix := range []string{migrationUpSuffix, migrationDownSuffix} {
+			path := filepath.Join(dir, version+suffix)
+			file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+			if err != nil {
+				return paths, fmt.Errorf("create migration file: %w", err)
+			}
+			if err := file.Close(); err != nil {
+				return paths, fmt.Errorf("close migration file: %w", err)
+			}
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+func hasFile(source fs.FS, name string) bool {
+	_, err := fs.Stat(source, name)
+	return err == nil
+}
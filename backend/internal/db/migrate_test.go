@@ -0,0 +1,210 @@
+package db_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"pomodoro/backend/internal/db"
+)
+
+func migrationSource() fstest.MapFS {
+	return fstest.MapFS{
+		"001_create_a.up.sql":   {Data: []byte(`CREATE TABLE a (id INTEGER PRIMARY KEY);`)},
+		"001_create_a.down.sql": {Data: []byte(`DROP TABLE a;`)},
+		"002_create_b.up.sql":   {Data: []byte(`CREATE TABLE b (id INTEGER PRIMARY KEY, a_id INTEGER REFERENCES a(id));`)},
+		"002_create_b.down.sql": {Data: []byte(`DROP TABLE b;`)},
+		"003_create_c.up.sql":   {Data: []byte(`CREATE TABLE c (id INTEGER PRIMARY KEY);`)},
+		"003_create_c.down.sql": {Data: []byte(`DROP TABLE c;`)},
+	}
+}
+
+func openMigrationDB(t *testing.T) *sql.DB {
+	t.Helper()
+	database, err := db.OpenSQLite(filepath.Join(t.TempDir(), "migrate.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	return database
+}
+
+func newMigrator(t *testing.T, database *sql.DB, source fstest.MapFS) *db.Migrator {
+	t.Helper()
+	migrator, err := db.NewMigrator(database, db.SQLiteDialect{}, source)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	return migrator
+}
+
+func tableExists(t *testing.T, database *sql.DB, name string) bool {
+	t.Helper()
+	var count int
+	if err := database.QueryRow(`SELECT COUNT(1) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&count); err != nil {
+		t.Fatalf("look up table %s: %v", name, err)
+	}
+	return count == 1
+}
+
+func migrationStates(t *testing.T, migrator *db.Migrator) map[string]string {
+	t.Helper()
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("migration status: %v", err)
+	}
+	states := map[string]string{}
+	for _, status := range statuses {
+		states[status.Version] = status.State
+	}
+	return states
+}
+
+func TestMigratorUpDownUp(t *testing.T) {
+	database := openMigrationDB(t)
+	migrator := newMigrator(t, database, migrationSource())
+
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	if want := []string{"001_create_a", "002_create_b", "003_create_c"}; !reflect.DeepEqual(applied, want) {
+		t.Fatalf("expected %v applied, got %v", want, applied)
+	}
+	applied, err = migrator.Up()
+	if err != nil || len(applied) != 0 {
+		t.Fatalf("expected a second up to apply nothing, got %v (%v)", applied, err)
+	}
+
+	if _, err := migrator.Down(0); err == nil {
+		t.Fatal("expected down with no steps to fail")
+	}
+	reverted, err := migrator.Down(2)
+	if err != nil {
+		t.Fatalf("migrate down: %v", err)
+	}
+	if want := []string{"003_create_c", "002_create_b"}; !reflect.DeepEqual(reverted, want) {
+		t.Fatalf("expected %v reverted, got %v", want, reverted)
+	}
+	if !tableExists(t, database, "a") || tableExists(t, database, "b") || tableExists(t, database, "c") {
+		t.Fatal("expected only table a after rolling back two steps")
+	}
+	states := migrationStates(t, migrator)
+	if states["001_create_a"] != db.MigrationApplied ||
+		states["002_create_b"] != db.MigrationPending ||
+		states["003_create_c"] != db.MigrationPending {
+		t.Fatalf("unexpected states after down: %v", states)
+	}
+
+	applied, err = migrator.Up()
+	if err != nil {
+		t.Fatalf("migrate up again: %v", err)
+	}
+	if want := []string{"002_create_b", "003_create_c"}; !reflect.DeepEqual(applied, want) {
+		t.Fatalf("expected %v reapplied, got %v", want, applied)
+	}
+	if !tableExists(t, database, "b") || !tableExists(t, database, "c") {
+		t.Fatal("expected tables b and c after migrating up again")
+	}
+}
+
+func TestMigratorRefusesModifiedMigration(t *testing.T) {
+	database := openMigrationDB(t)
+	if _, err := newMigrator(t, database, migrationSource()).Up(); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+
+	source := migrationSource()
+	source["002_create_b.up.sql"] = &fstest.MapFile{Data: []byte(`CREATE TABLE b (id INTEGER PRIMARY KEY, note TEXT);`)}
+	source["004_create_d.up.sql"] = &fstest.MapFile{Data: []byte(`CREATE TABLE d (id INTEGER PRIMARY KEY);`)}
+	source["004_create_d.down.sql"] = &fstest.MapFile{Data: []byte(`DROP TABLE d;`)}
+	migrator := newMigrator(t, database, source)
+
+	if _, err := migrator.Up(); err == nil || !strings.Contains(err.Error(), "002_create_b was modified") {
+		t.Fatalf("expected up to refuse the modified migration, got %v", err)
+	}
+	if tableExists(t, database, "d") {
+		t.Fatal("expected no pending migration to run after the refusal")
+	}
+	if _, err := migrator.Down(1); err == nil {
+		t.Fatal("expected down to refuse the modified migration")
+	}
+	if !tableExists(t, database, "c") {
+		t.Fatal("expected the refused down to leave the schema alone")
+	}
+	if state := migrationStates(t, migrator)["002_create_b"]; state != db.MigrationModified {
+		t.Fatalf("expected 002_create_b to be reported as modified, got %s", state)
+	}
+}
+
+func TestMigratorAdoptsLegacyRows(t *testing.T) {
+	database := openMigrationDB(t)
+	// The layout written before up/down pairs and checksums existed.
+	if _, err := database.Exec(`
+		CREATE TABLE schema_migrations (name TEXT PRIMARY KEY, applied_at TEXT NOT NULL);
+		CREATE TABLE a (id INTEGER PRIMARY KEY);
+		INSERT INTO schema_migrations (name, applied_at) VALUES ('001_create_a.sql', '2024-01-01T00:00:00Z');
+	`); err != nil {
+		t.Fatalf("create legacy schema: %v", err)
+	}
+
+	migrator := newMigrator(t, database, migrationSource())
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatalf("migrate up over legacy rows: %v", err)
+	}
+	if want := []string{"002_create_b", "003_create_c"}; !reflect.DeepEqual(applied, want) {
+		t.Fatalf("expected %v applied, got %v", want, applied)
+	}
+
+	var name, checksum string
+	if err := database.QueryRow(`SELECT name, checksum FROM schema_migrations WHERE name LIKE '001%'`).Scan(&name, &checksum); err != nil {
+		t.Fatalf("read adopted row: %v", err)
+	}
+	if name != "001_create_a" || checksum == "" {
+		t.Fatalf("expected the legacy row renamed with a checksum, got %s %q", name, checksum)
+	}
+	if state := migrationStates(t, migrator)["001_create_a"]; state != db.MigrationApplied {
+		t.Fatalf("expected the adopted migration to be applied, got %s", state)
+	}
+
+	if _, err := migrator.Down(3); err != nil {
+		t.Fatalf("roll back including the adopted migration: %v", err)
+	}
+	if tableExists(t, database, "a") {
+		t.Fatal("expected the adopted migration to roll back like any other")
+	}
+}
+
+func TestMigratorFailedDownRollsBack(t *testing.T) {
+	database := openMigrationDB(t)
+	source := migrationSource()
+	source["003_create_c.down.sql"] = &fstest.MapFile{Data: []byte(`DROP TABLE c; DROP TABLE missing;`)}
+	migrator := newMigrator(t, database, source)
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+
+	reverted, err := migrator.Down(2)
+	if err == nil || !strings.Contains(err.Error(), "003_create_c") {
+		t.Fatalf("expected the down migration to fail, got %v", err)
+	}
+	if len(reverted) != 0 {
+		t.Fatalf("expected nothing reverted, got %v", reverted)
+	}
+	if !tableExists(t, database, "c") || !tableExists(t, database, "b") {
+		t.Fatal("expected the failed down to leave every table in place")
+	}
+	if state := migrationStates(t, migrator)["003_create_c"]; state != db.MigrationApplied {
+		t.Fatalf("expected 003_create_c to stay applied, got %s", state)
+	}
+
+	// The connection must be usable with foreign keys back on afterwards.
+	var enabled int
+	if err := database.QueryRow(`PRAGMA foreign_keys`).Scan(&enabled); err != nil || enabled != 1 {
+		t.Fatalf("expected foreign keys enabled after the failed rollback, got %d (%v)", enabled, err)
+	}
+}
@@ -0,0 +1,29 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+const postgresMaxOpenConns = 20
+
+func OpenPostgres(dsn string) (*sql.DB, error) {
+	database, err := sql.Open(DriverPostgres, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open postgres: %w", err)
+	}
+
+	database.SetMaxOpenConns(postgresMaxOpenConns)
+	database.SetMaxIdleConns(postgresMaxOpenConns / 2)
+	database.SetConnMaxIdleTime(5 * time.Minute)
+
+	if err := database.Ping(); err != nil {
+		_ = database.Close()
+		return nil, fmt.Errorf("ping postgres: %w", err)
+	}
+
+	return database, nil
+}
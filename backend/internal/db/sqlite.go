@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -38,79 +37,23 @@ func OpenSQLite(path string) (*sql.DB, error) {
 	return database, nil
 }
 
-func RunMigrations(database *sql.DB, migrationsDir string) error {
-	if _, err := database.Exec(`
-		CREATE TABLE IF NOT EXISTS schema_migrations (
-			name TEXT PRIMARY KEY,
-			applied_at TEXT NOT NULL
-		)
-	`); err != nil {
-		return fmt.Errorf("create schema_migrations: %w", err)
-	}
-
-	entries, err := os.ReadDir(migrationsDir)
+// Open connects to the configured driver; source is a file path for SQLite
+// and a connection URL for Postgres.
+func Open(driver, source string) (*sql.DB, Dialect, error) {
+	dialect, err := DialectFor(driver)
 	if err != nil {
-		return fmt.Errorf("read migrations dir: %w", err)
+		return nil, nil, err
 	}
 
-	files := make([]string, 0, len(entries))
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-		files = append(files, entry.Name())
+	var database *sql.DB
+	switch driver {
+	case DriverPostgres:
+		database, err = OpenPostgres(source)
+	default:
+		database, err = OpenSQLite(source)
 	}
-	sort.Strings(files)
-
-	for _, name := range files {
-		applied, err := isMigrationApplied(database, name)
-		if err != nil {
-			return err
-		}
-		if applied {
-			continue
-		}
-
-		path := filepath.Join(migrationsDir, name)
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return fmt.Errorf("read migration %s: %w", name, err)
-		}
-
-		tx, err := database.Begin()
-		if err != nil {
-			return fmt.Errorf("begin migration tx %s: %w", name, err)
-		}
-
-		if _, err := tx.Exec(string(content)); err != nil {
-			_ = tx.Rollback()
-			return fmt.Errorf("execute migration %s: %w", name, err)
-		}
-
-		if _, err := tx.Exec(
-			`INSERT INTO schema_migrations (name, applied_at) VALUES (?, ?)`,
-			name,
-			time.Now().UTC().Format(time.RFC3339Nano),
-		); err != nil {
-			_ = tx.Rollback()
-			return fmt.Errorf("record migration %s: %w", name, err)
-		}
-
-		if err := tx.Commit(); err != nil {
-			return fmt.Errorf("commit migration %s: %w", name, err)
-		}
-	}
-
-	return nil
-}
-
-func isMigrationApplied(database *sql.DB, name string) (bool, error) {
-	var count int
-	if err := database.QueryRow(
-		`SELECT COUNT(1) FROM schema_migrations WHERE name = ?`,
-		name,
-	).Scan(&count); err != nil {
-		return false, fmt.Errorf("check migration %s: %w", name, err)
+	if err != nil {
+		return nil, nil, err
 	}
-	return count > 0, nil
+	return database, dialect, nil
 }
@@ -1,12 +1,17 @@
 package errors
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 type APIError struct {
 	Status  int         `json:"-"`
 	Code    string      `json:"code"`
 	Message string      `json:"message"`
 	Details interface{} `json:"details,omitempty"`
+	// RetryAfter is sent as the Retry-After header when set.
+	RetryAfter time.Duration `json:"-"`
 }
 
 func (e *APIError) Error() string {
@@ -55,3 +60,19 @@ func Conflict(code, message string, details interface{}) *APIError {
 	err.Details = details
 	return err
 }
+
+func TooManyRequests(code, message string, retryAfter time.Duration) *APIError {
+	err := New(http.StatusTooManyRequests, code, message)
+	err.RetryAfter = retryAfter
+	return err
+}
+
+// RetryAfterSeconds rounds RetryAfter up to whole seconds, as the header
+// requires.
+func (e *APIError) RetryAfterSeconds() int {
+	seconds := int((e.RetryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"pomodoro/backend/internal/middleware"
+	"pomodoro/backend/internal/service"
+)
+
+type AccountHandler struct {
+	accountService *service.AccountService
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+type changeEmailRequest struct {
+	Email           string `json:"email"`
+	CurrentPassword string `json:"currentPassword"`
+}
+
+type deleteAccountRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+}
+
+func NewAccountHandler(accountService *service.AccountService) *AccountHandler {
+	return &AccountHandler{accountService: accountService}
+}
+
+func (h *AccountHandler) Get(c *gin.Context) {
+	user, apiErr := h.accountService.Get(c.Request.Context(), middleware.UserID(c))
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+func (h *AccountHandler) ChangePassword(c *gin.Context) {
+	var req changePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "invalid_json",
+				"message": "invalid request body",
+			},
+		})
+		return
+	}
+
+	apiErr := h.accountService.ChangePassword(
+		c.Request.Context(),
+		middleware.UserID(c),
+		middleware.TokenID(c),
+		req.CurrentPassword,
+		req.NewPassword,
+	)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AccountHandler) ChangeEmail(c *gin.Context) {
+	var req changeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "invalid_json",
+				"message": "invalid request body",
+			},
+		})
+		return
+	}
+
+	user, apiErr := h.accountService.ChangeEmail(c.Request.Context(), middleware.UserID(c), req.Email, req.CurrentPassword)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+func (h *AccountHandler) Delete(c *gin.Context) {
+	var req deleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "invalid_json",
+				"message": "invalid request body",
+			},
+		})
+		return
+	}
+
+	if apiErr := h.accountService.Delete(c.Request.Context(), middleware.UserID(c), req.CurrentPassword); apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
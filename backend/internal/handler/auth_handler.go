@@ -5,20 +5,46 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"pomodoro/backend/internal/middleware"
 	"pomodoro/backend/internal/service"
 )
 
 type AuthHandler struct {
-	authService *service.AuthService
+	authService    *service.AuthService
+	accountService *service.AccountService
 }
 
 type authRequest struct {
-	Email    string `json:"email"`
+	Email    string         `json:"email"`
+	Password string         `json:"password"`
+	Device   *deviceRequest `json:"device"`
+}
+
+type deviceRequest struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Platform string `json:"platform"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token"`
 	Password string `json:"password"`
 }
 
-func NewAuthHandler(authService *service.AuthService) *AuthHandler {
-	return &AuthHandler{authService: authService}
+type verifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+func NewAuthHandler(authService *service.AuthService, accountService *service.AccountService) *AuthHandler {
+	return &AuthHandler{authService: authService, accountService: accountService}
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -33,12 +59,16 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	result, apiErr := h.authService.Register(c.Request.Context(), req.Email, req.Password)
+	result, apiErr := h.authService.Register(c.Request.Context(), req.Email, req.Password, deviceInput(c, req.Device))
 	if apiErr != nil {
 		writeError(c, apiErr)
 		return
 	}
 
+	// The account exists at this point; if the mail cannot be sent the
+	// client can ask for another one through /auth/email/verification.
+	_ = h.accountService.SendEmailVerification(c.Request.Context(), result.User.ID)
+
 	c.JSON(http.StatusCreated, result)
 }
 
@@ -54,7 +84,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	result, apiErr := h.authService.Login(c.Request.Context(), req.Email, req.Password)
+	result, apiErr := h.authService.Login(c.Request.Context(), req.Email, req.Password, deviceInput(c, req.Device))
 	if apiErr != nil {
 		writeError(c, apiErr)
 		return
@@ -62,3 +92,113 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	c.JSON(http.StatusOK, result)
 }
+
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "invalid_json",
+				"message": "invalid request body",
+			},
+		})
+		return
+	}
+
+	result, apiErr := h.authService.Refresh(c.Request.Context(), req.RefreshToken)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if apiErr := h.authService.Logout(c.Request.Context(), middleware.TokenID(c)); apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req forgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "invalid_json",
+				"message": "invalid request body",
+			},
+		})
+		return
+	}
+
+	if apiErr := h.accountService.ForgotPassword(c.Request.Context(), req.Email); apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req resetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "invalid_json",
+				"message": "invalid request body",
+			},
+		})
+		return
+	}
+
+	if apiErr := h.accountService.ResetPassword(c.Request.Context(), req.Token, req.Password); apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req verifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "invalid_json",
+				"message": "invalid request body",
+			},
+		})
+		return
+	}
+
+	user, apiErr := h.accountService.VerifyEmail(c.Request.Context(), req.Token)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+func (h *AuthHandler) SendEmailVerification(c *gin.Context) {
+	if apiErr := h.accountService.SendEmailVerification(c.Request.Context(), middleware.UserID(c)); apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+func deviceInput(c *gin.Context, req *deviceRequest) service.DeviceInput {
+	input := service.DeviceInput{IP: c.ClientIP()}
+	if req != nil {
+		input.ID = req.ID
+		input.Name = req.Name
+		input.Platform = req.Platform
+	}
+	return input
+}
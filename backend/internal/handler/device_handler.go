@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"pomodoro/backend/internal/middleware"
+	"pomodoro/backend/internal/service"
+)
+
+type DeviceHandler struct {
+	deviceService *service.DeviceService
+}
+
+func NewDeviceHandler(deviceService *service.DeviceService) *DeviceHandler {
+	return &DeviceHandler{deviceService: deviceService}
+}
+
+func (h *DeviceHandler) List(c *gin.Context) {
+	userID := middleware.UserID(c)
+	devices, apiErr := h.deviceService.List(c.Request.Context(), userID)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"devices":         devices,
+		"currentDeviceId": middleware.DeviceID(c),
+	})
+}
+
+func (h *DeviceHandler) Delete(c *gin.Context) {
+	userID := middleware.UserID(c)
+	if apiErr := h.deviceService.Revoke(c.Request.Context(), userID, c.Param("id")); apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"pomodoro/backend/internal/middleware"
+	"pomodoro/backend/internal/service"
+)
+
+type ModeHandler struct {
+	modeService *service.ModeService
+}
+
+type modeRequest struct {
+	Key             string `json:"key"`
+	Name            string `json:"name"`
+	DurationSeconds int    `json:"durationSeconds"`
+	Color           string `json:"color"`
+	CountsAsFocus   bool   `json:"countsAsFocus"`
+}
+
+func NewModeHandler(modeService *service.ModeService) *ModeHandler {
+	return &ModeHandler{modeService: modeService}
+}
+
+func (h *ModeHandler) List(c *gin.Context) {
+	userID := middleware.UserID(c)
+	modes, apiErr := h.modeService.List(c.Request.Context(), userID)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"modes": modes})
+}
+
+func (h *ModeHandler) Create(c *gin.Context) {
+	var req modeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_json", "message": "invalid request body"},
+		})
+		return
+	}
+
+	userID := middleware.UserID(c)
+	mode, apiErr := h.modeService.Create(c.Request.Context(), userID, req.input())
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"mode": mode})
+}
+
+func (h *ModeHandler) Get(c *gin.Context) {
+	userID := middleware.UserID(c)
+	mode, apiErr := h.modeService.Get(c.Request.Context(), userID, c.Param("id"))
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"mode": mode})
+}
+
+func (h *ModeHandler) Update(c *gin.Context) {
+	var req modeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_json", "message": "invalid request body"},
+		})
+		return
+	}
+
+	userID := middleware.UserID(c)
+	mode, apiErr := h.modeService.Update(c.Request.Context(), userID, c.Param("id"), req.input())
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"mode": mode})
+}
+
+func (h *ModeHandler) Delete(c *gin.Context) {
+	userID := middleware.UserID(c)
+	if apiErr := h.modeService.Delete(c.Request.Context(), userID, c.Param("id")); apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (r modeRequest) input() service.ModeInput {
+	return service.ModeInput{
+		Key:             r.Key,
+		Name:            r.Name,
+		DurationSeconds: r.DurationSeconds,
+		Color:           r.Color,
+		CountsAsFocus:   r.CountsAsFocus,
+	}
+}
@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -10,6 +14,12 @@ import (
 	"pomodoro/backend/internal/service"
 )
 
+const (
+	sseHeartbeatInterval = 15 * time.Second
+	sseRetryMillis       = 3000
+	maxImportBytes       = middleware.MaxRequestBodyBytes
+)
+
 type PomodoroHandler struct {
 	pomodoroService *service.PomodoroService
 }
@@ -23,11 +33,40 @@ type switchModeRequest struct {
 	Mode        string `json:"mode"`
 }
 
+type setCurrentTaskRequest struct {
+	BaseVersion int     `json:"baseVersion"`
+	TaskID      *string `json:"taskId"`
+}
+
+type syncCommandRequest struct {
+	ID   string    `json:"id"`
+	Type string    `json:"type"`
+	Mode string    `json:"mode"`
+	At   time.Time `json:"at"`
+}
+
+type syncRequest struct {
+	SentAt   *time.Time           `json:"sentAt"`
+	Commands []syncCommandRequest `json:"commands"`
+}
+
 type updateSettingsRequest struct {
-	BaseVersion               int `json:"baseVersion"`
-	FocusDurationSeconds      int `json:"focusDurationSeconds"`
-	ShortBreakDurationSeconds int `json:"shortBreakDurationSeconds"`
-	LongBreakDurationSeconds  int `json:"longBreakDurationSeconds"`
+	BaseVersion               int   `json:"baseVersion"`
+	FocusDurationSeconds      int   `json:"focusDurationSeconds"`
+	ShortBreakDurationSeconds int   `json:"shortBreakDurationSeconds"`
+	LongBreakDurationSeconds  int   `json:"longBreakDurationSeconds"`
+	LongBreakInterval         *int  `json:"longBreakInterval"`
+	AutoStartBreaks           *bool `json:"autoStartBreaks"`
+	AutoStartFocus            *bool `json:"autoStartFocus"`
+}
+
+type updateGoalsRequest struct {
+	TimeZone             string `json:"timeZone"`
+	DailyPomodoros       *int   `json:"dailyPomodoros"`
+	DailyFocusSeconds    *int   `json:"dailyFocusSeconds"`
+	WeeklyPomodoros      *int   `json:"weeklyPomodoros"`
+	WeeklyFocusSeconds   *int   `json:"weeklyFocusSeconds"`
+	DailyMaxFocusSeconds *int   `json:"dailyMaxFocusSeconds"`
 }
 
 func NewPomodoroHandler(pomodoroService *service.PomodoroService) *PomodoroHandler {
@@ -168,6 +207,9 @@ func (h *PomodoroHandler) UpdateSettings(c *gin.Context) {
 		FocusDurationSeconds:      req.FocusDurationSeconds,
 		ShortBreakDurationSeconds: req.ShortBreakDurationSeconds,
 		LongBreakDurationSeconds:  req.LongBreakDurationSeconds,
+		LongBreakInterval:         req.LongBreakInterval,
+		AutoStartBreaks:           req.AutoStartBreaks,
+		AutoStartFocus:            req.AutoStartFocus,
 	})
 	if apiErr != nil {
 		writeError(c, apiErr)
@@ -176,6 +218,61 @@ func (h *PomodoroHandler) UpdateSettings(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"state": state})
 }
 
+func (h *PomodoroHandler) SetCurrentTask(c *gin.Context) {
+	var req setCurrentTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_json", "message": "invalid request body"},
+		})
+		return
+	}
+	if req.BaseVersion <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_base_version", "message": "baseVersion is required"},
+		})
+		return
+	}
+
+	userID := middleware.UserID(c)
+	state, apiErr := h.pomodoroService.SetCurrentTask(c.Request.Context(), userID, req.TaskID, req.BaseVersion)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"state": state})
+}
+
+func (h *PomodoroHandler) Sync(c *gin.Context) {
+	var req syncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_json", "message": "invalid request body"},
+		})
+		return
+	}
+
+	input := service.SyncInput{
+		SentAt:   req.SentAt,
+		Commands: make([]service.SyncCommand, 0, len(req.Commands)),
+	}
+	for _, command := range req.Commands {
+		input.Commands = append(input.Commands, service.SyncCommand{
+			ID:   command.ID,
+			Type: command.Type,
+			Mode: command.Mode,
+			At:   command.At,
+		})
+	}
+
+	userID := middleware.UserID(c)
+	result, apiErr := h.pomodoroService.Sync(c.Request.Context(), userID, input)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
 func (h *PomodoroHandler) GetHistory(c *gin.Context) {
 	userID := middleware.UserID(c)
 	if userID == "" {
@@ -185,18 +282,269 @@ func (h *PomodoroHandler) GetHistory(c *gin.Context) {
 		return
 	}
 
-	limit := 50
-	rawLimit := c.Query("limit")
-	if rawLimit != "" {
+	query := service.HistoryQuery{
+		Cursor: c.Query("cursor"),
+		Mode:   c.Query("mode"),
+		Status: c.Query("status"),
+		From:   c.Query("from"),
+		To:     c.Query("to"),
+	}
+	if rawLimit := c.Query("limit"); rawLimit != "" {
 		if parsed, err := strconv.Atoi(rawLimit); err == nil {
-			limit = parsed
+			query.Limit = parsed
+		}
+	}
+
+	page, apiErr := h.pomodoroService.GetHistory(c.Request.Context(), userID, query)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// Export streams the user's sessions as a download. Errors can only be
+// reported as JSON until the first byte is written; after that the
+// response is cut short.
+func (h *PomodoroHandler) Export(c *gin.Context) {
+	userID := middleware.UserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": gin.H{"code": "unauthorized", "message": "unauthorized"},
+		})
+		return
+	}
+
+	format := c.DefaultQuery("format", service.ExportFormatJSON)
+	contentType, apiErr := service.ExportContentType(format)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+
+	filename := fmt.Sprintf("pomodoro-sessions-%s.%s", time.Now().UTC().Format("20060102"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "no-store")
+
+	if apiErr := h.pomodoroService.ExportSessions(c.Request.Context(), userID, format, c.Writer); apiErr != nil {
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			writeError(c, apiErr)
 		}
+		return
 	}
+}
 
-	sessions, apiErr := h.pomodoroService.GetHistory(c.Request.Context(), userID, limit)
+// Import reads the format from ?format=, falling back to the request's
+// Content-Type, so an export file can be posted back as is.
+func (h *PomodoroHandler) Import(c *gin.Context) {
+	userID := middleware.UserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": gin.H{"code": "unauthorized", "message": "unauthorized"},
+		})
+		return
+	}
+	if c.Request.ContentLength > maxImportBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": gin.H{"code": "import_too_large", "message": "import body is too large"},
+		})
+		return
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = service.ExportFormatJSON
+		if strings.Contains(c.ContentType(), "csv") {
+			format = service.ExportFormatCSV
+		}
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	report, apiErr := h.pomodoroService.ImportSessions(c.Request.Context(), userID, format, body)
 	if apiErr != nil {
 		writeError(c, apiErr)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *PomodoroHandler) GetEventLog(c *gin.Context) {
+	userID := middleware.UserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": gin.H{"code": "unauthorized", "message": "unauthorized"},
+		})
+		return
+	}
+
+	query := service.EventLogQuery{
+		Cursor:    c.Query("cursor"),
+		SessionID: c.Query("sessionId"),
+		From:      c.Query("from"),
+		To:        c.Query("to"),
+	}
+	if rawLimit := c.Query("limit"); rawLimit != "" {
+		if parsed, err := strconv.Atoi(rawLimit); err == nil {
+			query.Limit = parsed
+		}
+	}
+
+	page, apiErr := h.pomodoroService.GetEventLog(c.Request.Context(), userID, query)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *PomodoroHandler) Events(c *gin.Context) {
+	userID := middleware.UserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": gin.H{"code": "unauthorized", "message": "unauthorized"},
+		})
+		return
+	}
+
+	lastVersion := 0
+	if rawLastEventID := strings.TrimSpace(c.GetHeader("Last-Event-ID")); rawLastEventID != "" {
+		if parsed, err := strconv.Atoi(rawLastEventID); err == nil && parsed > 0 {
+			lastVersion = parsed
+		}
+	}
+
+	// Subscribe before reading the current state so no change can slip in
+	// between the snapshot and the first pushed update.
+	updates, unsubscribe := h.pomodoroService.Subscribe(userID)
+	defer unsubscribe()
+
+	state, apiErr := h.pomodoroService.GetState(c.Request.Context(), userID)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if _, err := fmt.Fprintf(c.Writer, "retry: %d\n\n", sseRetryMillis); err != nil {
+		return
+	}
+	if state.Version > lastVersion {
+		if err := writeStateEvent(c, *state); err != nil {
+			return
+		}
+		lastVersion = state.Version
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case view, ok := <-updates:
+			if !ok {
+				return
+			}
+			if view.Version <= lastVersion {
+				continue
+			}
+			if err := writeStateEvent(c, view); err != nil {
+				return
+			}
+			lastVersion = view.Version
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+func writeStateEvent(c *gin.Context, view service.StateView) error {
+	payload, err := json.Marshal(view)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: state\ndata: %s\n\n", view.Version, payload)
+	return err
+}
+
+func (h *PomodoroHandler) GetStats(c *gin.Context) {
+	userID := middleware.UserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": gin.H{"code": "unauthorized", "message": "unauthorized"},
+		})
+		return
+	}
+
+	query := service.StatsQuery{TimeZone: c.Query("tz")}
+	if rawDays := c.Query("days"); rawDays != "" {
+		if parsed, err := strconv.Atoi(rawDays); err == nil {
+			query.Days = parsed
+		}
+	}
+
+	stats, apiErr := h.pomodoroService.GetStats(c.Request.Context(), userID, query)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"stats": stats})
+}
+
+func (h *PomodoroHandler) GetGoals(c *gin.Context) {
+	userID := middleware.UserID(c)
+	goals, apiErr := h.pomodoroService.GetGoals(c.Request.Context(), userID)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, goals)
+}
+
+func (h *PomodoroHandler) UpdateGoals(c *gin.Context) {
+	var req updateGoalsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_json", "message": "invalid request body"},
+		})
+		return
+	}
+
+	userID := middleware.UserID(c)
+	goals, apiErr := h.pomodoroService.UpdateGoals(c.Request.Context(), userID, service.UpdateGoalsInput{
+		TimeZone:             req.TimeZone,
+		DailyPomodoros:       req.DailyPomodoros,
+		DailyFocusSeconds:    req.DailyFocusSeconds,
+		WeeklyPomodoros:      req.WeeklyPomodoros,
+		WeeklyFocusSeconds:   req.WeeklyFocusSeconds,
+		DailyMaxFocusSeconds: req.DailyMaxFocusSeconds,
+	})
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, goals)
+}
+
+func (h *PomodoroHandler) DeleteGoals(c *gin.Context) {
+	userID := middleware.UserID(c)
+	if apiErr := h.pomodoroService.DeleteGoals(c.Request.Context(), userID); apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
 }
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/middleware"
+	"pomodoro/backend/internal/service"
+)
+
+const (
+	socketCommandStart    = "start"
+	socketCommandPause    = "pause"
+	socketCommandReset    = "reset"
+	socketCommandMode     = "mode"
+	socketCommandSettings = "settings"
+
+	socketMessageState = "state"
+	socketMessageError = "error"
+)
+
+type socketCommand struct {
+	ID                        string `json:"id,omitempty"`
+	Type                      string `json:"type"`
+	BaseVersion               int    `json:"baseVersion"`
+	Mode                      string `json:"mode,omitempty"`
+	FocusDurationSeconds      int    `json:"focusDurationSeconds,omitempty"`
+	ShortBreakDurationSeconds int    `json:"shortBreakDurationSeconds,omitempty"`
+	LongBreakDurationSeconds  int    `json:"longBreakDurationSeconds,omitempty"`
+	LongBreakInterval         *int   `json:"longBreakInterval,omitempty"`
+	AutoStartBreaks           *bool  `json:"autoStartBreaks,omitempty"`
+	AutoStartFocus            *bool  `json:"autoStartFocus,omitempty"`
+}
+
+type socketMessage struct {
+	ID    string             `json:"id,omitempty"`
+	Type  string             `json:"type"`
+	State *service.StateView `json:"state,omitempty"`
+	Error gin.H              `json:"error,omitempty"`
+}
+
+func (h *PomodoroHandler) WebSocket(c *gin.Context) {
+	userID := middleware.UserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": gin.H{"code": "unauthorized", "message": "unauthorized"},
+		})
+		return
+	}
+
+	// Browsers do not apply CORS to sockets, so the handshake checks the
+	// Origin against the same policy and refuses other sites with 403.
+	server := websocket.Server{
+		Handshake: func(config *websocket.Config, _ *http.Request) error {
+			if !middleware.OriginAllowed(c) {
+				return fmt.Errorf("origin %s not allowed", config.Origin)
+			}
+			return nil
+		},
+		Handler: func(conn *websocket.Conn) {
+			h.serveSocket(c.Request.Context(), conn, userID)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *PomodoroHandler) serveSocket(ctx context.Context, conn *websocket.Conn, userID string) {
+	defer conn.Close()
+
+	updates, unsubscribe := h.pomodoroService.Subscribe(userID)
+	defer unsubscribe()
+
+	state, apiErr := h.pomodoroService.GetState(ctx, userID)
+	if apiErr != nil {
+		_ = websocket.JSON.Send(conn, socketMessage{Type: socketMessageError, Error: errorBody(apiErr)})
+		return
+	}
+	if err := websocket.JSON.Send(conn, socketMessage{Type: socketMessageState, State: state}); err != nil {
+		return
+	}
+	lastVersion := state.Version
+
+	commands := make(chan json.RawMessage)
+	go func() {
+		defer close(commands)
+		for {
+			var raw json.RawMessage
+			if err := websocket.JSON.Receive(conn, &raw); err != nil {
+				return
+			}
+			select {
+			case commands <- raw:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case raw, ok := <-commands:
+			if !ok {
+				return
+			}
+			reply := h.handleSocketCommand(ctx, userID, raw)
+			if reply.State != nil && reply.State.Version > lastVersion {
+				lastVersion = reply.State.Version
+			}
+			if err := websocket.JSON.Send(conn, reply); err != nil {
+				return
+			}
+		case view, ok := <-updates:
+			if !ok {
+				return
+			}
+			if view.Version <= lastVersion {
+				continue
+			}
+			lastVersion = view.Version
+			if err := websocket.JSON.Send(conn, socketMessage{Type: socketMessageState, State: &view}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *PomodoroHandler) handleSocketCommand(ctx context.Context, userID string, raw json.RawMessage) socketMessage {
+	var cmd socketCommand
+	if err := json.Unmarshal(raw, &cmd); err != nil {
+		return socketMessage{
+			Type:  socketMessageError,
+			Error: errorBody(apperrors.BadRequest("invalid_json", "invalid command body")),
+		}
+	}
+
+	state, apiErr := h.applySocketCommand(ctx, userID, cmd)
+	if apiErr != nil {
+		return socketMessage{ID: cmd.ID, Type: socketMessageError, Error: errorBody(apiErr)}
+	}
+	return socketMessage{ID: cmd.ID, Type: socketMessageState, State: state}
+}
+
+func (h *PomodoroHandler) applySocketCommand(ctx context.Context, userID string, cmd socketCommand) (*service.StateView, *apperrors.APIError) {
+	if cmd.BaseVersion <= 0 {
+		return nil, apperrors.BadRequest("invalid_base_version", "baseVersion is required")
+	}
+
+	switch cmd.Type {
+	case socketCommandStart:
+		return h.pomodoroService.Start(ctx, userID, cmd.BaseVersion)
+	case socketCommandPause:
+		return h.pomodoroService.Pause(ctx, userID, cmd.BaseVersion)
+	case socketCommandReset:
+		return h.pomodoroService.Reset(ctx, userID, cmd.BaseVersion)
+	case socketCommandMode:
+		return h.pomodoroService.SwitchMode(ctx, userID, cmd.Mode, cmd.BaseVersion)
+	case socketCommandSettings:
+		return h.pomodoroService.UpdateSettings(ctx, userID, service.UpdateSettingsInput{
+			BaseVersion:               cmd.BaseVersion,
+			FocusDurationSeconds:      cmd.FocusDurationSeconds,
+			ShortBreakDurationSeconds: cmd.ShortBreakDurationSeconds,
+			LongBreakDurationSeconds:  cmd.LongBreakDurationSeconds,
+			LongBreakInterval:         cmd.LongBreakInterval,
+			AutoStartBreaks:           cmd.AutoStartBreaks,
+			AutoStartFocus:            cmd.AutoStartFocus,
+		})
+	default:
+		return nil, apperrors.BadRequest("invalid_command", "type must be one of start, pause, reset, mode, settings")
+	}
+}
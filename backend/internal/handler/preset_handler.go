@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"pomodoro/backend/internal/middleware"
+	"pomodoro/backend/internal/service"
+)
+
+type PresetHandler struct {
+	presetService *service.PresetService
+}
+
+type presetRequest struct {
+	Name                      string `json:"name"`
+	FocusDurationSeconds      int    `json:"focusDurationSeconds"`
+	ShortBreakDurationSeconds int    `json:"shortBreakDurationSeconds"`
+	LongBreakDurationSeconds  int    `json:"longBreakDurationSeconds"`
+	LongBreakInterval         *int   `json:"longBreakInterval"`
+	AutoStartBreaks           *bool  `json:"autoStartBreaks"`
+	AutoStartFocus            *bool  `json:"autoStartFocus"`
+}
+
+func NewPresetHandler(presetService *service.PresetService) *PresetHandler {
+	return &PresetHandler{presetService: presetService}
+}
+
+func (h *PresetHandler) List(c *gin.Context) {
+	userID := middleware.UserID(c)
+	presets, apiErr := h.presetService.List(c.Request.Context(), userID)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"presets": presets})
+}
+
+func (h *PresetHandler) Create(c *gin.Context) {
+	var req presetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_json", "message": "invalid request body"},
+		})
+		return
+	}
+
+	userID := middleware.UserID(c)
+	preset, apiErr := h.presetService.Create(c.Request.Context(), userID, req.input())
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"preset": preset})
+}
+
+func (h *PresetHandler) Get(c *gin.Context) {
+	userID := middleware.UserID(c)
+	preset, apiErr := h.presetService.Get(c.Request.Context(), userID, c.Param("id"))
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"preset": preset})
+}
+
+func (h *PresetHandler) Update(c *gin.Context) {
+	var req presetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_json", "message": "invalid request body"},
+		})
+		return
+	}
+
+	userID := middleware.UserID(c)
+	preset, apiErr := h.presetService.Update(c.Request.Context(), userID, c.Param("id"), req.input())
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"preset": preset})
+}
+
+func (h *PresetHandler) Delete(c *gin.Context) {
+	userID := middleware.UserID(c)
+	if apiErr := h.presetService.Delete(c.Request.Context(), userID, c.Param("id")); apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *PresetHandler) Activate(c *gin.Context) {
+	var req versionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_json", "message": "invalid request body"},
+		})
+		return
+	}
+	if req.BaseVersion <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_base_version", "message": "baseVersion is required"},
+		})
+		return
+	}
+
+	userID := middleware.UserID(c)
+	state, apiErr := h.presetService.Activate(c.Request.Context(), userID, c.Param("id"), req.BaseVersion)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"state": state})
+}
+
+func (r presetRequest) input() service.PresetInput {
+	return service.PresetInput{
+		Name:                      r.Name,
+		FocusDurationSeconds:      r.FocusDurationSeconds,
+		ShortBreakDurationSeconds: r.ShortBreakDurationSeconds,
+		LongBreakDurationSeconds:  r.LongBreakDurationSeconds,
+		LongBreakInterval:         r.LongBreakInterval,
+		AutoStartBreaks:           r.AutoStartBreaks,
+		AutoStartFocus:            r.AutoStartFocus,
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
@@ -19,15 +20,21 @@ func writeError(c *gin.Context, apiErr *apperrors.APIError) {
 		return
 	}
 
-	errorBody := gin.H{
+	if apiErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(apiErr.RetryAfterSeconds()))
+	}
+	c.JSON(apiErr.Status, gin.H{
+		"error": errorBody(apiErr),
+	})
+}
+
+func errorBody(apiErr *apperrors.APIError) gin.H {
+	body := gin.H{
 		"code":    apiErr.Code,
 		"message": apiErr.Message,
 	}
 	if apiErr.Details != nil {
-		errorBody["details"] = apiErr.Details
+		body["details"] = apiErr.Details
 	}
-
-	c.JSON(apiErr.Status, gin.H{
-		"error": errorBody,
-	})
+	return body
 }
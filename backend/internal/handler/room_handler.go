@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"pomodoro/backend/internal/middleware"
+	"pomodoro/backend/internal/service"
+)
+
+type RoomHandler struct {
+	roomService *service.RoomService
+}
+
+type createRoomRequest struct {
+	Name                      string `json:"name"`
+	FocusDurationSeconds      int    `json:"focusDurationSeconds"`
+	ShortBreakDurationSeconds int    `json:"shortBreakDurationSeconds"`
+	LongBreakDurationSeconds  int    `json:"longBreakDurationSeconds"`
+	LongBreakInterval         *int   `json:"longBreakInterval"`
+	AutoStartBreaks           bool   `json:"autoStartBreaks"`
+	AutoStartFocus            bool   `json:"autoStartFocus"`
+}
+
+type joinRoomRequest struct {
+	InviteCode string `json:"inviteCode"`
+}
+
+func NewRoomHandler(roomService *service.RoomService) *RoomHandler {
+	return &RoomHandler{roomService: roomService}
+}
+
+func (h *RoomHandler) List(c *gin.Context) {
+	userID := middleware.UserID(c)
+	rooms, apiErr := h.roomService.List(c.Request.Context(), userID)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rooms": rooms})
+}
+
+func (h *RoomHandler) Create(c *gin.Context) {
+	var req createRoomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_json", "message": "invalid request body"},
+		})
+		return
+	}
+
+	userID := middleware.UserID(c)
+	room, state, apiErr := h.roomService.Create(c.Request.Context(), userID, service.CreateRoomInput{
+		Name:                      req.Name,
+		FocusDurationSeconds:      req.FocusDurationSeconds,
+		ShortBreakDurationSeconds: req.ShortBreakDurationSeconds,
+		LongBreakDurationSeconds:  req.LongBreakDurationSeconds,
+		LongBreakInterval:         req.LongBreakInterval,
+		AutoStartBreaks:           req.AutoStartBreaks,
+		AutoStartFocus:            req.AutoStartFocus,
+	})
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"room": room, "state": state})
+}
+
+func (h *RoomHandler) Get(c *gin.Context) {
+	userID := middleware.UserID(c)
+	room, members, apiErr := h.roomService.Get(c.Request.Context(), userID, c.Param("id"))
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"room": room, "members": members})
+}
+
+func (h *RoomHandler) Delete(c *gin.Context) {
+	userID := middleware.UserID(c)
+	if apiErr := h.roomService.Delete(c.Request.Context(), userID, c.Param("id")); apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *RoomHandler) Join(c *gin.Context) {
+	var req joinRoomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_json", "message": "invalid request body"},
+		})
+		return
+	}
+
+	userID := middleware.UserID(c)
+	room, apiErr := h.roomService.Join(c.Request.Context(), userID, req.InviteCode)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"room": room})
+}
+
+func (h *RoomHandler) RotateInviteCode(c *gin.Context) {
+	userID := middleware.UserID(c)
+	room, apiErr := h.roomService.RotateInviteCode(c.Request.Context(), userID, c.Param("id"))
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"room": room})
+}
+
+func (h *RoomHandler) RemoveMember(c *gin.Context) {
+	userID := middleware.UserID(c)
+	if apiErr := h.roomService.RemoveMember(c.Request.Context(), userID, c.Param("id"), c.Param("userId")); apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *RoomHandler) GetState(c *gin.Context) {
+	userID := middleware.UserID(c)
+	state, apiErr := h.roomService.GetState(c.Request.Context(), userID, c.Param("id"))
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"state": state})
+}
+
+func (h *RoomHandler) Start(c *gin.Context) {
+	var req versionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_json", "message": "invalid request body"},
+		})
+		return
+	}
+	if req.BaseVersion <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_base_version", "message": "baseVersion is required"},
+		})
+		return
+	}
+
+	userID := middleware.UserID(c)
+	state, apiErr := h.roomService.Start(c.Request.Context(), userID, c.Param("id"), req.BaseVersion)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"state": state})
+}
+
+func (h *RoomHandler) Pause(c *gin.Context) {
+	var req versionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_json", "message": "invalid request body"},
+		})
+		return
+	}
+	if req.BaseVersion <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_base_version", "message": "baseVersion is required"},
+		})
+		return
+	}
+
+	userID := middleware.UserID(c)
+	state, apiErr := h.roomService.Pause(c.Request.Context(), userID, c.Param("id"), req.BaseVersion)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"state": state})
+}
+
+func (h *RoomHandler) Reset(c *gin.Context) {
+	var req versionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_json", "message": "invalid request body"},
+		})
+		return
+	}
+	if req.BaseVersion <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_base_version", "message": "baseVersion is required"},
+		})
+		return
+	}
+
+	userID := middleware.UserID(c)
+	state, apiErr := h.roomService.Reset(c.Request.Context(), userID, c.Param("id"), req.BaseVersion)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"state": state})
+}
+
+func (h *RoomHandler) SwitchMode(c *gin.Context) {
+	var req switchModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_json", "message": "invalid request body"},
+		})
+		return
+	}
+	if req.BaseVersion <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_base_version", "message": "baseVersion is required"},
+		})
+		return
+	}
+
+	userID := middleware.UserID(c)
+	state, apiErr := h.roomService.SwitchMode(c.Request.Context(), userID, c.Param("id"), req.Mode, req.BaseVersion)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"state": state})
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"pomodoro/backend/internal/middleware"
+	"pomodoro/backend/internal/service"
+)
+
+type TaskHandler struct {
+	taskService *service.TaskService
+}
+
+type createTaskRequest struct {
+	Title              string `json:"title"`
+	Notes              string `json:"notes"`
+	EstimatedPomodoros int    `json:"estimatedPomodoros"`
+}
+
+type updateTaskRequest struct {
+	Title              *string `json:"title"`
+	Notes              *string `json:"notes"`
+	Status             *string `json:"status"`
+	EstimatedPomodoros *int    `json:"estimatedPomodoros"`
+}
+
+func NewTaskHandler(taskService *service.TaskService) *TaskHandler {
+	return &TaskHandler{taskService: taskService}
+}
+
+func (h *TaskHandler) List(c *gin.Context) {
+	userID := middleware.UserID(c)
+	tasks, apiErr := h.taskService.List(c.Request.Context(), userID, c.Query("status"))
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tasks": tasks})
+}
+
+func (h *TaskHandler) Create(c *gin.Context) {
+	var req createTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_json", "message": "invalid request body"},
+		})
+		return
+	}
+
+	userID := middleware.UserID(c)
+	task, apiErr := h.taskService.Create(c.Request.Context(), userID, service.CreateTaskInput{
+		Title:              req.Title,
+		Notes:              req.Notes,
+		EstimatedPomodoros: req.EstimatedPomodoros,
+	})
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"task": task})
+}
+
+func (h *TaskHandler) Get(c *gin.Context) {
+	userID := middleware.UserID(c)
+	task, apiErr := h.taskService.Get(c.Request.Context(), userID, c.Param("id"))
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"task": task})
+}
+
+func (h *TaskHandler) Update(c *gin.Context) {
+	var req updateTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_json", "message": "invalid request body"},
+		})
+		return
+	}
+
+	userID := middleware.UserID(c)
+	task, apiErr := h.taskService.Update(c.Request.Context(), userID, c.Param("id"), service.UpdateTaskInput{
+		Title:              req.Title,
+		Notes:              req.Notes,
+		Status:             req.Status,
+		EstimatedPomodoros: req.EstimatedPomodoros,
+	})
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"task": task})
+}
+
+func (h *TaskHandler) Delete(c *gin.Context) {
+	userID := middleware.UserID(c)
+	if apiErr := h.taskService.Delete(c.Request.Context(), userID, c.Param("id")); apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
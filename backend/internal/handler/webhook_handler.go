@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"pomodoro/backend/internal/middleware"
+	"pomodoro/backend/internal/service"
+)
+
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+}
+
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+func NewWebhookHandler(webhookService *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+func (h *WebhookHandler) List(c *gin.Context) {
+	userID := middleware.UserID(c)
+	webhooks, apiErr := h.webhookService.List(c.Request.Context(), userID)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks})
+}
+
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_json", "message": "invalid request body"},
+		})
+		return
+	}
+
+	userID := middleware.UserID(c)
+	webhook, apiErr := h.webhookService.Create(c.Request.Context(), userID, req.input())
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"webhook": webhook})
+}
+
+func (h *WebhookHandler) Get(c *gin.Context) {
+	userID := middleware.UserID(c)
+	webhook, apiErr := h.webhookService.Get(c.Request.Context(), userID, c.Param("id"))
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhook": webhook})
+}
+
+func (h *WebhookHandler) Update(c *gin.Context) {
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{"code": "invalid_json", "message": "invalid request body"},
+		})
+		return
+	}
+
+	userID := middleware.UserID(c)
+	webhook, apiErr := h.webhookService.Update(c.Request.Context(), userID, c.Param("id"), req.input())
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhook": webhook})
+}
+
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	userID := middleware.UserID(c)
+	if apiErr := h.webhookService.Delete(c.Request.Context(), userID, c.Param("id")); apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	userID := middleware.UserID(c)
+	webhook, apiErr := h.webhookService.RotateSecret(c.Request.Context(), userID, c.Param("id"))
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhook": webhook})
+}
+
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	userID := middleware.UserID(c)
+	query := service.DeliveryLogQuery{
+		Cursor: c.Query("cursor"),
+		Status: c.Query("status"),
+	}
+	if rawLimit := c.Query("limit"); rawLimit != "" {
+		if parsed, err := strconv.Atoi(rawLimit); err == nil {
+			query.Limit = parsed
+		}
+	}
+
+	page, apiErr := h.webhookService.ListDeliveries(c.Request.Context(), userID, c.Param("id"), query)
+	if apiErr != nil {
+		writeError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func (r webhookRequest) input() service.WebhookInput {
+	return service.WebhookInput{
+		URL:    r.URL,
+		Events: r.Events,
+		Active: r.Active,
+	}
+}
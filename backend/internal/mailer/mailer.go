@@ -0,0 +1,58 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+const (
+	DriverSMTP   = "smtp"
+	DriverOutbox = "outbox"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages; services depend on this interface only.
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// render builds the RFC 5322 form of message. Recipients are parsed as
+// addresses, so header injection through them is not possible.
+func render(from string, message Message, now time.Time) ([]byte, string, error) {
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, "", fmt.Errorf("parse sender %q: %w", from, err)
+	}
+	recipient, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return nil, "", fmt.Errorf("parse recipient %q: %w", message.To, err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", sender.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", recipient.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+
+	body := strings.ReplaceAll(message.Body, "\r\n", "\n")
+	buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	if !strings.HasSuffix(body, "\n") {
+		buf.WriteString("\r\n")
+	}
+	return buf.Bytes(), recipient.Address, nil
+}
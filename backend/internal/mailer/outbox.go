@@ -0,0 +1,52 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// OutboxMailer is for local development and tests: every message is written
+// to its own .eml file in dir, or to the log when dir is empty. File names
+// sort in the order the messages were sent.
+type OutboxMailer struct {
+	dir  string
+	from string
+}
+
+func NewOutboxMailer(dir, from string) (*OutboxMailer, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create outbox dir: %w", err)
+		}
+	}
+	return &OutboxMailer{dir: dir, from: from}, nil
+}
+
+func (m *OutboxMailer) Send(_ context.Context, message Message) error {
+	now := time.Now().UTC()
+	data, recipient, err := render(m.from, message, now)
+	if err != nil {
+		return err
+	}
+
+	if m.dir == "" {
+		log.Printf("outbox: mail to %s\n%s", recipient, data)
+		return nil
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("name outbox message: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102T150405.000000000Z"), hex.EncodeToString(suffix))
+	if err := os.WriteFile(filepath.Join(m.dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("write outbox message: %w", err)
+	}
+	return nil
+}
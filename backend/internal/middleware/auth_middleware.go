@@ -1,7 +1,11 @@
 package middleware
 
 import (
+	"context"
+	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -9,7 +13,12 @@ import (
 	"pomodoro/backend/internal/service"
 )
 
-const UserIDContextKey = "userID"
+const (
+	UserIDContextKey   = "userID"
+	TokenIDContextKey  = "tokenID"
+	DeviceIDContextKey = "deviceID"
+	TokenExpiryKey     = "tokenExpiresAt"
+)
 
 func Auth(authService *service.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -30,13 +39,64 @@ func Auth(authService *service.AuthService) gin.HandlerFunc {
 			return
 		}
 
-		userID, apiErr := authService.ParseToken(token)
+		claims, apiErr := authService.Authenticate(c.Request.Context(), token, c.ClientIP())
 		if apiErr != nil {
 			writeError(c, apiErr)
 			return
 		}
 
-		c.Set(UserIDContextKey, userID)
+		c.Set(UserIDContextKey, claims.UserID)
+		c.Set(TokenIDContextKey, claims.TokenID)
+		c.Set(TokenExpiryKey, claims.ExpiresAt)
+		if claims.DeviceID != "" {
+			c.Set(DeviceIDContextKey, claims.DeviceID)
+			c.Request = c.Request.WithContext(service.WithDeviceID(c.Request.Context(), claims.DeviceID))
+		}
+		c.Next()
+	}
+}
+
+// StreamAuth keeps checking the credentials of a long-lived request after Auth
+// let it in. The request context is cancelled once the access token expires
+// or its session is revoked, which ends event streams and sockets. A failed
+// check that is not an authentication error leaves the stream open.
+func StreamAuth(authService *service.AuthService, interval time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := UserID(c)
+		tokenID := TokenID(c)
+		ctx, cancel := context.WithCancel(c.Request.Context())
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		var expired <-chan time.Time
+		if value, ok := c.Get(TokenExpiryKey); ok {
+			if expiresAt, ok := value.(time.Time); ok && !expiresAt.IsZero() {
+				expiry := time.NewTimer(time.Until(expiresAt))
+				defer expiry.Stop()
+				expired = expiry.C
+			}
+		}
+
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-expired:
+					cancel()
+					return
+				case <-ticker.C:
+					apiErr := authService.CheckToken(ctx, userID, tokenID)
+					if apiErr != nil && apiErr.Status == http.StatusUnauthorized {
+						cancel()
+						return
+					}
+				}
+			}
+		}()
+
 		c.Next()
 	}
 }
@@ -53,7 +113,34 @@ func UserID(c *gin.Context) string {
 	return userID
 }
 
+func TokenID(c *gin.Context) string {
+	value, ok := c.Get(TokenIDContextKey)
+	if !ok {
+		return ""
+	}
+	tokenID, ok := value.(string)
+	if !ok {
+		return ""
+	}
+	return tokenID
+}
+
+func DeviceID(c *gin.Context) string {
+	value, ok := c.Get(DeviceIDContextKey)
+	if !ok {
+		return ""
+	}
+	deviceID, ok := value.(string)
+	if !ok {
+		return ""
+	}
+	return deviceID
+}
+
 func writeError(c *gin.Context, apiErr *apperrors.APIError) {
+	if apiErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(apiErr.RetryAfterSeconds()))
+	}
 	c.AbortWithStatusJSON(apiErr.Status, gin.H{
 		"error": gin.H{
 			"code":    apiErr.Code,
@@ -7,6 +7,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// OriginAllowedKey marks a request whose Origin the CORS policy accepts.
+const OriginAllowedKey = "originAllowed"
+
 func CORS(allowedOrigins []string) gin.HandlerFunc {
 	allowed := make(map[string]struct{}, len(allowedOrigins))
 	for _, origin := range allowedOrigins {
@@ -18,14 +21,16 @@ func CORS(allowedOrigins []string) gin.HandlerFunc {
 		if origin != "" {
 			if _, ok := allowed["*"]; ok {
 				c.Header("Access-Control-Allow-Origin", "*")
+				c.Set(OriginAllowedKey, true)
 			} else if _, ok := allowed[origin]; ok {
 				c.Header("Access-Control-Allow-Origin", origin)
 				c.Header("Vary", "Origin")
+				c.Set(OriginAllowedKey, true)
 			}
 		}
 
-		c.Header("Access-Control-Allow-Methods", "GET,POST,PUT,OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Authorization,Content-Type")
+		c.Header("Access-Control-Allow-Methods", "GET,POST,PUT,PATCH,DELETE,OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Authorization,Content-Type,Last-Event-ID,Idempotency-Key")
 		c.Header("Access-Control-Max-Age", "86400")
 
 		if c.Request.Method == http.MethodOptions {
@@ -36,3 +41,12 @@ func CORS(allowedOrigins []string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// OriginAllowed reports whether the request came without an Origin, as
+// from a native client, or from an origin the CORS policy accepts.
+func OriginAllowed(c *gin.Context) bool {
+	if c.GetHeader("Origin") == "" {
+		return true
+	}
+	return c.GetBool(OriginAllowedKey)
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/service"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	idempotentContentType    = "application/json; charset=utf-8"
+	// MaxRequestBodyBytes caps the bodies buffered here for hashing; it is
+	// the largest body any handler accepts, a session import.
+	MaxRequestBodyBytes = 32 << 20
+)
+
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// Idempotency replays the stored response when a request is retried with
+// the same Idempotency-Key header. Requests without the header pass through
+// untouched. It must run after Auth, since keys are scoped per user.
+func Idempotency(idempotencyService *service.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			writeError(c, apperrors.BadRequest("invalid_idempotency_key", "Idempotency-Key must be at most 255 characters"))
+			return
+		}
+
+		userID := UserID(c)
+		if userID == "" {
+			writeError(c, apperrors.Unauthorized(""))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MaxRequestBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeError(c, apperrors.New(http.StatusRequestEntityTooLarge, "request_too_large", "request body is too large"))
+				return
+			}
+			writeError(c, apperrors.BadRequest("invalid_body", "failed to read request body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "?" + c.Request.URL.RawQuery + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		ctx := c.Request.Context()
+		stored, apiErr := idempotencyService.Begin(ctx, userID, key, requestHash)
+		if apiErr != nil {
+			writeError(c, apiErr)
+			return
+		}
+		if stored != nil {
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(stored.StatusCode, idempotentContentType, []byte(stored.ResponseBody))
+			c.Abort()
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		// The response is already sent, so the key is settled even if the
+		// client has gone away in the meantime.
+		ctx = context.WithoutCancel(ctx)
+		if writer.Status() >= http.StatusInternalServerError {
+			_ = idempotencyService.Release(ctx, userID, key)
+			return
+		}
+		_ = idempotencyService.Complete(ctx, userID, key, writer.Status(), writer.body.String())
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/service"
+)
+
+// maxRateLimitBodyBytes caps how much of an auth request is read to find
+// the email; credentials are far smaller.
+const maxRateLimitBodyBytes = 64 << 10
+
+// AuthRateLimit throttles credential endpoints by client IP and by the email
+// in the JSON body, so neither many addresses from one client nor one
+// address from many clients gets through. Either limiter may be nil.
+func AuthRateLimit(ipLimiter, emailLimiter *service.RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ok, retryAfter := ipLimiter.Allow(c.ClientIP()); !ok {
+			writeRateLimited(c, retryAfter)
+			return
+		}
+
+		if emailLimiter != nil {
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxRateLimitBodyBytes))
+			if err != nil {
+				writeError(c, apperrors.BadRequest("invalid_body", "failed to read request body"))
+				return
+			}
+			c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+
+			var credentials struct {
+				Email string `json:"email"`
+			}
+			// A malformed body is left for the handler to reject.
+			if json.Unmarshal(body, &credentials) == nil {
+				email := strings.ToLower(strings.TrimSpace(credentials.Email))
+				if email != "" {
+					if ok, retryAfter := emailLimiter.Allow(email); !ok {
+						writeRateLimited(c, retryAfter)
+						return
+					}
+				}
+			}
+		}
+
+		c.Next()
+	}
+}
+
+func writeRateLimited(c *gin.Context, retryAfter time.Duration) {
+	writeError(c, apperrors.TooManyRequests("rate_limited", "too many requests, try again later", retryAfter))
+}
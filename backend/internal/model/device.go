@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+const DefaultDeviceName = "Unknown device"
+
+type Device struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"userId"`
+	Name       string     `json:"name"`
+	Platform   string     `json:"platform"`
+	LastIP     string     `json:"lastIp"`
+	LastSeenAt time.Time  `json:"lastSeenAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// FocusGoals are a user's targets for completed focus sessions. Targets
+// left nil are not tracked; days and weeks follow TimeZone, and weeks start
+// on Monday.
+type FocusGoals struct {
+	UserID               string    `json:"userId"`
+	TimeZone             string    `json:"timeZone"`
+	DailyPomodoros       *int      `json:"dailyPomodoros,omitempty"`
+	DailyFocusSeconds    *int      `json:"dailyFocusSeconds,omitempty"`
+	WeeklyPomodoros      *int      `json:"weeklyPomodoros,omitempty"`
+	WeeklyFocusSeconds   *int      `json:"weeklyFocusSeconds,omitempty"`
+	DailyMaxFocusSeconds *int      `json:"dailyMaxFocusSeconds,omitempty"`
+	UpdatedAt            time.Time `json:"updatedAt"`
+}
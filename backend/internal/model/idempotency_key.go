@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// IdempotencyKey holds the response to a request sent with an
+// Idempotency-Key header. CompletedAt is nil while the first request is still
+// being processed.
+type IdempotencyKey struct {
+	UserID       string
+	Key          string
+	RequestHash  string
+	StatusCode   int
+	ResponseBody string
+	CreatedAt    time.Time
+	CompletedAt  *time.Time
+	ExpiresAt    time.Time
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// LoginAttempt counts consecutive failed logins for an email address, which
+// need not belong to a registered user.
+type LoginAttempt struct {
+	Email        string
+	FailedCount  int
+	LastFailedAt time.Time
+	LockedUntil  *time.Time
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+const (
+	ColorFocus      = "#E5484D"
+	ColorShortBreak = "#30A46C"
+	ColorLongBreak  = "#0090FF"
+	// DefaultModeColor is used for custom modes created without a color.
+	DefaultModeColor = "#8B8D98"
+)
+
+// Mode is a user-defined timer mode next to the built-in focus, short_break
+// and long_break. Timers and sessions store its Key as their mode. Completed
+// sessions of a mode that CountsAsFocus are treated like focus sessions by
+// the cycle, stats, goals and task progress.
+type Mode struct {
+	ID              string    `json:"id"`
+	UserID          string    `json:"userId"`
+	Key             string    `json:"key"`
+	Name            string    `json:"name"`
+	DurationSeconds int       `json:"durationSeconds"`
+	Color           string    `json:"color"`
+	CountsAsFocus   bool      `json:"countsAsFocus"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
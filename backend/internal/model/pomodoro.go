@@ -10,12 +10,17 @@ const (
 	StatusIdle    = "idle"
 	StatusRunning = "running"
 	StatusPaused  = "paused"
+
+	SessionStatusRunning   = "running"
+	SessionStatusCompleted = "completed"
+	SessionStatusCancelled = "cancelled"
 )
 
 const (
 	DefaultFocusDurationSeconds      = 25 * 60
 	DefaultShortBreakDurationSeconds = 5 * 60
 	DefaultLongBreakDurationSeconds  = 15 * 60
+	DefaultLongBreakInterval         = 4
 )
 
 type PomodoroState struct {
@@ -26,21 +31,41 @@ type PomodoroState struct {
 	FocusDurationSeconds      int        `json:"focusDurationSeconds"`
 	ShortBreakDurationSeconds int        `json:"shortBreakDurationSeconds"`
 	LongBreakDurationSeconds  int        `json:"longBreakDurationSeconds"`
+	CompletedFocusCount       int        `json:"completedFocusCount"`
+	LongBreakInterval         int        `json:"longBreakInterval"`
+	AutoStartBreaks           bool       `json:"autoStartBreaks"`
+	AutoStartFocus            bool       `json:"autoStartFocus"`
+	CurrentTaskID             *string    `json:"currentTaskId,omitempty"`
 	StartedAt                 *time.Time `json:"startedAt,omitempty"`
 	SessionID                 *string    `json:"sessionId,omitempty"`
 	Version                   int        `json:"version"`
 	UpdatedAt                 time.Time  `json:"updatedAt"`
+	UpdatedByDeviceID         *string    `json:"updatedByDeviceId,omitempty"`
 }
 
 type PomodoroSession struct {
-	ID                     string     `json:"id"`
-	UserID                 string     `json:"userId"`
-	Mode                   string     `json:"mode"`
-	PlannedDurationSeconds int        `json:"plannedDurationSeconds"`
-	ActualDurationSeconds  int        `json:"actualDurationSeconds"`
-	StartedAt              time.Time  `json:"startedAt"`
-	EndedAt                *time.Time `json:"endedAt,omitempty"`
-	Status                 string     `json:"status"`
-	CreatedAt              time.Time  `json:"createdAt"`
-	UpdatedAt              time.Time  `json:"updatedAt"`
+	ID                     string         `json:"id"`
+	UserID                 string         `json:"userId"`
+	TaskID                 *string        `json:"taskId,omitempty"`
+	Mode                   string         `json:"mode"`
+	CountsAsFocus          bool           `json:"countsAsFocus"`
+	PlannedDurationSeconds int            `json:"plannedDurationSeconds"`
+	ActualDurationSeconds  int            `json:"actualDurationSeconds"`
+	StartedAt              time.Time      `json:"startedAt"`
+	EndedAt                *time.Time     `json:"endedAt,omitempty"`
+	Status                 string         `json:"status"`
+	PauseCount             int            `json:"pauseCount"`
+	PausedSeconds          int            `json:"pausedSeconds"`
+	Pauses                 []SessionPause `json:"pauses,omitempty"`
+	CreatedAt              time.Time      `json:"createdAt"`
+	UpdatedAt              time.Time      `json:"updatedAt"`
+}
+
+// SessionPause is one interruption of a session; ResumedAt stays nil while
+// the session is still paused.
+type SessionPause struct {
+	ID        string     `json:"id"`
+	SessionID string     `json:"sessionId"`
+	PausedAt  time.Time  `json:"pausedAt"`
+	ResumedAt *time.Time `json:"resumedAt,omitempty"`
 }
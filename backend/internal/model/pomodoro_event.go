@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+const (
+	EventStart          = "start"
+	EventPause          = "pause"
+	EventReset          = "reset"
+	EventSwitchMode     = "switch_mode"
+	EventUpdateSettings = "update_settings"
+	EventSetTask        = "set_task"
+	EventUpdateGoals    = "update_goals"
+	EventComplete       = "complete"
+	EventAutoStop       = "auto_stop"
+)
+
+// PomodoroEvent is an append-only record of one state transition. Events
+// written by the server when a session runs out carry no device.
+type PomodoroEvent struct {
+	ID         int64     `json:"id"`
+	UserID     string    `json:"userId"`
+	Type       string    `json:"type"`
+	FromStatus string    `json:"fromStatus"`
+	ToStatus   string    `json:"toStatus"`
+	FromMode   string    `json:"fromMode"`
+	ToMode     string    `json:"toMode"`
+	SessionID  *string   `json:"sessionId,omitempty"`
+	Version    int       `json:"version"`
+	DeviceID   *string   `json:"deviceId,omitempty"`
+	OccurredAt time.Time `json:"occurredAt"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
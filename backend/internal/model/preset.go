@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Preset is a named set of timer settings. The optional fields are left as
+// they are on the state when the preset is activated.
+type Preset struct {
+	ID                        string    `json:"id"`
+	UserID                    string    `json:"userId"`
+	Name                      string    `json:"name"`
+	FocusDurationSeconds      int       `json:"focusDurationSeconds"`
+	ShortBreakDurationSeconds int       `json:"shortBreakDurationSeconds"`
+	LongBreakDurationSeconds  int       `json:"longBreakDurationSeconds"`
+	LongBreakInterval         *int      `json:"longBreakInterval,omitempty"`
+	AutoStartBreaks           *bool     `json:"autoStartBreaks,omitempty"`
+	AutoStartFocus            *bool     `json:"autoStartFocus,omitempty"`
+	CreatedAt                 time.Time `json:"createdAt"`
+	UpdatedAt                 time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// RefreshToken shares its ID with the jti of the access token issued
+// alongside it, so revoking the row also invalidates that access token.
+type RefreshToken struct {
+	ID         string
+	UserID     string
+	DeviceID   *string
+	FamilyID   string
+	TokenHash  string
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+	ReplacedBy *string
+	CreatedAt  time.Time
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+const (
+	RoomRoleOwner  = "owner"
+	RoomRoleMember = "member"
+)
+
+// Room is a group of users following one shared timer. The timer is stored
+// with the room and loaded as a PomodoroState without a user.
+type Room struct {
+	ID         string    `json:"id"`
+	OwnerID    string    `json:"ownerId"`
+	Name       string    `json:"name"`
+	InviteCode string    `json:"inviteCode"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// RoomMember is one participant of a room. SessionID is the member's own
+// session for the current phase of the room timer, if any.
+type RoomMember struct {
+	RoomID    string    `json:"roomId"`
+	UserID    string    `json:"userId"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	SessionID *string   `json:"sessionId,omitempty"`
+	JoinedAt  time.Time `json:"joinedAt"`
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+const (
+	TaskStatusOpen = "open"
+	TaskStatusDone = "done"
+)
+
+type Task struct {
+	ID                 string    `json:"id"`
+	UserID             string    `json:"userId"`
+	Title              string    `json:"title"`
+	Notes              string    `json:"notes"`
+	Status             string    `json:"status"`
+	EstimatedPomodoros int       `json:"estimatedPomodoros"`
+	CompletedPomodoros int       `json:"completedPomodoros"`
+	CreatedAt          time.Time `json:"createdAt"`
+	UpdatedAt          time.Time `json:"updatedAt"`
+}
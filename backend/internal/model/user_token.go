@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+)
+
+// UserToken is a single-use token mailed to a user. Only its hash is
+// stored; Email is the address it was sent to, so a verification token
+// stops working once the account's email changes.
+type UserToken struct {
+	ID        string
+	UserID    string
+	Purpose   string
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	WebhookEventSessionStarted   = "session.started"
+	WebhookEventSessionPaused    = "session.paused"
+	WebhookEventSessionResumed   = "session.resumed"
+	WebhookEventSessionCompleted = "session.completed"
+	WebhookEventSessionCancelled = "session.cancelled"
+)
+
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusFailed    = "failed"
+)
+
+// Webhook subscribes a URL to session events of its user. The secret signs
+// every delivery and is only shown when it is created or rotated.
+type Webhook struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// WebhookDelivery is one event queued for one webhook. It is written in the
+// transaction that changed the session and then doubles as the delivery
+// log: the worker records every attempt on it.
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      string          `json:"webhookId"`
+	UserID         string          `json:"userId"`
+	EventID        string          `json:"eventId"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"nextAttemptAt,omitempty"`
+	LastAttemptAt  *time.Time      `json:"lastAttemptAt,omitempty"`
+	ResponseStatus *int            `json:"responseStatus,omitempty"`
+	LastError      *string         `json:"lastError,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty"`
+}
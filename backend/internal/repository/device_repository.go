@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pomodoro/backend/internal/db"
+	"pomodoro/backend/internal/model"
+)
+
+type DeviceRepository struct {
+	db      *sql.DB
+	dialect db.Dialect
+}
+
+func NewDeviceRepository(database *sql.DB, dialect db.Dialect) *DeviceRepository {
+	return &DeviceRepository{db: database, dialect: dialect}
+}
+
+func (r *DeviceRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	return tx, nil
+}
+
+func (r *DeviceRepository) Create(ctx context.Context, device *model.Device) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`INSERT INTO devices (
+			id, user_id, name, platform, last_ip, last_seen_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)`),
+		device.ID,
+		device.UserID,
+		device.Name,
+		device.Platform,
+		device.LastIP,
+		formatTime(device.LastSeenAt),
+		formatTime(device.CreatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("create device: %w", err)
+	}
+	return nil
+}
+
+func (r *DeviceRepository) GetByID(ctx context.Context, userID, deviceID string) (*model.Device, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, user_id, name, platform, last_ip, last_seen_at, created_at, revoked_at
+		 FROM devices
+		 WHERE id = ? AND user_id = ?`),
+		deviceID,
+		userID,
+	)
+	return scanDevice(row)
+}
+
+func (r *DeviceRepository) GetByIDTx(ctx context.Context, tx *sql.Tx, userID, deviceID string) (*model.Device, error) {
+	row := tx.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, user_id, name, platform, last_ip, last_seen_at, created_at, revoked_at
+		 FROM devices
+		 WHERE id = ? AND user_id = ?`),
+		deviceID,
+		userID,
+	)
+	return scanDevice(row)
+}
+
+func (r *DeviceRepository) ListActive(ctx context.Context, userID string) ([]model.Device, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, user_id, name, platform, last_ip, last_seen_at, created_at, revoked_at
+		 FROM devices
+		 WHERE user_id = ? AND revoked_at IS NULL
+		 ORDER BY last_seen_at DESC`),
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list devices: %w", err)
+	}
+	defer rows.Close()
+
+	devices := make([]model.Device, 0)
+	for rows.Next() {
+		device, scanErr := scanDevice(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		devices = append(devices, *device)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate devices: %w", err)
+	}
+
+	return devices, nil
+}
+
+func (r *DeviceRepository) UpdateDetails(ctx context.Context, device *model.Device) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE devices
+		 SET name = ?, platform = ?, last_ip = ?, last_seen_at = ?
+		 WHERE id = ? AND user_id = ? AND revoked_at IS NULL`),
+		device.Name,
+		device.Platform,
+		device.LastIP,
+		formatTime(device.LastSeenAt),
+		device.ID,
+		device.UserID,
+	)
+	if err != nil {
+		return fmt.Errorf("update device: %w", err)
+	}
+	return expectAffected(result, "update device")
+}
+
+// Touch records activity on a device, skipping the write when the device was
+// already seen from the same address after staleBefore.
+func (r *DeviceRepository) Touch(ctx context.Context, deviceID, ip string, now, staleBefore time.Time) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE devices
+		 SET last_seen_at = ?, last_ip = ?
+		 WHERE id = ? AND (last_seen_at < ? OR last_ip <> ?)`),
+		formatTime(now),
+		ip,
+		deviceID,
+		formatTime(staleBefore),
+		ip,
+	)
+	if err != nil {
+		return fmt.Errorf("touch device: %w", err)
+	}
+	return nil
+}
+
+func (r *DeviceRepository) RevokeTx(ctx context.Context, tx *sql.Tx, userID, deviceID string, now time.Time) error {
+	result, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE devices SET revoked_at = ?
+		 WHERE id = ? AND user_id = ? AND revoked_at IS NULL`),
+		formatTime(now),
+		deviceID,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("revoke device: %w", err)
+	}
+	return expectAffected(result, "revoke device")
+}
+
+func scanDevice(s scanner) (*model.Device, error) {
+	device := model.Device{}
+	var lastSeenAt string
+	var createdAt string
+	var revokedAt sql.NullString
+	err := s.Scan(
+		&device.ID,
+		&device.UserID,
+		&device.Name,
+		&device.Platform,
+		&device.LastIP,
+		&lastSeenAt,
+		&createdAt,
+		&revokedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan device: %w", err)
+	}
+
+	parsedLastSeenAt, err := parseTime(lastSeenAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse device last_seen_at: %w", err)
+	}
+	device.LastSeenAt = parsedLastSeenAt
+
+	parsedCreatedAt, err := parseTime(createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse device created_at: %w", err)
+	}
+	device.CreatedAt = parsedCreatedAt
+
+	if revokedAt.Valid {
+		parsedRevokedAt, parseErr := parseTime(revokedAt.String)
+		if parseErr != nil {
+			return nil, fmt.Errorf("parse device revoked_at: %w", parseErr)
+		}
+		device.RevokedAt = &parsedRevokedAt
+	}
+
+	return &device, nil
+}
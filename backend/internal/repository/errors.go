@@ -1,5 +1,23 @@
 package repository
 
-import "errors"
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
 
-var ErrNotFound = errors.New("not found")
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrDuplicate = errors.New("duplicate")
+)
+
+func expectAffected(result sql.Result, action string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s rows affected: %w", action, err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
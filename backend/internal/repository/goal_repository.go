@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"pomodoro/backend/internal/db"
+	"pomodoro/backend/internal/model"
+)
+
+type GoalRepository struct {
+	db      *sql.DB
+	dialect db.Dialect
+}
+
+func NewGoalRepository(database *sql.DB, dialect db.Dialect) *GoalRepository {
+	return &GoalRepository{db: database, dialect: dialect}
+}
+
+func (r *GoalRepository) GetTx(ctx context.Context, tx *sql.Tx, userID string) (*model.FocusGoals, error) {
+	row := tx.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT user_id, time_zone, daily_pomodoros, daily_focus_seconds,
+		        weekly_pomodoros, weekly_focus_seconds, daily_max_focus_seconds, updated_at
+		 FROM focus_goals
+		 WHERE user_id = ?`),
+		userID,
+	)
+
+	goals := model.FocusGoals{}
+	var dailyPomodoros sql.NullInt64
+	var dailyFocusSeconds sql.NullInt64
+	var weeklyPomodoros sql.NullInt64
+	var weeklyFocusSeconds sql.NullInt64
+	var dailyMaxFocusSeconds sql.NullInt64
+	var updatedAt string
+	err := row.Scan(
+		&goals.UserID,
+		&goals.TimeZone,
+		&dailyPomodoros,
+		&dailyFocusSeconds,
+		&weeklyPomodoros,
+		&weeklyFocusSeconds,
+		&dailyMaxFocusSeconds,
+		&updatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan focus goals: %w", err)
+	}
+	goals.DailyPomodoros = intPtr(dailyPomodoros)
+	goals.DailyFocusSeconds = intPtr(dailyFocusSeconds)
+	goals.WeeklyPomodoros = intPtr(weeklyPomodoros)
+	goals.WeeklyFocusSeconds = intPtr(weeklyFocusSeconds)
+	goals.DailyMaxFocusSeconds = intPtr(dailyMaxFocusSeconds)
+
+	parsedUpdatedAt, err := parseTime(updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse focus goals updated_at: %w", err)
+	}
+	goals.UpdatedAt = parsedUpdatedAt
+
+	return &goals, nil
+}
+
+func (r *GoalRepository) UpsertTx(ctx context.Context, tx *sql.Tx, goals *model.FocusGoals) error {
+	_, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`INSERT INTO focus_goals (
+			user_id, time_zone, daily_pomodoros, daily_focus_seconds, weekly_pomodoros,
+			weekly_focus_seconds, daily_max_focus_seconds, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (user_id) DO UPDATE SET
+		   time_zone = excluded.time_zone,
+		   daily_pomodoros = excluded.daily_pomodoros,
+		   daily_focus_seconds = excluded.daily_focus_seconds,
+		   weekly_pomodoros = excluded.weekly_pomodoros,
+		   weekly_focus_seconds = excluded.weekly_focus_seconds,
+		   daily_max_focus_seconds = excluded.daily_max_focus_seconds,
+		   updated_at = excluded.updated_at`),
+		goals.UserID,
+		goals.TimeZone,
+		nullableInt(goals.DailyPomodoros),
+		nullableInt(goals.DailyFocusSeconds),
+		nullableInt(goals.WeeklyPomodoros),
+		nullableInt(goals.WeeklyFocusSeconds),
+		nullableInt(goals.DailyMaxFocusSeconds),
+		formatTime(goals.UpdatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("upsert focus goals: %w", err)
+	}
+	return nil
+}
+
+func (r *GoalRepository) DeleteTx(ctx context.Context, tx *sql.Tx, userID string) error {
+	result, err := tx.ExecContext(ctx, r.dialect.Rebind(`DELETE FROM focus_goals WHERE user_id = ?`), userID)
+	if err != nil {
+		return fmt.Errorf("delete focus goals: %w", err)
+	}
+	return expectAffected(result, "delete focus goals")
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pomodoro/backend/internal/db"
+	"pomodoro/backend/internal/model"
+)
+
+type IdempotencyRepository struct {
+	db      *sql.DB
+	dialect db.Dialect
+}
+
+func NewIdempotencyRepository(database *sql.DB, dialect db.Dialect) *IdempotencyRepository {
+	return &IdempotencyRepository{db: database, dialect: dialect}
+}
+
+// Create claims a key; it returns ErrDuplicate when the user already holds
+// the key.
+func (r *IdempotencyRepository) Create(ctx context.Context, record *model.IdempotencyKey) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`INSERT INTO idempotency_keys (
+			user_id, idempotency_key, request_hash, created_at, expires_at
+		) VALUES (?, ?, ?, ?, ?)`),
+		record.UserID,
+		record.Key,
+		record.RequestHash,
+		formatTime(record.CreatedAt),
+		formatTime(record.ExpiresAt),
+	)
+	if err != nil {
+		if r.dialect.IsUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("create idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *IdempotencyRepository) Get(ctx context.Context, userID, key string) (*model.IdempotencyKey, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT user_id, idempotency_key, request_hash, status_code, response_body,
+		 created_at, completed_at, expires_at
+		 FROM idempotency_keys
+		 WHERE user_id = ? AND idempotency_key = ?`),
+		userID,
+		key,
+	)
+	return scanIdempotencyKey(row)
+}
+
+// Reclaim hands a pending key whose request was abandoned before
+// staleBefore to a new request.
+func (r *IdempotencyRepository) Reclaim(ctx context.Context, record *model.IdempotencyKey, staleBefore time.Time) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE idempotency_keys
+		 SET request_hash = ?, created_at = ?, expires_at = ?
+		 WHERE user_id = ? AND idempotency_key = ? AND completed_at IS NULL AND created_at < ?`),
+		record.RequestHash,
+		formatTime(record.CreatedAt),
+		formatTime(record.ExpiresAt),
+		record.UserID,
+		record.Key,
+		formatTime(staleBefore),
+	)
+	if err != nil {
+		return fmt.Errorf("reclaim idempotency key: %w", err)
+	}
+	return expectAffected(result, "reclaim idempotency key")
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, userID, key string, statusCode int, body string, now time.Time) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE idempotency_keys
+		 SET status_code = ?, response_body = ?, completed_at = ?
+		 WHERE user_id = ? AND idempotency_key = ? AND completed_at IS NULL`),
+		statusCode,
+		body,
+		formatTime(now),
+		userID,
+		key,
+	)
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	return expectAffected(result, "complete idempotency key")
+}
+
+func (r *IdempotencyRepository) Delete(ctx context.Context, userID, key string) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`DELETE FROM idempotency_keys WHERE user_id = ? AND idempotency_key = ?`),
+		userID,
+		key,
+	)
+	if err != nil {
+		return fmt.Errorf("delete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, userID string, now time.Time) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`DELETE FROM idempotency_keys WHERE user_id = ? AND expires_at <= ?`),
+		userID,
+		formatTime(now),
+	)
+	if err != nil {
+		return fmt.Errorf("delete expired idempotency keys: %w", err)
+	}
+	return nil
+}
+
+func scanIdempotencyKey(s scanner) (*model.IdempotencyKey, error) {
+	record := model.IdempotencyKey{}
+	var createdAt string
+	var completedAt sql.NullString
+	var expiresAt string
+	err := s.Scan(
+		&record.UserID,
+		&record.Key,
+		&record.RequestHash,
+		&record.StatusCode,
+		&record.ResponseBody,
+		&createdAt,
+		&completedAt,
+		&expiresAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan idempotency key: %w", err)
+	}
+
+	parsedCreatedAt, err := parseTime(createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse idempotency key created_at: %w", err)
+	}
+	record.CreatedAt = parsedCreatedAt
+
+	if completedAt.Valid {
+		parsedCompletedAt, parseErr := parseTime(completedAt.String)
+		if parseErr != nil {
+			return nil, fmt.Errorf("parse idempotency key completed_at: %w", parseErr)
+		}
+		record.CompletedAt = &parsedCompletedAt
+	}
+
+	parsedExpiresAt, err := parseTime(expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse idempotency key expires_at: %w", err)
+	}
+	record.ExpiresAt = parsedExpiresAt
+
+	return &record, nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pomodoro/backend/internal/db"
+	"pomodoro/backend/internal/model"
+)
+
+type LoginAttemptRepository struct {
+	db      *sql.DB
+	dialect db.Dialect
+}
+
+func NewLoginAttemptRepository(database *sql.DB, dialect db.Dialect) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: database, dialect: dialect}
+}
+
+func (r *LoginAttemptRepository) Get(ctx context.Context, email string) (*model.LoginAttempt, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT email, failed_count, last_failed_at, locked_until
+		 FROM login_attempts
+		 WHERE email = ?`),
+		email,
+	)
+
+	attempt := model.LoginAttempt{}
+	var lastFailedAt string
+	var lockedUntil sql.NullString
+	err := row.Scan(&attempt.Email, &attempt.FailedCount, &lastFailedAt, &lockedUntil)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan login attempt: %w", err)
+	}
+
+	parsedLastFailedAt, err := parseTime(lastFailedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse login attempt last_failed_at: %w", err)
+	}
+	attempt.LastFailedAt = parsedLastFailedAt
+
+	if lockedUntil.Valid {
+		parsedLockedUntil, parseErr := parseTime(lockedUntil.String)
+		if parseErr != nil {
+			return nil, fmt.Errorf("parse login attempt locked_until: %w", parseErr)
+		}
+		attempt.LockedUntil = &parsedLockedUntil
+	}
+
+	return &attempt, nil
+}
+
+// RecordFailure increments the failure count and returns it. A count whose
+// last failure happened before resetBefore starts over at one.
+func (r *LoginAttemptRepository) RecordFailure(ctx context.Context, email string, now, resetBefore time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`INSERT INTO login_attempts (email, failed_count, last_failed_at)
+		 VALUES (?, 1, ?)
+		 ON CONFLICT (email) DO UPDATE SET
+		   failed_count = CASE
+		     WHEN login_attempts.last_failed_at < ? THEN 1
+		     ELSE login_attempts.failed_count + 1
+		   END,
+		   last_failed_at = excluded.last_failed_at
+		 RETURNING failed_count`),
+		email,
+		formatTime(now),
+		formatTime(resetBefore),
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("record login failure: %w", err)
+	}
+	return count, nil
+}
+
+func (r *LoginAttemptRepository) Lock(ctx context.Context, email string, until time.Time) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE login_attempts SET locked_until = ? WHERE email = ?`),
+		formatTime(until),
+		email,
+	)
+	if err != nil {
+		return fmt.Errorf("lock login: %w", err)
+	}
+	return expectAffected(result, "lock login")
+}
+
+func (r *LoginAttemptRepository) Clear(ctx context.Context, email string) error {
+	return r.clear(ctx, r.db, email)
+}
+
+func (r *LoginAttemptRepository) ClearTx(ctx context.Context, tx *sql.Tx, email string) error {
+	return r.clear(ctx, tx, email)
+}
+
+func (r *LoginAttemptRepository) clear(ctx context.Context, exec execer, email string) error {
+	_, err := exec.ExecContext(
+		ctx,
+		r.dialect.Rebind(`DELETE FROM login_attempts WHERE email = ?`),
+		email,
+	)
+	if err != nil {
+		return fmt.Errorf("clear login attempts: %w", err)
+	}
+	return nil
+}
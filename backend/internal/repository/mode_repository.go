@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"pomodoro/backend/internal/db"
+	"pomodoro/backend/internal/model"
+)
+
+type ModeRepository struct {
+	db      *sql.DB
+	dialect db.Dialect
+}
+
+func NewModeRepository(database *sql.DB, dialect db.Dialect) *ModeRepository {
+	return &ModeRepository{db: database, dialect: dialect}
+}
+
+// Create returns ErrDuplicate when the user already has a mode with the
+// same key.
+func (r *ModeRepository) Create(ctx context.Context, mode *model.Mode) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`INSERT INTO modes (
+			id, user_id, mode, name, duration_seconds, color, counts_as_focus, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		mode.ID,
+		mode.UserID,
+		mode.Key,
+		mode.Name,
+		mode.DurationSeconds,
+		mode.Color,
+		mode.CountsAsFocus,
+		formatTime(mode.CreatedAt),
+		formatTime(mode.UpdatedAt),
+	)
+	if err != nil {
+		if r.dialect.IsUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("create mode: %w", err)
+	}
+	return nil
+}
+
+func (r *ModeRepository) GetByID(ctx context.Context, userID, modeID string) (*model.Mode, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, user_id, mode, name, duration_seconds, color, counts_as_focus,
+		        created_at, updated_at
+		 FROM modes
+		 WHERE id = ? AND user_id = ?`),
+		modeID,
+		userID,
+	)
+	return scanMode(row)
+}
+
+func (r *ModeRepository) GetByKeyTx(ctx context.Context, tx *sql.Tx, userID, key string) (*model.Mode, error) {
+	row := tx.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, user_id, mode, name, duration_seconds, color, counts_as_focus,
+		        created_at, updated_at
+		 FROM modes
+		 WHERE user_id = ? AND mode = ?`),
+		userID,
+		key,
+	)
+	return scanMode(row)
+}
+
+func (r *ModeRepository) List(ctx context.Context, userID string) ([]model.Mode, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, user_id, mode, name, duration_seconds, color, counts_as_focus,
+		        created_at, updated_at
+		 FROM modes
+		 WHERE user_id = ?
+		 ORDER BY name ASC`),
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list modes: %w", err)
+	}
+	defer rows.Close()
+
+	modes := make([]model.Mode, 0)
+	for rows.Next() {
+		mode, scanErr := scanMode(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		modes = append(modes, *mode)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate modes: %w", err)
+	}
+
+	return modes, nil
+}
+
+// Update leaves the key alone, since stored timers and sessions refer to
+// the mode by it.
+func (r *ModeRepository) Update(ctx context.Context, mode *model.Mode) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE modes
+		 SET name = ?,
+		     duration_seconds = ?,
+		     color = ?,
+		     counts_as_focus = ?,
+		     updated_at = ?
+		 WHERE id = ? AND user_id = ?`),
+		mode.Name,
+		mode.DurationSeconds,
+		mode.Color,
+		mode.CountsAsFocus,
+		formatTime(mode.UpdatedAt),
+		mode.ID,
+		mode.UserID,
+	)
+	if err != nil {
+		return fmt.Errorf("update mode: %w", err)
+	}
+	return expectAffected(result, "update mode")
+}
+
+func (r *ModeRepository) DeleteTx(ctx context.Context, tx *sql.Tx, userID, modeID string) error {
+	result, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`DELETE FROM modes WHERE id = ? AND user_id = ?`),
+		modeID,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("delete mode: %w", err)
+	}
+	return expectAffected(result, "delete mode")
+}
+
+func scanMode(s scanner) (*model.Mode, error) {
+	mode := model.Mode{}
+	var createdAt string
+	var updatedAt string
+	err := s.Scan(
+		&mode.ID,
+		&mode.UserID,
+		&mode.Key,
+		&mode.Name,
+		&mode.DurationSeconds,
+		&mode.Color,
+		&mode.CountsAsFocus,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan mode: %w", err)
+	}
+
+	parsedCreatedAt, err := parseTime(createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse mode created_at: %w", err)
+	}
+	mode.CreatedAt = parsedCreatedAt
+
+	parsedUpdatedAt, err := parseTime(updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse mode updated_at: %w", err)
+	}
+	mode.UpdatedAt = parsedUpdatedAt
+
+	return &mode, nil
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pomodoro/backend/internal/model"
+)
+
+type EventFilter struct {
+	SessionID string
+	From      *time.Time
+	To        *time.Time
+	BeforeID  int64
+	Limit     int
+}
+
+func (r *PomodoroRepository) InsertEventTx(ctx context.Context, tx *sql.Tx, event *model.PomodoroEvent) error {
+	err := tx.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`INSERT INTO pomodoro_events (
+			user_id, type, from_status, to_status, from_mode, to_mode,
+			session_id, version, device_id, occurred_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id`),
+		event.UserID,
+		event.Type,
+		event.FromStatus,
+		event.ToStatus,
+		event.FromMode,
+		event.ToMode,
+		nullableString(event.SessionID),
+		event.Version,
+		nullableString(event.DeviceID),
+		formatTime(event.OccurredAt),
+		formatTime(event.CreatedAt),
+	).Scan(&event.ID)
+	if err != nil {
+		return fmt.Errorf("insert pomodoro event: %w", err)
+	}
+	return nil
+}
+
+// LastEventTimeTx returns when the user's latest transition took effect, or
+// nil when none has been recorded.
+func (r *PomodoroRepository) LastEventTimeTx(ctx context.Context, tx *sql.Tx, userID string) (*time.Time, error) {
+	var occurredAt string
+	err := tx.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT occurred_at
+		 FROM pomodoro_events
+		 WHERE user_id = ?
+		 ORDER BY id DESC
+		 LIMIT 1`),
+		userID,
+	).Scan(&occurredAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get last pomodoro event: %w", err)
+	}
+	parsed, err := parseTime(occurredAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse pomodoro event occurred_at: %w", err)
+	}
+	return &parsed, nil
+}
+
+// LastEventTypeTx returns the type of the user's latest transition, or ""
+// when none has been recorded.
+func (r *PomodoroRepository) LastEventTypeTx(ctx context.Context, tx *sql.Tx, userID string) (string, error) {
+	var eventType string
+	err := tx.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT type
+		 FROM pomodoro_events
+		 WHERE user_id = ?
+		 ORDER BY id DESC
+		 LIMIT 1`),
+		userID,
+	).Scan(&eventType)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get last pomodoro event type: %w", err)
+	}
+	return eventType, nil
+}
+
+// ListEvents returns events newest first; BeforeID continues a previous page.
+func (r *PomodoroRepository) ListEvents(ctx context.Context, userID string, filter EventFilter) ([]model.PomodoroEvent, error) {
+	query := `SELECT id, user_id, type, from_status, to_status, from_mode, to_mode,
+	                 session_id, version, device_id, occurred_at, created_at
+	          FROM pomodoro_events
+	          WHERE user_id = ?`
+	args := []interface{}{userID}
+	if filter.SessionID != "" {
+		query += ` AND session_id = ?`
+		args = append(args, filter.SessionID)
+	}
+	if filter.From != nil {
+		query += ` AND occurred_at >= ?`
+		args = append(args, formatTime(*filter.From))
+	}
+	if filter.To != nil {
+		query += ` AND occurred_at < ?`
+		args = append(args, formatTime(*filter.To))
+	}
+	if filter.BeforeID > 0 {
+		query += ` AND id < ?`
+		args = append(args, filter.BeforeID)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("list pomodoro events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]model.PomodoroEvent, 0)
+	for rows.Next() {
+		event, scanErr := scanPomodoroEvent(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		events = append(events, *event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pomodoro events: %w", err)
+	}
+
+	return events, nil
+}
+
+func scanPomodoroEvent(s scanner) (*model.PomodoroEvent, error) {
+	event := model.PomodoroEvent{}
+	var sessionID sql.NullString
+	var deviceID sql.NullString
+	var occurredAt string
+	var createdAt string
+	err := s.Scan(
+		&event.ID,
+		&event.UserID,
+		&event.Type,
+		&event.FromStatus,
+		&event.ToStatus,
+		&event.FromMode,
+		&event.ToMode,
+		&sessionID,
+		&event.Version,
+		&deviceID,
+		&occurredAt,
+		&createdAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("scan pomodoro event: %w", err)
+	}
+	event.SessionID = stringPtr(sessionID)
+	event.DeviceID = stringPtr(deviceID)
+
+	parsedOccurredAt, err := parseTime(occurredAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse pomodoro event occurred_at: %w", err)
+	}
+	event.OccurredAt = parsedOccurredAt
+
+	parsedCreatedAt, err := parseTime(createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse pomodoro event created_at: %w", err)
+	}
+	event.CreatedAt = parsedCreatedAt
+
+	return &event, nil
+}
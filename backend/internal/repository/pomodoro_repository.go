@@ -4,17 +4,20 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
+	"pomodoro/backend/internal/db"
 	"pomodoro/backend/internal/model"
 )
 
 type PomodoroRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect db.Dialect
 }
 
-func NewPomodoroRepository(db *sql.DB) *PomodoroRepository {
-	return &PomodoroRepository{db: db}
+func NewPomodoroRepository(database *sql.DB, dialect db.Dialect) *PomodoroRepository {
+	return &PomodoroRepository{db: database, dialect: dialect}
 }
 
 func (r *PomodoroRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
@@ -26,13 +29,14 @@ func (r *PomodoroRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
 }
 
 func (r *PomodoroRepository) CreateInitialState(ctx context.Context, userID string) error {
-	now := time.Now().UTC().Format(time.RFC3339Nano)
+	now := formatTime(time.Now())
 	_, err := r.db.ExecContext(
 		ctx,
-		`INSERT INTO pomodoro_states (
+		r.dialect.Rebind(`INSERT INTO pomodoro_states (
 			user_id, mode, status, remaining_seconds, focus_duration_seconds,
-			short_break_duration_seconds, long_break_duration_seconds, version, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			short_break_duration_seconds, long_break_duration_seconds, long_break_interval,
+			version, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
 		userID,
 		model.ModeFocus,
 		model.StatusIdle,
@@ -40,6 +44,7 @@ func (r *PomodoroRepository) CreateInitialState(ctx context.Context, userID stri
 		model.DefaultFocusDurationSeconds,
 		model.DefaultShortBreakDurationSeconds,
 		model.DefaultLongBreakDurationSeconds,
+		model.DefaultLongBreakInterval,
 		1,
 		now,
 	)
@@ -52,10 +57,11 @@ func (r *PomodoroRepository) CreateInitialState(ctx context.Context, userID stri
 func (r *PomodoroRepository) GetState(ctx context.Context, userID string) (*model.PomodoroState, error) {
 	row := r.db.QueryRowContext(
 		ctx,
-		`SELECT user_id, mode, status, remaining_seconds, focus_duration_seconds,
+		r.dialect.Rebind(`SELECT user_id, mode, status, remaining_seconds, focus_duration_seconds,
 		        short_break_duration_seconds, long_break_duration_seconds,
-				started_at, session_id, version, updated_at
-		 FROM pomodoro_states WHERE user_id = ?`,
+				completed_focus_count, long_break_interval, auto_start_breaks, auto_start_focus,
+				current_task_id, started_at, session_id, version, updated_at, updated_by_device_id
+		 FROM pomodoro_states WHERE user_id = ?`),
 		userID,
 	)
 	state, err := scanPomodoroState(row)
@@ -68,10 +74,11 @@ func (r *PomodoroRepository) GetState(ctx context.Context, userID string) (*mode
 func (r *PomodoroRepository) GetStateTx(ctx context.Context, tx *sql.Tx, userID string) (*model.PomodoroState, error) {
 	row := tx.QueryRowContext(
 		ctx,
-		`SELECT user_id, mode, status, remaining_seconds, focus_duration_seconds,
+		r.dialect.Rebind(`SELECT user_id, mode, status, remaining_seconds, focus_duration_seconds,
 		        short_break_duration_seconds, long_break_duration_seconds,
-				started_at, session_id, version, updated_at
-		 FROM pomodoro_states WHERE user_id = ?`,
+				completed_focus_count, long_break_interval, auto_start_breaks, auto_start_focus,
+				current_task_id, started_at, session_id, version, updated_at, updated_by_device_id
+		 FROM pomodoro_states WHERE user_id = ?`+r.dialect.ForUpdate()),
 		userID,
 	)
 	state, err := scanPomodoroState(row)
@@ -81,40 +88,81 @@ func (r *PomodoroRepository) GetStateTx(ctx context.Context, tx *sql.Tx, userID
 	return state, nil
 }
 
+// ListRunningStates returns every timer that is counting down, so the
+// session finalizer can pick up their deadlines.
+func (r *PomodoroRepository) ListRunningStates(ctx context.Context) ([]model.PomodoroState, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		r.dialect.Rebind(`SELECT user_id, mode, status, remaining_seconds, focus_duration_seconds,
+		        short_break_duration_seconds, long_break_duration_seconds,
+				completed_focus_count, long_break_interval, auto_start_breaks, auto_start_focus,
+				current_task_id, started_at, session_id, version, updated_at, updated_by_device_id
+		 FROM pomodoro_states WHERE status = ?`),
+		model.StatusRunning,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list running states: %w", err)
+	}
+	defer rows.Close()
+
+	states := make([]model.PomodoroState, 0)
+	for rows.Next() {
+		state, scanErr := scanPomodoroState(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		states = append(states, *state)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate running states: %w", err)
+	}
+
+	return states, nil
+}
+
 func (r *PomodoroRepository) UpdateStateTx(ctx context.Context, tx *sql.Tx, state *model.PomodoroState) error {
 	var startedAt interface{}
 	if state.StartedAt != nil {
-		startedAt = state.StartedAt.UTC().Format(time.RFC3339Nano)
-	}
-	var sessionID interface{}
-	if state.SessionID != nil {
-		sessionID = *state.SessionID
+		startedAt = formatTime(*state.StartedAt)
 	}
 
 	_, err := tx.ExecContext(
 		ctx,
-		`UPDATE pomodoro_states
+		r.dialect.Rebind(`UPDATE pomodoro_states
 		 SET mode = ?,
 		     status = ?,
 			 remaining_seconds = ?,
 			 focus_duration_seconds = ?,
 			 short_break_duration_seconds = ?,
 			 long_break_duration_seconds = ?,
+			 completed_focus_count = ?,
+			 long_break_interval = ?,
+			 auto_start_breaks = ?,
+			 auto_start_focus = ?,
+			 current_task_id = ?,
 			 started_at = ?,
 			 session_id = ?,
 			 version = ?,
-			 updated_at = ?
-		 WHERE user_id = ?`,
+			 updated_at = ?,
+			 updated_by_device_id = ?
+		 WHERE user_id = ?`),
 		state.Mode,
 		state.Status,
 		state.RemainingSeconds,
 		state.FocusDurationSeconds,
 		state.ShortBreakDurationSeconds,
 		state.LongBreakDurationSeconds,
+		state.CompletedFocusCount,
+		state.LongBreakInterval,
+		state.AutoStartBreaks,
+		state.AutoStartFocus,
+		nullableString(state.CurrentTaskID),
 		startedAt,
-		sessionID,
+		nullableString(state.SessionID),
 		state.Version,
-		state.UpdatedAt.UTC().Format(time.RFC3339Nano),
+		formatTime(state.UpdatedAt),
+		nullableString(state.UpdatedByDeviceID),
 		state.UserID,
 	)
 	if err != nil {
@@ -126,25 +174,29 @@ func (r *PomodoroRepository) UpdateStateTx(ctx context.Context, tx *sql.Tx, stat
 func (r *PomodoroRepository) InsertSessionTx(ctx context.Context, tx *sql.Tx, session *model.PomodoroSession) error {
 	var endedAt interface{}
 	if session.EndedAt != nil {
-		endedAt = session.EndedAt.UTC().Format(time.RFC3339Nano)
+		endedAt = formatTime(*session.EndedAt)
 	}
 
 	_, err := tx.ExecContext(
 		ctx,
-		`INSERT INTO pomodoro_sessions (
-			id, user_id, mode, planned_duration_seconds, actual_duration_seconds,
-			started_at, ended_at, status, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.dialect.Rebind(`INSERT INTO pomodoro_sessions (
+			id, user_id, task_id, mode, counts_as_focus, planned_duration_seconds, actual_duration_seconds,
+			started_at, ended_at, status, pause_count, paused_seconds, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
 		session.ID,
 		session.UserID,
+		nullableString(session.TaskID),
 		session.Mode,
+		session.CountsAsFocus,
 		session.PlannedDurationSeconds,
 		session.ActualDurationSeconds,
-		session.StartedAt.UTC().Format(time.RFC3339Nano),
+		formatTime(session.StartedAt),
 		endedAt,
 		session.Status,
-		session.CreatedAt.UTC().Format(time.RFC3339Nano),
-		session.UpdatedAt.UTC().Format(time.RFC3339Nano),
+		session.PauseCount,
+		session.PausedSeconds,
+		formatTime(session.CreatedAt),
+		formatTime(session.UpdatedAt),
 	)
 	if err != nil {
 		return fmt.Errorf("insert session: %w", err)
@@ -155,10 +207,10 @@ func (r *PomodoroRepository) InsertSessionTx(ctx context.Context, tx *sql.Tx, se
 func (r *PomodoroRepository) GetSessionTx(ctx context.Context, tx *sql.Tx, sessionID string) (*model.PomodoroSession, error) {
 	row := tx.QueryRowContext(
 		ctx,
-		`SELECT id, user_id, mode, planned_duration_seconds, actual_duration_seconds,
-		        started_at, ended_at, status, created_at, updated_at
+		r.dialect.Rebind(`SELECT id, user_id, task_id, mode, counts_as_focus, planned_duration_seconds, actual_duration_seconds,
+		        started_at, ended_at, status, pause_count, paused_seconds, created_at, updated_at
 		 FROM pomodoro_sessions
-		 WHERE id = ?`,
+		 WHERE id = ?`),
 		sessionID,
 	)
 	session, err := scanPomodoroSession(row)
@@ -168,30 +220,55 @@ func (r *PomodoroRepository) GetSessionTx(ctx context.Context, tx *sql.Tx, sessi
 	return session, nil
 }
 
+// HasSessionStartedTx reports whether the user has a session starting in
+// [from, to).
+func (r *PomodoroRepository) HasSessionStartedTx(ctx context.Context, tx *sql.Tx, userID string, from, to time.Time) (bool, error) {
+	var count int
+	err := tx.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT COUNT(1)
+		 FROM pomodoro_sessions
+		 WHERE user_id = ? AND started_at >= ? AND started_at < ?`),
+		userID,
+		formatTime(from),
+		formatTime(to),
+	).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("check session start: %w", err)
+	}
+	return count > 0, nil
+}
+
 func (r *PomodoroRepository) UpdateSessionTx(ctx context.Context, tx *sql.Tx, session *model.PomodoroSession) error {
 	var endedAt interface{}
 	if session.EndedAt != nil {
-		endedAt = session.EndedAt.UTC().Format(time.RFC3339Nano)
+		endedAt = formatTime(*session.EndedAt)
 	}
 
 	_, err := tx.ExecContext(
 		ctx,
-		`UPDATE pomodoro_sessions
+		r.dialect.Rebind(`UPDATE pomodoro_sessions
 		 SET mode = ?,
+		     counts_as_focus = ?,
 		     planned_duration_seconds = ?,
 			 actual_duration_seconds = ?,
 			 started_at = ?,
 			 ended_at = ?,
 			 status = ?,
+			 pause_count = ?,
+			 paused_seconds = ?,
 			 updated_at = ?
-		 WHERE id = ?`,
+		 WHERE id = ?`),
 		session.Mode,
+		session.CountsAsFocus,
 		session.PlannedDurationSeconds,
 		session.ActualDurationSeconds,
-		session.StartedAt.UTC().Format(time.RFC3339Nano),
+		formatTime(session.StartedAt),
 		endedAt,
 		session.Status,
-		session.UpdatedAt.UTC().Format(time.RFC3339Nano),
+		session.PauseCount,
+		session.PausedSeconds,
+		formatTime(session.UpdatedAt),
 		session.ID,
 	)
 	if err != nil {
@@ -200,24 +277,59 @@ func (r *PomodoroRepository) UpdateSessionTx(ctx context.Context, tx *sql.Tx, se
 	return nil
 }
 
-func (r *PomodoroRepository) ListSessions(ctx context.Context, userID string, limit int) ([]model.PomodoroSession, error) {
-	rows, err := r.db.QueryContext(
-		ctx,
-		`SELECT id, user_id, mode, planned_duration_seconds, actual_duration_seconds,
-		        started_at, ended_at, status, created_at, updated_at
-		 FROM pomodoro_sessions
-		 WHERE user_id = ?
-		 ORDER BY started_at DESC
-		 LIMIT ?`,
-		userID,
-		limit,
-	)
+type SessionCursor struct {
+	StartedAt time.Time
+	ID        string
+}
+
+type SessionFilter struct {
+	Mode   string
+	Status string
+	From   *time.Time
+	To     *time.Time
+	After  *SessionCursor
+	Limit  int
+}
+
+// ListSessions pages newest first using a keyset on (started_at, id), so
+// pages stay stable while new sessions are being recorded.
+func (r *PomodoroRepository) ListSessions(ctx context.Context, userID string, filter SessionFilter) ([]model.PomodoroSession, error) {
+	query := `SELECT id, user_id, task_id, mode, counts_as_focus, planned_duration_seconds, actual_duration_seconds,
+	                 started_at, ended_at, status, pause_count, paused_seconds, created_at, updated_at
+	          FROM pomodoro_sessions
+	          WHERE user_id = ?`
+	args := []interface{}{userID}
+	if filter.Mode != "" {
+		query += ` AND mode = ?`
+		args = append(args, filter.Mode)
+	}
+	if filter.Status != "" {
+		query += ` AND status = ?`
+		args = append(args, filter.Status)
+	}
+	if filter.From != nil {
+		query += ` AND started_at >= ?`
+		args = append(args, formatTime(*filter.From))
+	}
+	if filter.To != nil {
+		query += ` AND started_at < ?`
+		args = append(args, formatTime(*filter.To))
+	}
+	if filter.After != nil {
+		startedAt := formatTime(filter.After.StartedAt)
+		query += ` AND (started_at < ? OR (started_at = ? AND id < ?))`
+		args = append(args, startedAt, startedAt, filter.After.ID)
+	}
+	query += ` ORDER BY started_at DESC, id DESC LIMIT ?`
+	args = append(args, filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), args...)
 	if err != nil {
 		return nil, fmt.Errorf("list sessions: %w", err)
 	}
 	defer rows.Close()
 
-	sessions := make([]model.PomodoroSession, 0, limit)
+	sessions := make([]model.PomodoroSession, 0, filter.Limit)
 	for rows.Next() {
 		session, scanErr := scanPomodoroSession(rows)
 		if scanErr != nil {
@@ -233,15 +345,229 @@ func (r *PomodoroRepository) ListSessions(ctx context.Context, userID string, li
 	return sessions, nil
 }
 
+// sessionIterateBatchSize is how many sessions EachSession reads per query.
+const sessionIterateBatchSize = 500
+
+// EachSession calls fn for every session of the user, newest first. Rows are
+// read in keyset batches so the connection is released between batches
+// rather than held for as long as fn takes, e.g. a slow download.
+func (r *PomodoroRepository) EachSession(ctx context.Context, userID string, fn func(model.PomodoroSession) error) error {
+	filter := SessionFilter{Limit: sessionIterateBatchSize}
+	for {
+		sessions, err := r.ListSessions(ctx, userID, filter)
+		if err != nil {
+			return err
+		}
+		for _, session := range sessions {
+			if err := fn(session); err != nil {
+				return err
+			}
+		}
+		if len(sessions) < filter.Limit {
+			return nil
+		}
+		last := sessions[len(sessions)-1]
+		filter.After = &SessionCursor{StartedAt: last.StartedAt, ID: last.ID}
+	}
+}
+
+// DailyFocus sums the completed focus sessions started on one local
+// calendar date, formatted as YYYY-MM-DD.
+type DailyFocus struct {
+	Date     string
+	Sessions int
+	Seconds  int
+}
+
+// OffsetSpan is a stretch of time, from Start until the next span, during
+// which a time zone keeps the same UTC offset.
+type OffsetSpan struct {
+	Start         time.Time
+	OffsetSeconds int
+}
+
+// SumDailyFocus groups the completed focus sessions started in [from, to)
+// by local date. spans begin at from and cover the range, so days are cut
+// at local midnight on both sides of a daylight saving change.
+func (r *PomodoroRepository) SumDailyFocus(
+	ctx context.Context,
+	userID string,
+	from time.Time,
+	to time.Time,
+	spans []OffsetSpan,
+) ([]DailyFocus, error) {
+	args := make([]interface{}, 0, 2*len(spans)+6)
+	shift := "?"
+	if len(spans) > 1 {
+		var b strings.Builder
+		b.WriteString("CASE")
+		for i := 1; i < len(spans); i++ {
+			b.WriteString(" WHEN started_at < ? THEN ?")
+			args = append(args, formatTime(spans[i].Start), offsetModifier(spans[i-1].OffsetSeconds))
+		}
+		b.WriteString(" ELSE ? END")
+		shift = b.String()
+	}
+	args = append(args,
+		offsetModifier(spans[len(spans)-1].OffsetSeconds),
+		userID,
+		model.SessionStatusCompleted,
+		formatTime(from),
+		formatTime(to),
+		true,
+	)
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		r.dialect.Rebind(`SELECT `+r.dialect.ShiftedDate("started_at", shift)+` AS day,
+		        COUNT(1), COALESCE(SUM(actual_duration_seconds), 0)
+		 FROM pomodoro_sessions
+		 WHERE user_id = ? AND status = ? AND started_at >= ? AND started_at < ? AND counts_as_focus = ?
+		 GROUP BY day
+		 ORDER BY day`),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("sum daily focus: %w", err)
+	}
+	defer rows.Close()
+
+	days := make([]DailyFocus, 0)
+	for rows.Next() {
+		var day DailyFocus
+		if err := rows.Scan(&day.Date, &day.Sessions, &day.Seconds); err != nil {
+			return nil, fmt.Errorf("scan daily focus: %w", err)
+		}
+		days = append(days, day)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate daily focus: %w", err)
+	}
+
+	return days, nil
+}
+
+// FirstCompletedFocusAt is when the user's earliest completed focus session
+// started, or nil when there is none.
+func (r *PomodoroRepository) FirstCompletedFocusAt(ctx context.Context, userID string) (*time.Time, error) {
+	var startedAt sql.NullString
+	err := r.db.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT MIN(started_at)
+		 FROM pomodoro_sessions
+		 WHERE user_id = ? AND status = ? AND counts_as_focus = ?`),
+		userID,
+		model.SessionStatusCompleted,
+		true,
+	).Scan(&startedAt)
+	if err != nil {
+		return nil, fmt.Errorf("find first focus session: %w", err)
+	}
+	if !startedAt.Valid {
+		return nil, nil
+	}
+	parsed, err := parseTime(startedAt.String)
+	if err != nil {
+		return nil, fmt.Errorf("parse first focus session started_at: %w", err)
+	}
+	return &parsed, nil
+}
+
+// ModeTotal sums the sessions of one mode that ended in one status, or
+// are still running, split by whether they were recorded as focus time.
+type ModeTotal struct {
+	Mode          string
+	CountsAsFocus bool
+	Status        string
+	Sessions      int
+	ActualSeconds int
+}
+
+func (r *PomodoroRepository) SumSessionsByMode(ctx context.Context, userID string, from, to time.Time) ([]ModeTotal, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		r.dialect.Rebind(`SELECT mode, counts_as_focus, status, COUNT(1), COALESCE(SUM(actual_duration_seconds), 0)
+		 FROM pomodoro_sessions
+		 WHERE user_id = ? AND started_at >= ? AND started_at < ?
+		 GROUP BY mode, counts_as_focus, status
+		 ORDER BY mode, counts_as_focus, status`),
+		userID,
+		formatTime(from),
+		formatTime(to),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("sum sessions by mode: %w", err)
+	}
+	defer rows.Close()
+
+	totals := make([]ModeTotal, 0)
+	for rows.Next() {
+		var total ModeTotal
+		if err := rows.Scan(&total.Mode, &total.CountsAsFocus, &total.Status, &total.Sessions, &total.ActualSeconds); err != nil {
+			return nil, fmt.Errorf("scan mode total: %w", err)
+		}
+		totals = append(totals, total)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate mode totals: %w", err)
+	}
+
+	return totals, nil
+}
+
+// offsetModifier is a UTC offset in the form both SQLite date modifiers and
+// Postgres intervals accept.
+func offsetModifier(seconds int) string {
+	return fmt.Sprintf("%+d seconds", seconds)
+}
+
+// FocusTotal sums the completed sessions started in a time range that were
+// recorded as focus time: focus itself and custom modes marked as such.
+type FocusTotal struct {
+	Sessions int
+	Seconds  int
+}
+
+func (r *PomodoroRepository) SumCompletedFocusTx(ctx context.Context, tx *sql.Tx, userID string, from, to time.Time) (FocusTotal, error) {
+	var total FocusTotal
+	err := tx.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT COUNT(1), COALESCE(SUM(actual_duration_seconds), 0)
+		 FROM pomodoro_sessions
+		 WHERE user_id = ? AND status = ? AND started_at >= ? AND started_at < ? AND counts_as_focus = ?`),
+		userID,
+		model.SessionStatusCompleted,
+		formatTime(from),
+		formatTime(to),
+		true,
+	).Scan(&total.Sessions, &total.Seconds)
+	if err != nil {
+		return FocusTotal{}, fmt.Errorf("sum completed focus sessions: %w", err)
+	}
+	return total, nil
+}
+
 type scanner interface {
 	Scan(dest ...interface{}) error
 }
 
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
 func scanPomodoroState(s scanner) (*model.PomodoroState, error) {
 	state := model.PomodoroState{}
+	var currentTaskID sql.NullString
 	var startedAt sql.NullString
 	var sessionID sql.NullString
 	var updatedAt string
+	var updatedByDeviceID sql.NullString
 	err := s.Scan(
 		&state.UserID,
 		&state.Mode,
@@ -250,10 +576,16 @@ func scanPomodoroState(s scanner) (*model.PomodoroState, error) {
 		&state.FocusDurationSeconds,
 		&state.ShortBreakDurationSeconds,
 		&state.LongBreakDurationSeconds,
+		&state.CompletedFocusCount,
+		&state.LongBreakInterval,
+		&state.AutoStartBreaks,
+		&state.AutoStartFocus,
+		&currentTaskID,
 		&startedAt,
 		&sessionID,
 		&state.Version,
 		&updatedAt,
+		&updatedByDeviceID,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -269,10 +601,9 @@ func scanPomodoroState(s scanner) (*model.PomodoroState, error) {
 		}
 		state.StartedAt = &parsedStartedAt
 	}
-	if sessionID.Valid {
-		value := sessionID.String
-		state.SessionID = &value
-	}
+	state.CurrentTaskID = stringPtr(currentTaskID)
+	state.SessionID = stringPtr(sessionID)
+	state.UpdatedByDeviceID = stringPtr(updatedByDeviceID)
 
 	parsedUpdatedAt, parseErr := parseTime(updatedAt)
 	if parseErr != nil {
@@ -284,6 +615,7 @@ func scanPomodoroState(s scanner) (*model.PomodoroState, error) {
 
 func scanPomodoroSession(s scanner) (*model.PomodoroSession, error) {
 	session := model.PomodoroSession{}
+	var taskID sql.NullString
 	var startedAt string
 	var endedAt sql.NullString
 	var createdAt string
@@ -291,12 +623,16 @@ func scanPomodoroSession(s scanner) (*model.PomodoroSession, error) {
 	err := s.Scan(
 		&session.ID,
 		&session.UserID,
+		&taskID,
 		&session.Mode,
+		&session.CountsAsFocus,
 		&session.PlannedDurationSeconds,
 		&session.ActualDurationSeconds,
 		&startedAt,
 		&endedAt,
 		&session.Status,
+		&session.PauseCount,
+		&session.PausedSeconds,
 		&createdAt,
 		&updatedAt,
 	)
@@ -306,6 +642,7 @@ func scanPomodoroSession(s scanner) (*model.PomodoroSession, error) {
 		}
 		return nil, fmt.Errorf("scan session: %w", err)
 	}
+	session.TaskID = stringPtr(taskID)
 
 	parsedStartedAt, err := parseTime(startedAt)
 	if err != nil {
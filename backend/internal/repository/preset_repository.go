@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"pomodoro/backend/internal/db"
+	"pomodoro/backend/internal/model"
+)
+
+type PresetRepository struct {
+	db      *sql.DB
+	dialect db.Dialect
+}
+
+func NewPresetRepository(database *sql.DB, dialect db.Dialect) *PresetRepository {
+	return &PresetRepository{db: database, dialect: dialect}
+}
+
+// Create returns ErrDuplicate when the user already has a preset with the
+// same name.
+func (r *PresetRepository) Create(ctx context.Context, preset *model.Preset) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`INSERT INTO presets (
+			id, user_id, name, focus_duration_seconds, short_break_duration_seconds,
+			long_break_duration_seconds, long_break_interval, auto_start_breaks,
+			auto_start_focus, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		preset.ID,
+		preset.UserID,
+		preset.Name,
+		preset.FocusDurationSeconds,
+		preset.ShortBreakDurationSeconds,
+		preset.LongBreakDurationSeconds,
+		nullableInt(preset.LongBreakInterval),
+		nullableBool(preset.AutoStartBreaks),
+		nullableBool(preset.AutoStartFocus),
+		formatTime(preset.CreatedAt),
+		formatTime(preset.UpdatedAt),
+	)
+	if err != nil {
+		if r.dialect.IsUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("create preset: %w", err)
+	}
+	return nil
+}
+
+func (r *PresetRepository) GetByID(ctx context.Context, userID, presetID string) (*model.Preset, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, user_id, name, focus_duration_seconds, short_break_duration_seconds,
+		        long_break_duration_seconds, long_break_interval, auto_start_breaks,
+		        auto_start_focus, created_at, updated_at
+		 FROM presets
+		 WHERE id = ? AND user_id = ?`),
+		presetID,
+		userID,
+	)
+	return scanPreset(row)
+}
+
+func (r *PresetRepository) List(ctx context.Context, userID string) ([]model.Preset, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, user_id, name, focus_duration_seconds, short_break_duration_seconds,
+		        long_break_duration_seconds, long_break_interval, auto_start_breaks,
+		        auto_start_focus, created_at, updated_at
+		 FROM presets
+		 WHERE user_id = ?
+		 ORDER BY name ASC`),
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list presets: %w", err)
+	}
+	defer rows.Close()
+
+	presets := make([]model.Preset, 0)
+	for rows.Next() {
+		preset, scanErr := scanPreset(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		presets = append(presets, *preset)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate presets: %w", err)
+	}
+
+	return presets, nil
+}
+
+// Update returns ErrDuplicate when the new name is taken by another preset
+// of the user.
+func (r *PresetRepository) Update(ctx context.Context, preset *model.Preset) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE presets
+		 SET name = ?,
+		     focus_duration_seconds = ?,
+			 short_break_duration_seconds = ?,
+			 long_break_duration_seconds = ?,
+			 long_break_interval = ?,
+			 auto_start_breaks = ?,
+			 auto_start_focus = ?,
+			 updated_at = ?
+		 WHERE id = ? AND user_id = ?`),
+		preset.Name,
+		preset.FocusDurationSeconds,
+		preset.ShortBreakDurationSeconds,
+		preset.LongBreakDurationSeconds,
+		nullableInt(preset.LongBreakInterval),
+		nullableBool(preset.AutoStartBreaks),
+		nullableBool(preset.AutoStartFocus),
+		formatTime(preset.UpdatedAt),
+		preset.ID,
+		preset.UserID,
+	)
+	if err != nil {
+		if r.dialect.IsUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("update preset: %w", err)
+	}
+	return expectAffected(result, "update preset")
+}
+
+func (r *PresetRepository) Delete(ctx context.Context, userID, presetID string) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`DELETE FROM presets WHERE id = ? AND user_id = ?`),
+		presetID,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("delete preset: %w", err)
+	}
+	return expectAffected(result, "delete preset")
+}
+
+func scanPreset(s scanner) (*model.Preset, error) {
+	preset := model.Preset{}
+	var longBreakInterval sql.NullInt64
+	var autoStartBreaks sql.NullBool
+	var autoStartFocus sql.NullBool
+	var createdAt string
+	var updatedAt string
+	err := s.Scan(
+		&preset.ID,
+		&preset.UserID,
+		&preset.Name,
+		&preset.FocusDurationSeconds,
+		&preset.ShortBreakDurationSeconds,
+		&preset.LongBreakDurationSeconds,
+		&longBreakInterval,
+		&autoStartBreaks,
+		&autoStartFocus,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan preset: %w", err)
+	}
+	preset.LongBreakInterval = intPtr(longBreakInterval)
+	preset.AutoStartBreaks = boolPtr(autoStartBreaks)
+	preset.AutoStartFocus = boolPtr(autoStartFocus)
+
+	parsedCreatedAt, err := parseTime(createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse preset created_at: %w", err)
+	}
+	preset.CreatedAt = parsedCreatedAt
+
+	parsedUpdatedAt, err := parseTime(updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse preset updated_at: %w", err)
+	}
+	preset.UpdatedAt = parsedUpdatedAt
+
+	return &preset, nil
+}
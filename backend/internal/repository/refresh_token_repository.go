@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pomodoro/backend/internal/db"
+	"pomodoro/backend/internal/model"
+)
+
+type RefreshTokenRepository struct {
+	db      *sql.DB
+	dialect db.Dialect
+}
+
+func NewRefreshTokenRepository(database *sql.DB, dialect db.Dialect) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: database, dialect: dialect}
+}
+
+func (r *RefreshTokenRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	return tx, nil
+}
+
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	return r.insert(ctx, r.db, token)
+}
+
+func (r *RefreshTokenRepository) CreateTx(ctx context.Context, tx *sql.Tx, token *model.RefreshToken) error {
+	return r.insert(ctx, tx, token)
+}
+
+func (r *RefreshTokenRepository) GetByID(ctx context.Context, id string) (*model.RefreshToken, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, user_id, device_id, family_id, token_hash, expires_at, revoked_at, replaced_by, created_at
+		 FROM refresh_tokens
+		 WHERE id = ?`),
+		id,
+	)
+	return scanRefreshToken(row)
+}
+
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, user_id, device_id, family_id, token_hash, expires_at, revoked_at, replaced_by, created_at
+		 FROM refresh_tokens
+		 WHERE token_hash = ?`),
+		tokenHash,
+	)
+	return scanRefreshToken(row)
+}
+
+// RotateTx revokes the token only if it is still active, reporting
+// ErrNotFound when a concurrent request already rotated or revoked it.
+func (r *RefreshTokenRepository) RotateTx(ctx context.Context, tx *sql.Tx, id, replacedBy string, now time.Time) error {
+	result, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE refresh_tokens
+		 SET revoked_at = ?, replaced_by = ?
+		 WHERE id = ? AND revoked_at IS NULL`),
+		formatTime(now),
+		replacedBy,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("rotate refresh token: %w", err)
+	}
+	return expectAffected(result, "rotate refresh token")
+}
+
+func (r *RefreshTokenRepository) Revoke(ctx context.Context, id string, now time.Time) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE refresh_tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`),
+		formatTime(now),
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("revoke refresh token: %w", err)
+	}
+	return nil
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, now time.Time) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL`),
+		formatTime(now),
+		familyID,
+	)
+	if err != nil {
+		return fmt.Errorf("revoke refresh token family: %w", err)
+	}
+	return nil
+}
+
+func (r *RefreshTokenRepository) RevokeDeviceTx(ctx context.Context, tx *sql.Tx, deviceID string, now time.Time) error {
+	_, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE refresh_tokens SET revoked_at = ? WHERE device_id = ? AND revoked_at IS NULL`),
+		formatTime(now),
+		deviceID,
+	)
+	if err != nil {
+		return fmt.Errorf("revoke device refresh tokens: %w", err)
+	}
+	return nil
+}
+
+func (r *RefreshTokenRepository) RevokeAllForUserTx(ctx context.Context, tx *sql.Tx, userID string, now time.Time) error {
+	_, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`),
+		formatTime(now),
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("revoke user refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// RevokeOthersForUserTx signs out every session of the user except the one
+// holding keepID.
+func (r *RefreshTokenRepository) RevokeOthersForUserTx(ctx context.Context, tx *sql.Tx, userID, keepID string, now time.Time) error {
+	_, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE refresh_tokens SET revoked_at = ?
+		 WHERE user_id = ? AND id <> ? AND revoked_at IS NULL`),
+		formatTime(now),
+		userID,
+		keepID,
+	)
+	if err != nil {
+		return fmt.Errorf("revoke other refresh tokens: %w", err)
+	}
+	return nil
+}
+
+func (r *RefreshTokenRepository) insert(ctx context.Context, exec execer, token *model.RefreshToken) error {
+	_, err := exec.ExecContext(
+		ctx,
+		r.dialect.Rebind(`INSERT INTO refresh_tokens (
+			id, user_id, device_id, family_id, token_hash, expires_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)`),
+		token.ID,
+		token.UserID,
+		nullableString(token.DeviceID),
+		token.FamilyID,
+		token.TokenHash,
+		formatTime(token.ExpiresAt),
+		formatTime(token.CreatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("create refresh token: %w", err)
+	}
+	return nil
+}
+
+func scanRefreshToken(s scanner) (*model.RefreshToken, error) {
+	token := model.RefreshToken{}
+	var deviceID sql.NullString
+	var expiresAt string
+	var revokedAt sql.NullString
+	var replacedBy sql.NullString
+	var createdAt string
+	err := s.Scan(
+		&token.ID,
+		&token.UserID,
+		&deviceID,
+		&token.FamilyID,
+		&token.TokenHash,
+		&expiresAt,
+		&revokedAt,
+		&replacedBy,
+		&createdAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan refresh token: %w", err)
+	}
+	token.DeviceID = stringPtr(deviceID)
+
+	parsedExpiresAt, err := parseTime(expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse refresh token expires_at: %w", err)
+	}
+	token.ExpiresAt = parsedExpiresAt
+
+	if revokedAt.Valid {
+		parsedRevokedAt, parseErr := parseTime(revokedAt.String)
+		if parseErr != nil {
+			return nil, fmt.Errorf("parse refresh token revoked_at: %w", parseErr)
+		}
+		token.RevokedAt = &parsedRevokedAt
+	}
+	token.ReplacedBy = stringPtr(replacedBy)
+
+	parsedCreatedAt, err := parseTime(createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse refresh token created_at: %w", err)
+	}
+	token.CreatedAt = parsedCreatedAt
+
+	return &token, nil
+}
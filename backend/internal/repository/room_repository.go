@@ -0,0 +1,425 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pomodoro/backend/internal/db"
+	"pomodoro/backend/internal/model"
+)
+
+type RoomRepository struct {
+	db      *sql.DB
+	dialect db.Dialect
+}
+
+func NewRoomRepository(database *sql.DB, dialect db.Dialect) *RoomRepository {
+	return &RoomRepository{db: database, dialect: dialect}
+}
+
+func (r *RoomRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	return tx, nil
+}
+
+// CreateTx stores a room with its initial timer; it returns ErrDuplicate
+// when the invite code is taken.
+func (r *RoomRepository) CreateTx(ctx context.Context, tx *sql.Tx, room *model.Room, state *model.PomodoroState) error {
+	_, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`INSERT INTO rooms (
+			id, owner_id, name, invite_code, mode, status, remaining_seconds,
+			focus_duration_seconds, short_break_duration_seconds, long_break_duration_seconds,
+			completed_focus_count, long_break_interval, auto_start_breaks, auto_start_focus,
+			version, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		room.ID,
+		room.OwnerID,
+		room.Name,
+		room.InviteCode,
+		state.Mode,
+		state.Status,
+		state.RemainingSeconds,
+		state.FocusDurationSeconds,
+		state.ShortBreakDurationSeconds,
+		state.LongBreakDurationSeconds,
+		state.CompletedFocusCount,
+		state.LongBreakInterval,
+		state.AutoStartBreaks,
+		state.AutoStartFocus,
+		state.Version,
+		formatTime(room.CreatedAt),
+		formatTime(room.UpdatedAt),
+	)
+	if err != nil {
+		if r.dialect.IsUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("create room: %w", err)
+	}
+	return nil
+}
+
+func (r *RoomRepository) GetByID(ctx context.Context, roomID string) (*model.Room, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, owner_id, name, invite_code, created_at, updated_at
+		 FROM rooms
+		 WHERE id = ?`),
+		roomID,
+	)
+	return scanRoom(row)
+}
+
+func (r *RoomRepository) GetByInviteCode(ctx context.Context, inviteCode string) (*model.Room, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, owner_id, name, invite_code, created_at, updated_at
+		 FROM rooms
+		 WHERE invite_code = ?`),
+		inviteCode,
+	)
+	return scanRoom(row)
+}
+
+func (r *RoomRepository) ListForUser(ctx context.Context, userID string) ([]model.Room, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		r.dialect.Rebind(`SELECT rooms.id, rooms.owner_id, rooms.name, rooms.invite_code,
+		        rooms.created_at, rooms.updated_at
+		 FROM rooms
+		 JOIN room_members ON room_members.room_id = rooms.id
+		 WHERE room_members.user_id = ?
+		 ORDER BY rooms.created_at DESC`),
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list rooms: %w", err)
+	}
+	defer rows.Close()
+
+	rooms := make([]model.Room, 0)
+	for rows.Next() {
+		room, scanErr := scanRoom(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		rooms = append(rooms, *room)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rooms: %w", err)
+	}
+
+	return rooms, nil
+}
+
+// UpdateInviteCode returns ErrDuplicate when the code is taken.
+func (r *RoomRepository) UpdateInviteCode(ctx context.Context, roomID, inviteCode string, now time.Time) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE rooms SET invite_code = ?, updated_at = ? WHERE id = ?`),
+		inviteCode,
+		formatTime(now),
+		roomID,
+	)
+	if err != nil {
+		if r.dialect.IsUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("update room invite code: %w", err)
+	}
+	return expectAffected(result, "update room invite code")
+}
+
+func (r *RoomRepository) DeleteTx(ctx context.Context, tx *sql.Tx, roomID string) error {
+	result, err := tx.ExecContext(ctx, r.dialect.Rebind(`DELETE FROM rooms WHERE id = ?`), roomID)
+	if err != nil {
+		return fmt.Errorf("delete room: %w", err)
+	}
+	return expectAffected(result, "delete room")
+}
+
+// RunningRoom is a room timer that is counting down.
+type RunningRoom struct {
+	RoomID string
+	State  model.PomodoroState
+}
+
+// roomStateColumns are the rooms columns holding the timer, in the order
+// scanRoomState reads them.
+const roomStateColumns = `mode, status, remaining_seconds, focus_duration_seconds,
+	short_break_duration_seconds, long_break_duration_seconds,
+	completed_focus_count, long_break_interval, auto_start_breaks, auto_start_focus,
+	started_at, version, updated_at`
+
+// GetStateTx loads the room timer. The returned state has no user, session
+// or task; the members' sessions are kept on their memberships.
+func (r *RoomRepository) GetStateTx(ctx context.Context, tx *sql.Tx, roomID string) (*model.PomodoroState, error) {
+	row := tx.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT `+roomStateColumns+`
+		 FROM rooms WHERE id = ?`+r.dialect.ForUpdate()),
+		roomID,
+	)
+	return scanRoomState(row)
+}
+
+// ListRunningStates returns every room timer that is counting down, so the
+// session finalizer can pick up their deadlines.
+func (r *RoomRepository) ListRunningStates(ctx context.Context) ([]RunningRoom, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, `+roomStateColumns+`
+		 FROM rooms WHERE status = ?`),
+		model.StatusRunning,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list running rooms: %w", err)
+	}
+	defer rows.Close()
+
+	rooms := make([]RunningRoom, 0)
+	for rows.Next() {
+		var roomID string
+		state, scanErr := scanRoomState(rows, &roomID)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		rooms = append(rooms, RunningRoom{RoomID: roomID, State: *state})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate running rooms: %w", err)
+	}
+	return rooms, nil
+}
+
+// scanRoomState reads roomStateColumns; leading receives any columns
+// selected before them.
+func scanRoomState(s scanner, leading ...interface{}) (*model.PomodoroState, error) {
+	state := model.PomodoroState{}
+	var startedAt sql.NullString
+	var updatedAt string
+	err := s.Scan(append(leading,
+		&state.Mode,
+		&state.Status,
+		&state.RemainingSeconds,
+		&state.FocusDurationSeconds,
+		&state.ShortBreakDurationSeconds,
+		&state.LongBreakDurationSeconds,
+		&state.CompletedFocusCount,
+		&state.LongBreakInterval,
+		&state.AutoStartBreaks,
+		&state.AutoStartFocus,
+		&startedAt,
+		&state.Version,
+		&updatedAt,
+	)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan room state: %w", err)
+	}
+
+	if startedAt.Valid {
+		parsedStartedAt, parseErr := parseTime(startedAt.String)
+		if parseErr != nil {
+			return nil, fmt.Errorf("parse room started_at: %w", parseErr)
+		}
+		state.StartedAt = &parsedStartedAt
+	}
+	parsedUpdatedAt, parseErr := parseTime(updatedAt)
+	if parseErr != nil {
+		return nil, fmt.Errorf("parse room updated_at: %w", parseErr)
+	}
+	state.UpdatedAt = parsedUpdatedAt
+	return &state, nil
+}
+
+func (r *RoomRepository) UpdateStateTx(ctx context.Context, tx *sql.Tx, roomID string, state *model.PomodoroState) error {
+	var startedAt interface{}
+	if state.StartedAt != nil {
+		startedAt = formatTime(*state.StartedAt)
+	}
+
+	_, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE rooms
+		 SET mode = ?,
+		     status = ?,
+			 remaining_seconds = ?,
+			 completed_focus_count = ?,
+			 started_at = ?,
+			 version = ?,
+			 updated_at = ?
+		 WHERE id = ?`),
+		state.Mode,
+		state.Status,
+		state.RemainingSeconds,
+		state.CompletedFocusCount,
+		startedAt,
+		state.Version,
+		formatTime(state.UpdatedAt),
+		roomID,
+	)
+	if err != nil {
+		return fmt.Errorf("update room state: %w", err)
+	}
+	return nil
+}
+
+// AddMemberTx returns ErrDuplicate when the user already is a member.
+func (r *RoomRepository) AddMemberTx(ctx context.Context, tx *sql.Tx, member *model.RoomMember) error {
+	_, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`INSERT INTO room_members (room_id, user_id, role, joined_at)
+		 VALUES (?, ?, ?, ?)`),
+		member.RoomID,
+		member.UserID,
+		member.Role,
+		formatTime(member.JoinedAt),
+	)
+	if err != nil {
+		if r.dialect.IsUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("add room member: %w", err)
+	}
+	return nil
+}
+
+func (r *RoomRepository) GetMember(ctx context.Context, roomID, userID string) (*model.RoomMember, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT room_members.room_id, room_members.user_id, users.email,
+		        room_members.role, room_members.session_id, room_members.joined_at
+		 FROM room_members
+		 JOIN users ON users.id = room_members.user_id
+		 WHERE room_members.room_id = ? AND room_members.user_id = ?`),
+		roomID,
+		userID,
+	)
+	return scanRoomMember(row)
+}
+
+func (r *RoomRepository) ListMembers(ctx context.Context, roomID string) ([]model.RoomMember, error) {
+	return r.listMembers(ctx, r.db, roomID)
+}
+
+func (r *RoomRepository) ListMembersTx(ctx context.Context, tx *sql.Tx, roomID string) ([]model.RoomMember, error) {
+	return r.listMembers(ctx, tx, roomID)
+}
+
+func (r *RoomRepository) listMembers(ctx context.Context, q queryer, roomID string) ([]model.RoomMember, error) {
+	rows, err := q.QueryContext(
+		ctx,
+		r.dialect.Rebind(`SELECT room_members.room_id, room_members.user_id, users.email,
+		        room_members.role, room_members.session_id, room_members.joined_at
+		 FROM room_members
+		 JOIN users ON users.id = room_members.user_id
+		 WHERE room_members.room_id = ?
+		 ORDER BY room_members.joined_at ASC, room_members.user_id ASC`),
+		roomID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list room members: %w", err)
+	}
+	defer rows.Close()
+
+	members := make([]model.RoomMember, 0)
+	for rows.Next() {
+		member, scanErr := scanRoomMember(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		members = append(members, *member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate room members: %w", err)
+	}
+
+	return members, nil
+}
+
+func (r *RoomRepository) SetMemberSessionTx(ctx context.Context, tx *sql.Tx, roomID, userID string, sessionID *string) error {
+	_, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE room_members SET session_id = ? WHERE room_id = ? AND user_id = ?`),
+		nullableString(sessionID),
+		roomID,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("set room member session: %w", err)
+	}
+	return nil
+}
+
+func (r *RoomRepository) RemoveMemberTx(ctx context.Context, tx *sql.Tx, roomID, userID string) error {
+	result, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`DELETE FROM room_members WHERE room_id = ? AND user_id = ?`),
+		roomID,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("remove room member: %w", err)
+	}
+	return expectAffected(result, "remove room member")
+}
+
+func scanRoom(s scanner) (*model.Room, error) {
+	room := model.Room{}
+	var createdAt string
+	var updatedAt string
+	err := s.Scan(&room.ID, &room.OwnerID, &room.Name, &room.InviteCode, &createdAt, &updatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan room: %w", err)
+	}
+
+	parsedCreatedAt, err := parseTime(createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse room created_at: %w", err)
+	}
+	room.CreatedAt = parsedCreatedAt
+
+	parsedUpdatedAt, err := parseTime(updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse room updated_at: %w", err)
+	}
+	room.UpdatedAt = parsedUpdatedAt
+
+	return &room, nil
+}
+
+func scanRoomMember(s scanner) (*model.RoomMember, error) {
+	member := model.RoomMember{}
+	var sessionID sql.NullString
+	var joinedAt string
+	err := s.Scan(&member.RoomID, &member.UserID, &member.Email, &member.Role, &sessionID, &joinedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan room member: %w", err)
+	}
+	member.SessionID = stringPtr(sessionID)
+
+	parsedJoinedAt, err := parseTime(joinedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse room member joined_at: %w", err)
+	}
+	member.JoinedAt = parsedJoinedAt
+
+	return &member, nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"pomodoro/backend/internal/model"
+)
+
+func (r *PomodoroRepository) InsertPauseTx(ctx context.Context, tx *sql.Tx, userID string, pause *model.SessionPause) error {
+	_, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`INSERT INTO session_pauses (id, session_id, user_id, paused_at) VALUES (?, ?, ?, ?)`),
+		pause.ID,
+		pause.SessionID,
+		userID,
+		formatTime(pause.PausedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("insert session pause: %w", err)
+	}
+	return nil
+}
+
+// ResumePauseTx closes the open pause of a session, returning ErrNotFound
+// when the session is not paused.
+func (r *PomodoroRepository) ResumePauseTx(ctx context.Context, tx *sql.Tx, sessionID string, now time.Time) (*model.SessionPause, error) {
+	row := tx.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, session_id, paused_at, resumed_at
+		 FROM session_pauses
+		 WHERE session_id = ? AND resumed_at IS NULL
+		 ORDER BY paused_at DESC
+		 LIMIT 1`),
+		sessionID,
+	)
+	pause, err := scanSessionPause(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE session_pauses SET resumed_at = ? WHERE id = ?`),
+		formatTime(now),
+		pause.ID,
+	); err != nil {
+		return nil, fmt.Errorf("resume session pause: %w", err)
+	}
+	pause.ResumedAt = &now
+	return pause, nil
+}
+
+func (r *PomodoroRepository) ListPauses(ctx context.Context, sessionIDs []string) (map[string][]model.SessionPause, error) {
+	pauses := make(map[string][]model.SessionPause)
+	if len(sessionIDs) == 0 {
+		return pauses, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(sessionIDs)), ", ")
+	args := make([]interface{}, 0, len(sessionIDs))
+	for _, id := range sessionIDs {
+		args = append(args, id)
+	}
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, session_id, paused_at, resumed_at
+		 FROM session_pauses
+		 WHERE session_id IN (`+placeholders+`)
+		 ORDER BY paused_at ASC`),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list session pauses: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		pause, scanErr := scanSessionPause(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		pauses[pause.SessionID] = append(pauses[pause.SessionID], *pause)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate session pauses: %w", err)
+	}
+
+	return pauses, nil
+}
+
+func scanSessionPause(s scanner) (*model.SessionPause, error) {
+	pause := model.SessionPause{}
+	var pausedAt string
+	var resumedAt sql.NullString
+	err := s.Scan(&pause.ID, &pause.SessionID, &pausedAt, &resumedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan session pause: %w", err)
+	}
+
+	parsedPausedAt, err := parseTime(pausedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse session pause paused_at: %w", err)
+	}
+	pause.PausedAt = parsedPausedAt
+
+	if resumedAt.Valid {
+		parsedResumedAt, parseErr := parseTime(resumedAt.String)
+		if parseErr != nil {
+			return nil, fmt.Errorf("parse session pause resumed_at: %w", parseErr)
+		}
+		pause.ResumedAt = &parsedResumedAt
+	}
+
+	return &pause, nil
+}
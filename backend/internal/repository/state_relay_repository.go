@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	stateRelayChannel = "pomodoro_state"
+	// stateRelayPingInterval checks an idle listener connection, which
+	// otherwise only notices a dropped server on its next notification.
+	stateRelayPingInterval = time.Minute
+)
+
+// PostgresStateRelay carries state snapshots between server instances with
+// Postgres LISTEN/NOTIFY. Notifications are not queued: an instance that is
+// reconnecting misses them, as a client does between two event streams.
+type PostgresStateRelay struct {
+	db  *sql.DB
+	dsn string
+}
+
+func NewPostgresStateRelay(database *sql.DB, dsn string) *PostgresStateRelay {
+	return &PostgresStateRelay{db: database, dsn: dsn}
+}
+
+// Notify sends payload to every listening instance, this one included.
+// Postgres limits a payload to just under 8000 bytes.
+func (r *PostgresStateRelay) Notify(ctx context.Context, payload []byte) error {
+	if _, err := r.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, stateRelayChannel, string(payload)); err != nil {
+		return fmt.Errorf("notify state: %w", err)
+	}
+	return nil
+}
+
+// Listen calls receive with every payload until ctx is done. The listener
+// keeps its own connection and reconnects after losing it.
+func (r *PostgresStateRelay) Listen(ctx context.Context, receive func(payload []byte)) error {
+	listener := pq.NewListener(r.dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("state relay: listener event %d: %v", event, err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(stateRelayChannel); err != nil {
+		return fmt.Errorf("listen for state: %w", err)
+	}
+
+	ping := time.NewTicker(stateRelayPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-listener.Notify:
+			// A nil notification follows a reconnect; whatever was sent in
+			// between is lost.
+			if notification != nil {
+				receive([]byte(notification.Extra))
+			}
+		case <-ping.C:
+			if err := listener.Ping(); err != nil {
+				log.Printf("state relay: ping listener: %v", err)
+			}
+		}
+	}
+}
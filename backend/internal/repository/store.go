@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"pomodoro/backend/internal/model"
+)
+
+// The store interfaces are what services depend on. The SQL repositories in
+// this package implement them for every supported db.Dialect; methods
+// suffixed with Tx join a transaction opened through BeginTx.
+
+type UserStore interface {
+	Create(ctx context.Context, user *model.User) error
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	GetByID(ctx context.Context, id string) (*model.User, error)
+	GetByIDTx(ctx context.Context, tx *sql.Tx, id string) (*model.User, error)
+	UpdatePasswordTx(ctx context.Context, tx *sql.Tx, userID, passwordHash string, now time.Time) error
+	MarkEmailVerifiedTx(ctx context.Context, tx *sql.Tx, userID string, now time.Time) error
+	UpdateEmailTx(ctx context.Context, tx *sql.Tx, userID, email string, now time.Time) error
+	DeleteTx(ctx context.Context, tx *sql.Tx, userID string) error
+}
+
+type UserTokenStore interface {
+	BeginTx(ctx context.Context) (*sql.Tx, error)
+	CreateTx(ctx context.Context, tx *sql.Tx, token *model.UserToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*model.UserToken, error)
+	ConsumeTx(ctx context.Context, tx *sql.Tx, id string, now time.Time) error
+	InvalidateTx(ctx context.Context, tx *sql.Tx, userID, purpose string, now time.Time) error
+}
+
+type PomodoroStore interface {
+	BeginTx(ctx context.Context) (*sql.Tx, error)
+	CreateInitialState(ctx context.Context, userID string) error
+	GetState(ctx context.Context, userID string) (*model.PomodoroState, error)
+	GetStateTx(ctx context.Context, tx *sql.Tx, userID string) (*model.PomodoroState, error)
+	ListRunningStates(ctx context.Context) ([]model.PomodoroState, error)
+	UpdateStateTx(ctx context.Context, tx *sql.Tx, state *model.PomodoroState) error
+	InsertSessionTx(ctx context.Context, tx *sql.Tx, session *model.PomodoroSession) error
+	GetSessionTx(ctx context.Context, tx *sql.Tx, sessionID string) (*model.PomodoroSession, error)
+	UpdateSessionTx(ctx context.Context, tx *sql.Tx, session *model.PomodoroSession) error
+	HasSessionStartedTx(ctx context.Context, tx *sql.Tx, userID string, from, to time.Time) (bool, error)
+	ListSessions(ctx context.Context, userID string, filter SessionFilter) ([]model.PomodoroSession, error)
+	EachSession(ctx context.Context, userID string, fn func(model.PomodoroSession) error) error
+	SumDailyFocus(ctx context.Context, userID string, from, to time.Time, spans []OffsetSpan) ([]DailyFocus, error)
+	SumSessionsByMode(ctx context.Context, userID string, from, to time.Time) ([]ModeTotal, error)
+	FirstCompletedFocusAt(ctx context.Context, userID string) (*time.Time, error)
+	SumCompletedFocusTx(ctx context.Context, tx *sql.Tx, userID string, from, to time.Time) (FocusTotal, error)
+	InsertEventTx(ctx context.Context, tx *sql.Tx, event *model.PomodoroEvent) error
+	ListEvents(ctx context.Context, userID string, filter EventFilter) ([]model.PomodoroEvent, error)
+	LastEventTimeTx(ctx context.Context, tx *sql.Tx, userID string) (*time.Time, error)
+	LastEventTypeTx(ctx context.Context, tx *sql.Tx, userID string) (string, error)
+	InsertPauseTx(ctx context.Context, tx *sql.Tx, userID string, pause *model.SessionPause) error
+	ResumePauseTx(ctx context.Context, tx *sql.Tx, sessionID string, now time.Time) (*model.SessionPause, error)
+	ListPauses(ctx context.Context, sessionIDs []string) (map[string][]model.SessionPause, error)
+}
+
+type TaskStore interface {
+	Create(ctx context.Context, task *model.Task) error
+	GetByID(ctx context.Context, userID, taskID string) (*model.Task, error)
+	GetByIDTx(ctx context.Context, tx *sql.Tx, userID, taskID string) (*model.Task, error)
+	List(ctx context.Context, userID, status string) ([]model.Task, error)
+	Update(ctx context.Context, task *model.Task) error
+	DeleteTx(ctx context.Context, tx *sql.Tx, userID, taskID string) error
+	IncrementCompletedTx(ctx context.Context, tx *sql.Tx, taskID string, now time.Time) error
+}
+
+type PresetStore interface {
+	Create(ctx context.Context, preset *model.Preset) error
+	GetByID(ctx context.Context, userID, presetID string) (*model.Preset, error)
+	List(ctx context.Context, userID string) ([]model.Preset, error)
+	Update(ctx context.Context, preset *model.Preset) error
+	Delete(ctx context.Context, userID, presetID string) error
+}
+
+type RoomStore interface {
+	BeginTx(ctx context.Context) (*sql.Tx, error)
+	CreateTx(ctx context.Context, tx *sql.Tx, room *model.Room, state *model.PomodoroState) error
+	GetByID(ctx context.Context, roomID string) (*model.Room, error)
+	GetByInviteCode(ctx context.Context, inviteCode string) (*model.Room, error)
+	ListForUser(ctx context.Context, userID string) ([]model.Room, error)
+	UpdateInviteCode(ctx context.Context, roomID, inviteCode string, now time.Time) error
+	DeleteTx(ctx context.Context, tx *sql.Tx, roomID string) error
+	GetStateTx(ctx context.Context, tx *sql.Tx, roomID string) (*model.PomodoroState, error)
+	ListRunningStates(ctx context.Context) ([]RunningRoom, error)
+	UpdateStateTx(ctx context.Context, tx *sql.Tx, roomID string, state *model.PomodoroState) error
+	AddMemberTx(ctx context.Context, tx *sql.Tx, member *model.RoomMember) error
+	GetMember(ctx context.Context, roomID, userID string) (*model.RoomMember, error)
+	ListMembers(ctx context.Context, roomID string) ([]model.RoomMember, error)
+	ListMembersTx(ctx context.Context, tx *sql.Tx, roomID string) ([]model.RoomMember, error)
+	SetMemberSessionTx(ctx context.Context, tx *sql.Tx, roomID, userID string, sessionID *string) error
+	RemoveMemberTx(ctx context.Context, tx *sql.Tx, roomID, userID string) error
+}
+
+type ModeStore interface {
+	Create(ctx context.Context, mode *model.Mode) error
+	GetByID(ctx context.Context, userID, modeID string) (*model.Mode, error)
+	GetByKeyTx(ctx context.Context, tx *sql.Tx, userID, key string) (*model.Mode, error)
+	List(ctx context.Context, userID string) ([]model.Mode, error)
+	Update(ctx context.Context, mode *model.Mode) error
+	DeleteTx(ctx context.Context, tx *sql.Tx, userID, modeID string) error
+}
+
+type WebhookStore interface {
+	Create(ctx context.Context, webhook *model.Webhook) error
+	GetByID(ctx context.Context, userID, webhookID string) (*model.Webhook, error)
+	List(ctx context.Context, userID string) ([]model.Webhook, error)
+	ListActiveTx(ctx context.Context, tx *sql.Tx, userID string) ([]model.Webhook, error)
+	Update(ctx context.Context, webhook *model.Webhook) error
+	UpdateSecret(ctx context.Context, userID, webhookID, secret string, now time.Time) error
+	Delete(ctx context.Context, userID, webhookID string) error
+	InsertDeliveryTx(ctx context.Context, tx *sql.Tx, delivery *model.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, userID, webhookID string, filter DeliveryFilter) ([]model.WebhookDelivery, error)
+	ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]DueDelivery, error)
+	ClaimDelivery(ctx context.Context, delivery *model.WebhookDelivery, leaseUntil time.Time) error
+	RecordAttempt(ctx context.Context, delivery *model.WebhookDelivery) error
+}
+
+type GoalStore interface {
+	GetTx(ctx context.Context, tx *sql.Tx, userID string) (*model.FocusGoals, error)
+	UpsertTx(ctx context.Context, tx *sql.Tx, goals *model.FocusGoals) error
+	DeleteTx(ctx context.Context, tx *sql.Tx, userID string) error
+}
+
+type RefreshTokenStore interface {
+	BeginTx(ctx context.Context) (*sql.Tx, error)
+	Create(ctx context.Context, token *model.RefreshToken) error
+	CreateTx(ctx context.Context, tx *sql.Tx, token *model.RefreshToken) error
+	GetByID(ctx context.Context, id string) (*model.RefreshToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error)
+	RotateTx(ctx context.Context, tx *sql.Tx, id, replacedBy string, now time.Time) error
+	Revoke(ctx context.Context, id string, now time.Time) error
+	RevokeFamily(ctx context.Context, familyID string, now time.Time) error
+	RevokeDeviceTx(ctx context.Context, tx *sql.Tx, deviceID string, now time.Time) error
+	RevokeAllForUserTx(ctx context.Context, tx *sql.Tx, userID string, now time.Time) error
+	RevokeOthersForUserTx(ctx context.Context, tx *sql.Tx, userID, keepID string, now time.Time) error
+}
+
+type DeviceStore interface {
+	BeginTx(ctx context.Context) (*sql.Tx, error)
+	Create(ctx context.Context, device *model.Device) error
+	GetByID(ctx context.Context, userID, deviceID string) (*model.Device, error)
+	GetByIDTx(ctx context.Context, tx *sql.Tx, userID, deviceID string) (*model.Device, error)
+	ListActive(ctx context.Context, userID string) ([]model.Device, error)
+	UpdateDetails(ctx context.Context, device *model.Device) error
+	Touch(ctx context.Context, deviceID, ip string, now, staleBefore time.Time) error
+	RevokeTx(ctx context.Context, tx *sql.Tx, userID, deviceID string, now time.Time) error
+}
+
+type IdempotencyStore interface {
+	Create(ctx context.Context, record *model.IdempotencyKey) error
+	Get(ctx context.Context, userID, key string) (*model.IdempotencyKey, error)
+	Reclaim(ctx context.Context, record *model.IdempotencyKey, staleBefore time.Time) error
+	Complete(ctx context.Context, userID, key string, statusCode int, body string, now time.Time) error
+	Delete(ctx context.Context, userID, key string) error
+	DeleteExpired(ctx context.Context, userID string, now time.Time) error
+}
+
+type LoginAttemptStore interface {
+	Get(ctx context.Context, email string) (*model.LoginAttempt, error)
+	RecordFailure(ctx context.Context, email string, now, resetBefore time.Time) (int, error)
+	Lock(ctx context.Context, email string, until time.Time) error
+	Clear(ctx context.Context, email string) error
+	ClearTx(ctx context.Context, tx *sql.Tx, email string) error
+}
+
+// StateRelay passes state snapshots between server instances sharing a
+// database. Listen blocks until ctx is done.
+type StateRelay interface {
+	Notify(ctx context.Context, payload []byte) error
+	Listen(ctx context.Context, receive func(payload []byte)) error
+}
+
+var (
+	_ UserStore         = (*UserRepository)(nil)
+	_ PomodoroStore     = (*PomodoroRepository)(nil)
+	_ TaskStore         = (*TaskRepository)(nil)
+	_ PresetStore       = (*PresetRepository)(nil)
+	_ RoomStore         = (*RoomRepository)(nil)
+	_ ModeStore         = (*ModeRepository)(nil)
+	_ WebhookStore      = (*WebhookRepository)(nil)
+	_ GoalStore         = (*GoalRepository)(nil)
+	_ RefreshTokenStore = (*RefreshTokenRepository)(nil)
+	_ DeviceStore       = (*DeviceRepository)(nil)
+	_ IdempotencyStore  = (*IdempotencyRepository)(nil)
+	_ LoginAttemptStore = (*LoginAttemptRepository)(nil)
+	_ UserTokenStore    = (*UserTokenRepository)(nil)
+	_ StateRelay        = (*PostgresStateRelay)(nil)
+)
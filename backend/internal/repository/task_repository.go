@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pomodoro/backend/internal/db"
+	"pomodoro/backend/internal/model"
+)
+
+type TaskRepository struct {
+	db      *sql.DB
+	dialect db.Dialect
+}
+
+func NewTaskRepository(database *sql.DB, dialect db.Dialect) *TaskRepository {
+	return &TaskRepository{db: database, dialect: dialect}
+}
+
+func (r *TaskRepository) Create(ctx context.Context, task *model.Task) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`INSERT INTO tasks (
+			id, user_id, title, notes, status, estimated_pomodoros,
+			completed_pomodoros, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		task.ID,
+		task.UserID,
+		task.Title,
+		task.Notes,
+		task.Status,
+		task.EstimatedPomodoros,
+		task.CompletedPomodoros,
+		formatTime(task.CreatedAt),
+		formatTime(task.UpdatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("create task: %w", err)
+	}
+	return nil
+}
+
+func (r *TaskRepository) GetByID(ctx context.Context, userID, taskID string) (*model.Task, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, user_id, title, notes, status, estimated_pomodoros,
+		        completed_pomodoros, created_at, updated_at
+		 FROM tasks
+		 WHERE id = ? AND user_id = ?`),
+		taskID,
+		userID,
+	)
+	return scanTask(row)
+}
+
+func (r *TaskRepository) GetByIDTx(ctx context.Context, tx *sql.Tx, userID, taskID string) (*model.Task, error) {
+	row := tx.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, user_id, title, notes, status, estimated_pomodoros,
+		        completed_pomodoros, created_at, updated_at
+		 FROM tasks
+		 WHERE id = ? AND user_id = ?`),
+		taskID,
+		userID,
+	)
+	return scanTask(row)
+}
+
+func (r *TaskRepository) List(ctx context.Context, userID, status string) ([]model.Task, error) {
+	query := `SELECT id, user_id, title, notes, status, estimated_pomodoros,
+	                 completed_pomodoros, created_at, updated_at
+	          FROM tasks
+	          WHERE user_id = ?`
+	args := []interface{}{userID}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("list tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := make([]model.Task, 0)
+	for rows.Next() {
+		task, scanErr := scanTask(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		tasks = append(tasks, *task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+func (r *TaskRepository) Update(ctx context.Context, task *model.Task) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE tasks
+		 SET title = ?,
+		     notes = ?,
+			 status = ?,
+			 estimated_pomodoros = ?,
+			 updated_at = ?
+		 WHERE id = ? AND user_id = ?`),
+		task.Title,
+		task.Notes,
+		task.Status,
+		task.EstimatedPomodoros,
+		formatTime(task.UpdatedAt),
+		task.ID,
+		task.UserID,
+	)
+	if err != nil {
+		return fmt.Errorf("update task: %w", err)
+	}
+	return expectAffected(result, "update task")
+}
+
+func (r *TaskRepository) DeleteTx(ctx context.Context, tx *sql.Tx, userID, taskID string) error {
+	result, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`DELETE FROM tasks WHERE id = ? AND user_id = ?`),
+		taskID,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("delete task: %w", err)
+	}
+	return expectAffected(result, "delete task")
+}
+
+func (r *TaskRepository) IncrementCompletedTx(ctx context.Context, tx *sql.Tx, taskID string, now time.Time) error {
+	_, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE tasks
+		 SET completed_pomodoros = completed_pomodoros + 1,
+		     updated_at = ?
+		 WHERE id = ?`),
+		formatTime(now),
+		taskID,
+	)
+	if err != nil {
+		return fmt.Errorf("increment task pomodoros: %w", err)
+	}
+	return nil
+}
+
+func scanTask(s scanner) (*model.Task, error) {
+	task := model.Task{}
+	var createdAt string
+	var updatedAt string
+	err := s.Scan(
+		&task.ID,
+		&task.UserID,
+		&task.Title,
+		&task.Notes,
+		&task.Status,
+		&task.EstimatedPomodoros,
+		&task.CompletedPomodoros,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan task: %w", err)
+	}
+
+	parsedCreatedAt, err := parseTime(createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse task created_at: %w", err)
+	}
+	task.CreatedAt = parsedCreatedAt
+
+	parsedUpdatedAt, err := parseTime(updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse task updated_at: %w", err)
+	}
+	task.UpdatedAt = parsedUpdatedAt
+
+	return &task, nil
+}
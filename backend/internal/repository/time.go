@@ -2,6 +2,16 @@ package repository
 
 import "time"
 
+const (
+	// timeLayout keeps a fixed-width fraction so stored timestamps sort
+	// lexically in chronological order; RFC3339Nano trims trailing zeros.
+	timeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+)
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
 func parseTime(raw string) (time.Time, error) {
 	if raw == "" {
 		return time.Time{}, nil
@@ -6,29 +6,34 @@ import (
 	"fmt"
 	"time"
 
+	"pomodoro/backend/internal/db"
 	"pomodoro/backend/internal/model"
 )
 
 type UserRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect db.Dialect
 }
 
-func NewUserRepository(db *sql.DB) *UserRepository {
-	return &UserRepository{db: db}
+func NewUserRepository(database *sql.DB, dialect db.Dialect) *UserRepository {
+	return &UserRepository{db: database, dialect: dialect}
 }
 
 func (r *UserRepository) Create(ctx context.Context, user *model.User) error {
 	_, err := r.db.ExecContext(
 		ctx,
-		`INSERT INTO users (id, email, password_hash, created_at, updated_at)
-		 VALUES (?, ?, ?, ?, ?)`,
+		r.dialect.Rebind(`INSERT INTO users (id, email, password_hash, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?)`),
 		user.ID,
 		user.Email,
 		user.PasswordHash,
-		user.CreatedAt.UTC().Format(time.RFC3339Nano),
-		user.UpdatedAt.UTC().Format(time.RFC3339Nano),
+		formatTime(user.CreatedAt),
+		formatTime(user.UpdatedAt),
 	)
 	if err != nil {
+		if r.dialect.IsUniqueViolation(err) {
+			return ErrDuplicate
+		}
 		return fmt.Errorf("create user: %w", err)
 	}
 	return nil
@@ -37,55 +42,118 @@ func (r *UserRepository) Create(ctx context.Context, user *model.User) error {
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	row := r.db.QueryRowContext(
 		ctx,
-		`SELECT id, email, password_hash, created_at, updated_at
+		r.dialect.Rebind(`SELECT id, email, password_hash, email_verified_at, created_at, updated_at
 		 FROM users
-		 WHERE email = ?`,
+		 WHERE email = ?`),
 		email,
 	)
+	return scanUser(row)
+}
 
-	var user model.User
-	var createdAt string
-	var updatedAt string
-	if err := row.Scan(&user.ID, &user.Email, &user.PasswordHash, &createdAt, &updatedAt); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, ErrNotFound
-		}
-		return nil, fmt.Errorf("get user by email: %w", err)
-	}
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*model.User, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, email, password_hash, email_verified_at, created_at, updated_at
+		 FROM users
+		 WHERE id = ?`),
+		id,
+	)
+	return scanUser(row)
+}
 
-	parsedCreatedAt, err := parseTime(createdAt)
+func (r *UserRepository) GetByIDTx(ctx context.Context, tx *sql.Tx, id string) (*model.User, error) {
+	row := tx.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, email, password_hash, email_verified_at, created_at, updated_at
+		 FROM users
+		 WHERE id = ?`+r.dialect.ForUpdate()),
+		id,
+	)
+	return scanUser(row)
+}
+
+func (r *UserRepository) UpdatePasswordTx(ctx context.Context, tx *sql.Tx, userID, passwordHash string, now time.Time) error {
+	result, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE users SET password_hash = ?, updated_at = ? WHERE id = ?`),
+		passwordHash,
+		formatTime(now),
+		userID,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("parse user created_at: %w", err)
+		return fmt.Errorf("update user password: %w", err)
 	}
-	parsedUpdatedAt, err := parseTime(updatedAt)
+	return expectAffected(result, "update user password")
+}
+
+// MarkEmailVerifiedTx keeps the original verification time of an already
+// verified email.
+func (r *UserRepository) MarkEmailVerifiedTx(ctx context.Context, tx *sql.Tx, userID string, now time.Time) error {
+	_, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE users SET email_verified_at = ?, updated_at = ?
+		 WHERE id = ? AND email_verified_at IS NULL`),
+		formatTime(now),
+		formatTime(now),
+		userID,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("parse user updated_at: %w", err)
+		return fmt.Errorf("mark email verified: %w", err)
 	}
-	user.CreatedAt = parsedCreatedAt
-	user.UpdatedAt = parsedUpdatedAt
+	return nil
+}
 
-	return &user, nil
+// UpdateEmailTx returns ErrDuplicate when another account uses the email.
+// The new address starts out unverified.
+func (r *UserRepository) UpdateEmailTx(ctx context.Context, tx *sql.Tx, userID, email string, now time.Time) error {
+	result, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE users SET email = ?, email_verified_at = NULL, updated_at = ? WHERE id = ?`),
+		email,
+		formatTime(now),
+		userID,
+	)
+	if err != nil {
+		if r.dialect.IsUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("update user email: %w", err)
+	}
+	return expectAffected(result, "update user email")
 }
 
-func (r *UserRepository) GetByID(ctx context.Context, id string) (*model.User, error) {
-	row := r.db.QueryRowContext(
+// Delete removes the user; every table keyed by user_id cascades with it.
+func (r *UserRepository) DeleteTx(ctx context.Context, tx *sql.Tx, userID string) error {
+	result, err := tx.ExecContext(
 		ctx,
-		`SELECT id, email, password_hash, created_at, updated_at
-		 FROM users
-		 WHERE id = ?`,
-		id,
+		r.dialect.Rebind(`DELETE FROM users WHERE id = ?`),
+		userID,
 	)
+	if err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+	return expectAffected(result, "delete user")
+}
 
+func scanUser(s scanner) (*model.User, error) {
 	var user model.User
+	var emailVerifiedAt sql.NullString
 	var createdAt string
 	var updatedAt string
-	if err := row.Scan(&user.ID, &user.Email, &user.PasswordHash, &createdAt, &updatedAt); err != nil {
+	if err := s.Scan(&user.ID, &user.Email, &user.PasswordHash, &emailVerifiedAt, &createdAt, &updatedAt); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrNotFound
 		}
-		return nil, fmt.Errorf("get user by id: %w", err)
+		return nil, fmt.Errorf("scan user: %w", err)
 	}
 
+	if emailVerifiedAt.Valid {
+		parsedVerifiedAt, err := parseTime(emailVerifiedAt.String)
+		if err != nil {
+			return nil, fmt.Errorf("parse user email_verified_at: %w", err)
+		}
+		user.EmailVerifiedAt = &parsedVerifiedAt
+	}
 	parsedCreatedAt, err := parseTime(createdAt)
 	if err != nil {
 		return nil, fmt.Errorf("parse user created_at: %w", err)
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pomodoro/backend/internal/db"
+	"pomodoro/backend/internal/model"
+)
+
+type UserTokenRepository struct {
+	db      *sql.DB
+	dialect db.Dialect
+}
+
+func NewUserTokenRepository(database *sql.DB, dialect db.Dialect) *UserTokenRepository {
+	return &UserTokenRepository{db: database, dialect: dialect}
+}
+
+func (r *UserTokenRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	return tx, nil
+}
+
+func (r *UserTokenRepository) CreateTx(ctx context.Context, tx *sql.Tx, token *model.UserToken) error {
+	_, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`INSERT INTO user_tokens (
+			id, user_id, purpose, email, token_hash, expires_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)`),
+		token.ID,
+		token.UserID,
+		token.Purpose,
+		token.Email,
+		token.TokenHash,
+		formatTime(token.ExpiresAt),
+		formatTime(token.CreatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("create user token: %w", err)
+	}
+	return nil
+}
+
+func (r *UserTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.UserToken, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, user_id, purpose, email, token_hash, expires_at, used_at, created_at
+		 FROM user_tokens
+		 WHERE token_hash = ?`),
+		tokenHash,
+	)
+	return scanUserToken(row)
+}
+
+// ConsumeTx marks a token used, returning ErrNotFound when it was used
+// already.
+func (r *UserTokenRepository) ConsumeTx(ctx context.Context, tx *sql.Tx, id string, now time.Time) error {
+	result, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE user_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL`),
+		formatTime(now),
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("consume user token: %w", err)
+	}
+	return expectAffected(result, "consume user token")
+}
+
+// InvalidateTx uses up every outstanding token of the user for purpose.
+func (r *UserTokenRepository) InvalidateTx(ctx context.Context, tx *sql.Tx, userID, purpose string, now time.Time) error {
+	_, err := tx.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE user_tokens SET used_at = ?
+		 WHERE user_id = ? AND purpose = ? AND used_at IS NULL`),
+		formatTime(now),
+		userID,
+		purpose,
+	)
+	if err != nil {
+		return fmt.Errorf("invalidate user tokens: %w", err)
+	}
+	return nil
+}
+
+func scanUserToken(s scanner) (*model.UserToken, error) {
+	token := model.UserToken{}
+	var expiresAt string
+	var usedAt sql.NullString
+	var createdAt string
+	err := s.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Purpose,
+		&token.Email,
+		&token.TokenHash,
+		&expiresAt,
+		&usedAt,
+		&createdAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan user token: %w", err)
+	}
+
+	parsedExpiresAt, err := parseTime(expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse user token expires_at: %w", err)
+	}
+	token.ExpiresAt = parsedExpiresAt
+
+	if usedAt.Valid {
+		parsedUsedAt, parseErr := parseTime(usedAt.String)
+		if parseErr != nil {
+			return nil, fmt.Errorf("parse user token used_at: %w", parseErr)
+		}
+		token.UsedAt = &parsedUsedAt
+	}
+
+	parsedCreatedAt, err := parseTime(createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse user token created_at: %w", err)
+	}
+	token.CreatedAt = parsedCreatedAt
+
+	return &token, nil
+}
@@ -0,0 +1,439 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"pomodoro/backend/internal/db"
+	"pomodoro/backend/internal/model"
+)
+
+type WebhookRepository struct {
+	db      *sql.DB
+	dialect db.Dialect
+}
+
+// DeliveryFilter pages through a webhook's deliveries, newest first.
+type DeliveryFilter struct {
+	Status   string
+	BeforeID int64
+	Limit    int
+}
+
+// DueDelivery is a pending delivery with what the worker needs to send it.
+type DueDelivery struct {
+	Delivery model.WebhookDelivery
+	URL      string
+	Secret   string
+	Active   bool
+}
+
+func NewWebhookRepository(database *sql.DB, dialect db.Dialect) *WebhookRepository {
+	return &WebhookRepository{db: database, dialect: dialect}
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, webhook *model.Webhook) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`INSERT INTO webhooks (
+			id, user_id, url, secret, events, active, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`),
+		webhook.ID,
+		webhook.UserID,
+		webhook.URL,
+		webhook.Secret,
+		strings.Join(webhook.Events, ","),
+		webhook.Active,
+		formatTime(webhook.CreatedAt),
+		formatTime(webhook.UpdatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("create webhook: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) GetByID(ctx context.Context, userID, webhookID string) (*model.Webhook, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, user_id, url, secret, events, active, created_at, updated_at
+		 FROM webhooks
+		 WHERE id = ? AND user_id = ?`),
+		webhookID,
+		userID,
+	)
+	return scanWebhook(row)
+}
+
+func (r *WebhookRepository) List(ctx context.Context, userID string) ([]model.Webhook, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, user_id, url, secret, events, active, created_at, updated_at
+		 FROM webhooks
+		 WHERE user_id = ?
+		 ORDER BY created_at ASC, id ASC`),
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+	return collectWebhooks(rows)
+}
+
+// ListActiveTx returns the webhooks of the user that receive deliveries.
+func (r *WebhookRepository) ListActiveTx(ctx context.Context, tx *sql.Tx, userID string) ([]model.Webhook, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		r.dialect.Rebind(`SELECT id, user_id, url, secret, events, active, created_at, updated_at
+		 FROM webhooks
+		 WHERE user_id = ? AND active = ?`),
+		userID,
+		true,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list active webhooks: %w", err)
+	}
+	return collectWebhooks(rows)
+}
+
+func (r *WebhookRepository) Update(ctx context.Context, webhook *model.Webhook) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE webhooks
+		 SET url = ?,
+		     events = ?,
+		     active = ?,
+		     updated_at = ?
+		 WHERE id = ? AND user_id = ?`),
+		webhook.URL,
+		strings.Join(webhook.Events, ","),
+		webhook.Active,
+		formatTime(webhook.UpdatedAt),
+		webhook.ID,
+		webhook.UserID,
+	)
+	if err != nil {
+		return fmt.Errorf("update webhook: %w", err)
+	}
+	return expectAffected(result, "update webhook")
+}
+
+func (r *WebhookRepository) UpdateSecret(ctx context.Context, userID, webhookID, secret string, now time.Time) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE webhooks SET secret = ?, updated_at = ? WHERE id = ? AND user_id = ?`),
+		secret,
+		formatTime(now),
+		webhookID,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("update webhook secret: %w", err)
+	}
+	return expectAffected(result, "update webhook secret")
+}
+
+// Delete removes the webhook together with its delivery log.
+func (r *WebhookRepository) Delete(ctx context.Context, userID, webhookID string) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`DELETE FROM webhooks WHERE id = ? AND user_id = ?`),
+		webhookID,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+	return expectAffected(result, "delete webhook")
+}
+
+func (r *WebhookRepository) InsertDeliveryTx(ctx context.Context, tx *sql.Tx, delivery *model.WebhookDelivery) error {
+	err := tx.QueryRowContext(
+		ctx,
+		r.dialect.Rebind(`INSERT INTO webhook_deliveries (
+			webhook_id, user_id, event_id, event_type, payload, status, attempts,
+			next_attempt_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id`),
+		delivery.WebhookID,
+		delivery.UserID,
+		delivery.EventID,
+		delivery.EventType,
+		string(delivery.Payload),
+		delivery.Status,
+		delivery.Attempts,
+		formatTime(*delivery.NextAttemptAt),
+		formatTime(delivery.CreatedAt),
+	).Scan(&delivery.ID)
+	if err != nil {
+		return fmt.Errorf("insert webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, userID, webhookID string, filter DeliveryFilter) ([]model.WebhookDelivery, error) {
+	query := `SELECT id, webhook_id, user_id, event_id, event_type, payload, status, attempts,
+	                 next_attempt_at, last_attempt_at, response_status, last_error, created_at, delivered_at
+	          FROM webhook_deliveries
+	          WHERE user_id = ? AND webhook_id = ?`
+	args := []interface{}{userID, webhookID}
+	if filter.Status != "" {
+		query += ` AND status = ?`
+		args = append(args, filter.Status)
+	}
+	if filter.BeforeID > 0 {
+		query += ` AND id < ?`
+		args = append(args, filter.BeforeID)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]model.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, scanErr := scanWebhookDelivery(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// ListDueDeliveries returns pending deliveries whose next attempt is not
+// after now, oldest first.
+func (r *WebhookRepository) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]DueDelivery, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		r.dialect.Rebind(`SELECT d.id, d.webhook_id, d.user_id, d.event_id, d.event_type, d.payload, d.status,
+		        d.attempts, d.next_attempt_at, d.last_attempt_at, d.response_status, d.last_error,
+		        d.created_at, d.delivered_at, w.url, w.secret, w.active
+		 FROM webhook_deliveries d
+		 JOIN webhooks w ON w.id = d.webhook_id
+		 WHERE d.status = ? AND d.next_attempt_at <= ?
+		 ORDER BY d.next_attempt_at ASC, d.id ASC
+		 LIMIT ?`),
+		model.DeliveryStatusPending,
+		formatTime(now),
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list due webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	due := make([]DueDelivery, 0)
+	for rows.Next() {
+		var item DueDelivery
+		delivery, scanErr := scanWebhookDelivery(rows, &item.URL, &item.Secret, &item.Active)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		item.Delivery = *delivery
+		due = append(due, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate due webhook deliveries: %w", err)
+	}
+
+	return due, nil
+}
+
+// ClaimDelivery counts an attempt and holds the delivery until leaseUntil,
+// so no other worker sends it meanwhile. It returns ErrNotFound when
+// another worker claimed it first.
+func (r *WebhookRepository) ClaimDelivery(ctx context.Context, delivery *model.WebhookDelivery, leaseUntil time.Time) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE webhook_deliveries
+		 SET attempts = attempts + 1, next_attempt_at = ?
+		 WHERE id = ? AND status = ? AND attempts = ?`),
+		formatTime(leaseUntil),
+		delivery.ID,
+		model.DeliveryStatusPending,
+		delivery.Attempts,
+	)
+	if err != nil {
+		return fmt.Errorf("claim webhook delivery: %w", err)
+	}
+	if err := expectAffected(result, "claim webhook delivery"); err != nil {
+		return err
+	}
+	delivery.Attempts++
+	return nil
+}
+
+// RecordAttempt stores the outcome of the latest attempt, which must have
+// LastAttemptAt set.
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, delivery *model.WebhookDelivery) error {
+	// next_attempt_at is required, so a finished delivery keeps the time of
+	// its last attempt there.
+	nextAttemptAt := formatTime(*delivery.LastAttemptAt)
+	if delivery.NextAttemptAt != nil {
+		nextAttemptAt = formatTime(*delivery.NextAttemptAt)
+	}
+	var deliveredAt interface{}
+	if delivery.DeliveredAt != nil {
+		deliveredAt = formatTime(*delivery.DeliveredAt)
+	}
+
+	_, err := r.db.ExecContext(
+		ctx,
+		r.dialect.Rebind(`UPDATE webhook_deliveries
+		 SET status = ?,
+		     next_attempt_at = ?,
+		     last_attempt_at = ?,
+		     response_status = ?,
+		     last_error = ?,
+		     delivered_at = ?
+		 WHERE id = ?`),
+		delivery.Status,
+		nextAttemptAt,
+		formatTime(*delivery.LastAttemptAt),
+		nullableInt(delivery.ResponseStatus),
+		nullableString(delivery.LastError),
+		deliveredAt,
+		delivery.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("record webhook attempt: %w", err)
+	}
+	return nil
+}
+
+func collectWebhooks(rows *sql.Rows) ([]model.Webhook, error) {
+	defer rows.Close()
+
+	webhooks := make([]model.Webhook, 0)
+	for rows.Next() {
+		webhook, scanErr := scanWebhook(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func scanWebhook(s scanner) (*model.Webhook, error) {
+	webhook := model.Webhook{}
+	var events string
+	var createdAt string
+	var updatedAt string
+	err := s.Scan(
+		&webhook.ID,
+		&webhook.UserID,
+		&webhook.URL,
+		&webhook.Secret,
+		&events,
+		&webhook.Active,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan webhook: %w", err)
+	}
+	webhook.Events = strings.Split(events, ",")
+
+	parsedCreatedAt, err := parseTime(createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse webhook created_at: %w", err)
+	}
+	webhook.CreatedAt = parsedCreatedAt
+
+	parsedUpdatedAt, err := parseTime(updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse webhook updated_at: %w", err)
+	}
+	webhook.UpdatedAt = parsedUpdatedAt
+
+	return &webhook, nil
+}
+
+// scanWebhookDelivery scans the delivery columns followed by extra.
+func scanWebhookDelivery(s scanner, extra ...interface{}) (*model.WebhookDelivery, error) {
+	delivery := model.WebhookDelivery{}
+	var payload string
+	var nextAttemptAt string
+	var lastAttemptAt sql.NullString
+	var responseStatus sql.NullInt64
+	var lastError sql.NullString
+	var createdAt string
+	var deliveredAt sql.NullString
+	dest := []interface{}{
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.UserID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&nextAttemptAt,
+		&lastAttemptAt,
+		&responseStatus,
+		&lastError,
+		&createdAt,
+		&deliveredAt,
+	}
+	if err := s.Scan(append(dest, extra...)...); err != nil {
+		return nil, fmt.Errorf("scan webhook delivery: %w", err)
+	}
+	delivery.Payload = []byte(payload)
+	delivery.ResponseStatus = intPtr(responseStatus)
+	delivery.LastError = stringPtr(lastError)
+
+	// The next attempt only means something while the delivery is pending.
+	if delivery.Status == model.DeliveryStatusPending {
+		parsedNextAttemptAt, err := parseTime(nextAttemptAt)
+		if err != nil {
+			return nil, fmt.Errorf("parse webhook delivery next_attempt_at: %w", err)
+		}
+		delivery.NextAttemptAt = &parsedNextAttemptAt
+	}
+
+	if lastAttemptAt.Valid {
+		parsedLastAttemptAt, err := parseTime(lastAttemptAt.String)
+		if err != nil {
+			return nil, fmt.Errorf("parse webhook delivery last_attempt_at: %w", err)
+		}
+		delivery.LastAttemptAt = &parsedLastAttemptAt
+	}
+	if deliveredAt.Valid {
+		parsedDeliveredAt, err := parseTime(deliveredAt.String)
+		if err != nil {
+			return nil, fmt.Errorf("parse webhook delivery delivered_at: %w", err)
+		}
+		delivery.DeliveredAt = &parsedDeliveredAt
+	}
+
+	parsedCreatedAt, err := parseTime(createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse webhook delivery created_at: %w", err)
+	}
+	delivery.CreatedAt = parsedCreatedAt
+
+	return &delivery, nil
+}
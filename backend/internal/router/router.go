@@ -2,6 +2,7 @@ package router
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -14,7 +15,18 @@ func New(
 	authService *service.AuthService,
 	authHandler *handler.AuthHandler,
 	pomodoroHandler *handler.PomodoroHandler,
+	taskHandler *handler.TaskHandler,
+	presetHandler *handler.PresetHandler,
+	modeHandler *handler.ModeHandler,
+	webhookHandler *handler.WebhookHandler,
+	roomHandler *handler.RoomHandler,
+	deviceHandler *handler.DeviceHandler,
+	accountHandler *handler.AccountHandler,
+	idempotencyService *service.IdempotencyService,
+	authIPLimiter *service.RateLimiter,
+	authEmailLimiter *service.RateLimiter,
 	corsOrigins []string,
+	streamAuthInterval time.Duration,
 ) *gin.Engine {
 	engine := gin.New()
 	engine.Use(gin.Logger(), gin.Recovery(), middleware.CORS(corsOrigins))
@@ -25,18 +37,110 @@ func New(
 
 	api := engine.Group("/api")
 	auth := api.Group("/auth")
-	auth.POST("/register", authHandler.Register)
-	auth.POST("/login", authHandler.Login)
+	authRateLimit := middleware.AuthRateLimit(authIPLimiter, authEmailLimiter)
+	auth.POST("/register", authRateLimit, authHandler.Register)
+	auth.POST("/login", authRateLimit, authHandler.Login)
+	auth.POST("/refresh", authHandler.Refresh)
+	auth.POST("/logout", middleware.Auth(authService), authHandler.Logout)
+	auth.POST("/password/forgot", authRateLimit, authHandler.ForgotPassword)
+	auth.POST("/password/reset", authRateLimit, authHandler.ResetPassword)
+	auth.POST("/email/verify", authRateLimit, authHandler.VerifyEmail)
+	auth.POST("/email/verification", middleware.Auth(authService), authRateLimit, authHandler.SendEmailVerification)
+
+	// Changing credentials checks the current password, so these routes are
+	// throttled like login.
+	account := api.Group("/account")
+	account.Use(middleware.Auth(authService))
+	account.GET("", accountHandler.Get)
+	account.PUT("/password", authRateLimit, accountHandler.ChangePassword)
+	account.PUT("/email", authRateLimit, accountHandler.ChangeEmail)
+	account.DELETE("", authRateLimit, accountHandler.Delete)
 
 	pomodoro := api.Group("/pomodoro")
 	pomodoro.Use(middleware.Auth(authService))
 	pomodoro.GET("/state", pomodoroHandler.GetState)
-	pomodoro.POST("/start", pomodoroHandler.Start)
-	pomodoro.POST("/pause", pomodoroHandler.Pause)
-	pomodoro.POST("/reset", pomodoroHandler.Reset)
-	pomodoro.POST("/mode", pomodoroHandler.SwitchMode)
-	pomodoro.PUT("/settings", pomodoroHandler.UpdateSettings)
+	// Streams outlive the request check, so their token is checked again
+	// while they are open.
+	streamAuth := middleware.StreamAuth(authService, streamAuthInterval)
+	pomodoro.GET("/events", streamAuth, pomodoroHandler.Events)
+	pomodoro.GET("/events/log", pomodoroHandler.GetEventLog)
+	pomodoro.GET("/ws", streamAuth, pomodoroHandler.WebSocket)
 	pomodoro.GET("/history", pomodoroHandler.GetHistory)
+	pomodoro.GET("/stats", pomodoroHandler.GetStats)
+	pomodoro.GET("/export", pomodoroHandler.Export)
+	pomodoro.GET("/goals", pomodoroHandler.GetGoals)
+
+	mutations := pomodoro.Group("")
+	mutations.Use(middleware.Idempotency(idempotencyService))
+	mutations.POST("/start", pomodoroHandler.Start)
+	mutations.POST("/pause", pomodoroHandler.Pause)
+	mutations.POST("/reset", pomodoroHandler.Reset)
+	mutations.POST("/mode", pomodoroHandler.SwitchMode)
+	mutations.PUT("/settings", pomodoroHandler.UpdateSettings)
+	mutations.PUT("/task", pomodoroHandler.SetCurrentTask)
+	mutations.PUT("/goals", pomodoroHandler.UpdateGoals)
+	mutations.DELETE("/goals", pomodoroHandler.DeleteGoals)
+	mutations.POST("/sync", pomodoroHandler.Sync)
+	mutations.POST("/import", pomodoroHandler.Import)
+
+	tasks := api.Group("/tasks")
+	tasks.Use(middleware.Auth(authService))
+	tasks.GET("", taskHandler.List)
+	tasks.POST("", taskHandler.Create)
+	tasks.GET("/:id", taskHandler.Get)
+	tasks.PATCH("/:id", taskHandler.Update)
+	tasks.DELETE("/:id", taskHandler.Delete)
+
+	presets := api.Group("/presets")
+	presets.Use(middleware.Auth(authService))
+	presets.GET("", presetHandler.List)
+	presets.POST("", presetHandler.Create)
+	presets.GET("/:id", presetHandler.Get)
+	presets.PUT("/:id", presetHandler.Update)
+	presets.DELETE("/:id", presetHandler.Delete)
+	presets.POST("/:id/activate", middleware.Idempotency(idempotencyService), presetHandler.Activate)
+
+	modes := api.Group("/modes")
+	modes.Use(middleware.Auth(authService))
+	modes.GET("", modeHandler.List)
+	modes.POST("", modeHandler.Create)
+	modes.GET("/:id", modeHandler.Get)
+	modes.PUT("/:id", modeHandler.Update)
+	modes.DELETE("/:id", modeHandler.Delete)
+
+	webhooks := api.Group("/webhooks")
+	webhooks.Use(middleware.Auth(authService))
+	webhooks.GET("", webhookHandler.List)
+	webhooks.POST("", webhookHandler.Create)
+	webhooks.GET("/:id", webhookHandler.Get)
+	webhooks.PUT("/:id", webhookHandler.Update)
+	webhooks.DELETE("/:id", webhookHandler.Delete)
+	webhooks.POST("/:id/secret", webhookHandler.RotateSecret)
+	webhooks.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+
+	// Members follow the room timer; only the owner changes it.
+	rooms := api.Group("/rooms")
+	rooms.Use(middleware.Auth(authService))
+	rooms.GET("", roomHandler.List)
+	rooms.POST("", roomHandler.Create)
+	rooms.POST("/join", roomHandler.Join)
+	rooms.GET("/:id", roomHandler.Get)
+	rooms.DELETE("/:id", roomHandler.Delete)
+	rooms.POST("/:id/invite-code", roomHandler.RotateInviteCode)
+	rooms.DELETE("/:id/members/:userId", roomHandler.RemoveMember)
+	rooms.GET("/:id/state", roomHandler.GetState)
+
+	roomTimer := rooms.Group("/:id")
+	roomTimer.Use(middleware.Idempotency(idempotencyService))
+	roomTimer.POST("/start", roomHandler.Start)
+	roomTimer.POST("/pause", roomHandler.Pause)
+	roomTimer.POST("/reset", roomHandler.Reset)
+	roomTimer.POST("/mode", roomHandler.SwitchMode)
+
+	devices := api.Group("/devices")
+	devices.Use(middleware.Auth(authService))
+	devices.GET("", deviceHandler.List)
+	devices.DELETE("/:id", deviceHandler.Delete)
 
 	return engine
 }
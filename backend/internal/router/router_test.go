@@ -1,46 +1,206 @@
 package router_test
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"path/filepath"
-	"runtime"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"golang.org/x/net/websocket"
+
 	"pomodoro/backend/internal/db"
 	"pomodoro/backend/internal/handler"
+	"pomodoro/backend/internal/mailer"
 	"pomodoro/backend/internal/repository"
 	"pomodoro/backend/internal/router"
 	"pomodoro/backend/internal/service"
+	"pomodoro/backend/migrations"
 )
 
 type authResponse struct {
-	Token string `json:"token"`
-	User  struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	User         struct {
 		ID    string `json:"id"`
 		Email string `json:"email"`
 	} `json:"user"`
+	Device struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"device"`
 }
 
 type stateEnvelope struct {
 	State struct {
-		Version int `json:"version"`
+		Version             int    `json:"version"`
+		Mode                string `json:"mode"`
+		Status              string `json:"status"`
+		CompletedFocusCount int    `json:"completedFocusCount"`
+		AutoStopped         bool   `json:"autoStopped"`
+	} `json:"state"`
+}
+
+type syncEnvelope struct {
+	State struct {
+		Version          int    `json:"version"`
+		Status           string `json:"status"`
+		RemainingSeconds int    `json:"remainingSeconds"`
 	} `json:"state"`
+	Results []struct {
+		ID          string    `json:"id"`
+		Status      string    `json:"status"`
+		Code        string    `json:"code"`
+		Version     int       `json:"version"`
+		EffectiveAt time.Time `json:"effectiveAt"`
+	} `json:"results"`
+}
+
+type importReportEnvelope struct {
+	Accepted int `json:"accepted"`
+	Rejected int `json:"rejected"`
+	Rows     []struct {
+		Row    int    `json:"row"`
+		Status string `json:"status"`
+		Code   string `json:"code"`
+	} `json:"rows"`
 }
 
 type historyEnvelope struct {
 	Sessions []struct {
-		Status string `json:"status"`
+		ID            string `json:"id"`
+		Mode          string `json:"mode"`
+		Status        string `json:"status"`
+		PauseCount    int    `json:"pauseCount"`
+		PausedSeconds int    `json:"pausedSeconds"`
+		Pauses        []struct {
+			PausedAt  time.Time  `json:"pausedAt"`
+			ResumedAt *time.Time `json:"resumedAt"`
+		} `json:"pauses"`
 	} `json:"sessions"`
+	NextCursor *string `json:"nextCursor"`
+}
+
+type taskEnvelope struct {
+	Task struct {
+		ID                 string `json:"id"`
+		EstimatedPomodoros int    `json:"estimatedPomodoros"`
+		CompletedPomodoros int    `json:"completedPomodoros"`
+	} `json:"task"`
+}
+
+type presetEnvelope struct {
+	Preset struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"preset"`
+}
+
+type modeEnvelope struct {
+	Mode struct {
+		ID    string `json:"id"`
+		Key   string `json:"key"`
+		Color string `json:"color"`
+	} `json:"mode"`
+}
+
+type webhookEnvelope struct {
+	Webhook struct {
+		ID     string   `json:"id"`
+		Secret string   `json:"secret"`
+		Events []string `json:"events"`
+		Active bool     `json:"active"`
+	} `json:"webhook"`
+}
+
+type deliveryLogEnvelope struct {
+	Deliveries []struct {
+		ID             int64   `json:"id"`
+		EventType      string  `json:"eventType"`
+		Status         string  `json:"status"`
+		Attempts       int     `json:"attempts"`
+		ResponseStatus *int    `json:"responseStatus"`
+		LastError      *string `json:"lastError"`
+	} `json:"deliveries"`
+	NextCursor *string `json:"nextCursor"`
+}
+
+type statsEnvelope struct {
+	Stats struct {
+		TimeZone string `json:"timeZone"`
+		Today    struct {
+			Start                  string `json:"start"`
+			CompletedFocusSessions int    `json:"completedFocusSessions"`
+		} `json:"today"`
+		Daily          []json.RawMessage `json:"daily"`
+		CompletionRate float64           `json:"completionRate"`
+		Streak         struct {
+			CurrentDays int `json:"currentDays"`
+			LongestDays int `json:"longestDays"`
+		} `json:"streak"`
+	} `json:"stats"`
 }
 
 type apiErrorEnvelope struct {
 	Error struct {
 		Code    string `json:"code"`
 		Message string `json:"message"`
+		Details struct {
+			State struct {
+				Version int `json:"version"`
+			} `json:"state"`
+			Device struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"device"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+type eventLogEnvelope struct {
+	Events []struct {
+		ID         int64   `json:"id"`
+		Type       string  `json:"type"`
+		FromStatus string  `json:"fromStatus"`
+		ToStatus   string  `json:"toStatus"`
+		SessionID  *string `json:"sessionId"`
+		Version    int     `json:"version"`
+		DeviceID   *string `json:"deviceId"`
+	} `json:"events"`
+	NextCursor *string `json:"nextCursor"`
+}
+
+type devicesEnvelope struct {
+	Devices []struct {
+		ID       string `json:"id"`
+		Name     string `json:"name"`
+		Platform string `json:"platform"`
+	} `json:"devices"`
+	CurrentDeviceID string `json:"currentDeviceId"`
+}
+
+type socketReply struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	State struct {
+		Version int `json:"version"`
+	} `json:"state"`
+	Error struct {
+		Code    string `json:"code"`
 		Details struct {
 			State struct {
 				Version int `json:"version"`
@@ -123,81 +283,2568 @@ func TestPomodoroSyncAndConflict(t *testing.T) {
 	}
 }
 
-func TestCORSPreflight(t *testing.T) {
+func TestPomodoroCycleAutoAdvance(t *testing.T) {
 	engine := setupTestEngine(t)
-	req := httptest.NewRequest(http.MethodOptions, "/api/auth/login", nil)
-	req.Header.Set("Origin", "http://localhost:5173")
-	req.Header.Set("Access-Control-Request-Method", "POST")
-	recorder := httptest.NewRecorder()
+	user := registerUser(t, engine, "cycle@example.com", "123456")
 
-	engine.ServeHTTP(recorder, req)
+	status, body := requestJSON(t, engine, http.MethodPut, "/api/pomodoro/settings", user.Token, map[string]interface{}{
+		"baseVersion":               1,
+		"focusDurationSeconds":      1,
+		"shortBreakDurationSeconds": 60,
+		"longBreakDurationSeconds":  120,
+		"longBreakInterval":         1,
+		"autoStartBreaks":           true,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on settings, got %d: %s", status, string(body))
+	}
 
-	if recorder.Code != http.StatusNoContent {
-		t.Fatalf("expected 204 for preflight, got %d", recorder.Code)
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/start", user.Token, map[string]int{"baseVersion": 2})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on start, got %d", status)
 	}
-	if recorder.Header().Get("Access-Control-Allow-Origin") != "http://localhost:5173" {
-		t.Fatalf("unexpected allow-origin header: %s", recorder.Header().Get("Access-Control-Allow-Origin"))
+
+	time.Sleep(1100 * time.Millisecond)
+
+	state := getState(t, engine, user.Token)
+	if state.State.Mode != "long_break" || state.State.Status != "running" {
+		t.Fatalf("expected running long_break after focus, got %s/%s", state.State.Mode, state.State.Status)
+	}
+	if state.State.CompletedFocusCount != 1 {
+		t.Fatalf("expected 1 completed focus session, got %d", state.State.CompletedFocusCount)
+	}
+
+	status, historyRaw := requestJSON(t, engine, http.MethodGet, "/api/pomodoro/history?limit=10", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 for history, got %d", status)
+	}
+	var history historyEnvelope
+	if err := json.Unmarshal(historyRaw, &history); err != nil {
+		t.Fatalf("unmarshal history: %v", err)
+	}
+	if len(history.Sessions) != 2 {
+		t.Fatalf("expected focus and long_break sessions, got %d", len(history.Sessions))
+	}
+	if history.Sessions[0].Mode != "long_break" || history.Sessions[0].Status != "running" {
+		t.Fatalf("expected running long_break session, got %s/%s", history.Sessions[0].Mode, history.Sessions[0].Status)
+	}
+	if history.Sessions[1].Mode != "focus" || history.Sessions[1].Status != "completed" {
+		t.Fatalf("expected completed focus session, got %s/%s", history.Sessions[1].Mode, history.Sessions[1].Status)
+	}
+
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/events/log?limit=1", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 for event log, got %d", status)
+	}
+	var eventLog eventLogEnvelope
+	if err := json.Unmarshal(body, &eventLog); err != nil {
+		t.Fatalf("unmarshal event log: %v", err)
+	}
+	if len(eventLog.Events) != 1 || eventLog.Events[0].Type != "complete" || eventLog.Events[0].DeviceID != nil {
+		t.Fatalf("expected a server-side complete event, got %s", string(body))
 	}
 }
 
-func setupTestEngine(t *testing.T) http.Handler {
-	t.Helper()
+func TestSessionFinalizer(t *testing.T) {
+	engine := setupTestEngine(t)
+	user := registerUser(t, engine, "finalizer@example.com", "123456")
 
-	database, err := db.OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
-	if err != nil {
-		t.Fatalf("open sqlite: %v", err)
+	status, body := requestJSON(t, engine, http.MethodPut, "/api/pomodoro/settings", user.Token, map[string]interface{}{
+		"baseVersion":               1,
+		"focusDurationSeconds":      1,
+		"shortBreakDurationSeconds": 60,
+		"longBreakDurationSeconds":  120,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on settings, got %d: %s", status, string(body))
 	}
-	t.Cleanup(func() {
-		_ = database.Close()
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/start", user.Token, map[string]int{"baseVersion": 2})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on start, got %d", status)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+
+	// History does not settle the timer itself, so a completed session
+	// here was finished in the background.
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/history", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 for history, got %d", status)
+	}
+	var history struct {
+		Sessions []struct {
+			Status                string     `json:"status"`
+			ActualDurationSeconds int        `json:"actualDurationSeconds"`
+			StartedAt             time.Time  `json:"startedAt"`
+			EndedAt               *time.Time `json:"endedAt"`
+		} `json:"sessions"`
+	}
+	if err := json.Unmarshal(body, &history); err != nil {
+		t.Fatalf("unmarshal history: %v", err)
+	}
+	if len(history.Sessions) != 1 || history.Sessions[0].Status != "completed" {
+		t.Fatalf("expected the session completed without a state read, got %s", string(body))
+	}
+	session := history.Sessions[0]
+	if session.EndedAt == nil || !session.EndedAt.Equal(session.StartedAt.Add(time.Second)) || session.ActualDurationSeconds != 1 {
+		t.Fatalf("expected the session to end exactly at its deadline, got %s", string(body))
+	}
+
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/events/log?limit=1", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 for event log, got %d", status)
+	}
+	var eventLog eventLogEnvelope
+	if err := json.Unmarshal(body, &eventLog); err != nil {
+		t.Fatalf("unmarshal event log: %v", err)
+	}
+	if len(eventLog.Events) != 1 || eventLog.Events[0].Type != "complete" || eventLog.Events[0].Version != 4 {
+		t.Fatalf("expected a complete event at version 4, got %s", string(body))
+	}
+
+	state := getState(t, engine, user.Token)
+	if state.State.Version != 4 || state.State.Status != "idle" || state.State.Mode != "short_break" {
+		t.Fatalf("expected the read to find the timer already settled, got %+v", state.State)
+	}
+}
+
+func TestRoomSessionFinalizer(t *testing.T) {
+	engine := setupTestEngine(t)
+	owner := registerUser(t, engine, "room-finalizer@example.com", "123456")
+	member := registerUser(t, engine, "room-finalizer-member@example.com", "123456")
+
+	status, body := requestJSON(t, engine, http.MethodPost, "/api/rooms", owner.Token, map[string]interface{}{
+		"name":                      "Background sprint",
+		"focusDurationSeconds":      1,
+		"shortBreakDurationSeconds": 60,
 	})
+	if status != http.StatusCreated {
+		t.Fatalf("expected 201 on create room, got %d: %s", status, string(body))
+	}
+	var created struct {
+		Room struct {
+			ID         string `json:"id"`
+			InviteCode string `json:"inviteCode"`
+		} `json:"room"`
+	}
+	if err := json.Unmarshal(body, &created); err != nil {
+		t.Fatalf("unmarshal room: %v", err)
+	}
+	roomPath := "/api/rooms/" + created.Room.ID
 
-	_, currentFile, _, _ := runtime.Caller(0)
-	migrationsDir := filepath.Join(filepath.Dir(currentFile), "..", "..", "migrations")
-	if err := db.RunMigrations(database, migrationsDir); err != nil {
-		t.Fatalf("run migrations: %v", err)
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/rooms/join", member.Token, map[string]string{
+		"inviteCode": created.Room.InviteCode,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on join, got %d", status)
+	}
+	status, _ = requestJSON(t, engine, http.MethodPost, roomPath+"/start", owner.Token, map[string]int{"baseVersion": 1})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on room start, got %d", status)
 	}
 
-	userRepo := repository.NewUserRepository(database)
-	pomodoroRepo := repository.NewPomodoroRepository(database)
-	authService := service.NewAuthService(userRepo, pomodoroRepo, "test-secret", 24*time.Hour)
-	pomodoroService := service.NewPomodoroService(pomodoroRepo)
+	time.Sleep(1500 * time.Millisecond)
 
-	authHandler := handler.NewAuthHandler(authService)
-	pomodoroHandler := handler.NewPomodoroHandler(pomodoroService)
+	// Nobody reads the room in the meantime, so completed sessions were
+	// finished in the background.
+	for _, user := range []authResponse{owner, member} {
+		status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/history", user.Token, nil)
+		if status != http.StatusOK {
+			t.Fatalf("expected 200 on history, got %d", status)
+		}
+		var history struct {
+			Sessions []struct {
+				Status    string     `json:"status"`
+				StartedAt time.Time  `json:"startedAt"`
+				EndedAt   *time.Time `json:"endedAt"`
+			} `json:"sessions"`
+		}
+		if err := json.Unmarshal(body, &history); err != nil {
+			t.Fatalf("unmarshal history: %v", err)
+		}
+		if len(history.Sessions) != 1 || history.Sessions[0].Status != "completed" {
+			t.Fatalf("expected the room session of %s completed without a read, got %s", user.User.Email, string(body))
+		}
+		session := history.Sessions[0]
+		if session.EndedAt == nil || !session.EndedAt.Equal(session.StartedAt.Add(time.Second)) {
+			t.Fatalf("expected the room session to end at its deadline, got %s", string(body))
+		}
+	}
 
-	return router.New(authService, authHandler, pomodoroHandler, []string{"http://localhost:5173"})
+	status, body = requestJSON(t, engine, http.MethodGet, roomPath+"/state", member.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on room state, got %d", status)
+	}
+	var roomState stateEnvelope
+	if err := json.Unmarshal(body, &roomState); err != nil {
+		t.Fatalf("unmarshal room state: %v", err)
+	}
+	if roomState.State.Version != 3 || roomState.State.Status != "idle" || roomState.State.Mode != "short_break" {
+		t.Fatalf("expected the read to find the room timer already settled, got %s", string(body))
+	}
 }
 
-func registerUser(t *testing.T, server http.Handler, email, password string) authResponse {
-	t.Helper()
-	status, body := requestJSON(t, server, http.MethodPost, "/api/auth/register", "", map[string]string{
-		"email":    email,
-		"password": password,
+func TestTaskAttribution(t *testing.T) {
+	engine := setupTestEngine(t)
+	user := registerUser(t, engine, "tasks@example.com", "123456")
+
+	status, body := requestJSON(t, engine, http.MethodPost, "/api/tasks", user.Token, map[string]interface{}{
+		"title":              "Write report",
+		"estimatedPomodoros": 3,
 	})
 	if status != http.StatusCreated {
-		t.Fatalf("register %s failed with status %d: %s", email, status, string(body))
+		t.Fatalf("expected 201 on create task, got %d: %s", status, string(body))
 	}
-	var resp authResponse
-	if err := json.Unmarshal(body, &resp); err != nil {
-		t.Fatalf("unmarshal register response: %v", err)
+	var created taskEnvelope
+	if err := json.Unmarshal(body, &created); err != nil {
+		t.Fatalf("unmarshal task: %v", err)
 	}
-	if resp.Token == "" {
-		t.Fatalf("empty token for user %s", email)
+
+	status, _ = requestJSON(t, engine, http.MethodPut, "/api/pomodoro/task", user.Token, map[string]interface{}{
+		"baseVersion": 1,
+		"taskId":      created.Task.ID,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on set current task, got %d", status)
+	}
+	status, _ = requestJSON(t, engine, http.MethodPut, "/api/pomodoro/settings", user.Token, map[string]interface{}{
+		"baseVersion":               2,
+		"focusDurationSeconds":      1,
+		"shortBreakDurationSeconds": 60,
+		"longBreakDurationSeconds":  120,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on settings, got %d", status)
+	}
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/start", user.Token, map[string]int{"baseVersion": 3})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on start, got %d", status)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	getState(t, engine, user.Token)
+
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/tasks/"+created.Task.ID, user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on get task, got %d", status)
+	}
+	var fetched taskEnvelope
+	if err := json.Unmarshal(body, &fetched); err != nil {
+		t.Fatalf("unmarshal task: %v", err)
+	}
+	if fetched.Task.CompletedPomodoros != 1 || fetched.Task.EstimatedPomodoros != 3 {
+		t.Fatalf("expected 1/3 pomodoros, got %d/%d", fetched.Task.CompletedPomodoros, fetched.Task.EstimatedPomodoros)
+	}
+
+	other := registerUser(t, engine, "other-tasks@example.com", "123456")
+	status, _ = requestJSON(t, engine, http.MethodDelete, "/api/tasks/"+created.Task.ID, other.Token, nil)
+	if status != http.StatusNotFound {
+		t.Fatalf("expected 404 deleting another user's task, got %d", status)
+	}
+
+	// Deleting the current task clears it from the timer under a new version.
+	before := getState(t, engine, user.Token)
+	status, _ = requestJSON(t, engine, http.MethodDelete, "/api/tasks/"+created.Task.ID, user.Token, nil)
+	if status != http.StatusNoContent {
+		t.Fatalf("expected 204 on delete task, got %d", status)
+	}
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/state", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 for state, got %d", status)
+	}
+	var after struct {
+		State struct {
+			Version       int     `json:"version"`
+			CurrentTaskID *string `json:"currentTaskId"`
+		} `json:"state"`
+	}
+	if err := json.Unmarshal(body, &after); err != nil {
+		t.Fatalf("unmarshal state: %v", err)
+	}
+	if after.State.Version != before.State.Version+1 || after.State.CurrentTaskID != nil {
+		t.Fatalf("expected the task cleared at version %d, got %s", before.State.Version+1, string(body))
 	}
-	return resp
 }
 
-func getState(t *testing.T, server http.Handler, token string) stateEnvelope {
-	t.Helper()
-	status, body := requestJSON(t, server, http.MethodGet, "/api/pomodoro/state", token, nil)
+func TestTimerPresets(t *testing.T) {
+	engine := setupTestEngine(t)
+	user := registerUser(t, engine, "presets@example.com", "123456")
+
+	deepWork := map[string]interface{}{
+		"name":                      "Deep work",
+		"focusDurationSeconds":      3000,
+		"shortBreakDurationSeconds": 600,
+		"longBreakDurationSeconds":  1800,
+		"longBreakInterval":         3,
+	}
+	status, body := requestJSON(t, engine, http.MethodPost, "/api/presets", user.Token, deepWork)
+	if status != http.StatusCreated {
+		t.Fatalf("expected 201 on create preset, got %d: %s", status, string(body))
+	}
+	var created presetEnvelope
+	if err := json.Unmarshal(body, &created); err != nil {
+		t.Fatalf("unmarshal preset: %v", err)
+	}
+	if status, _ = requestJSON(t, engine, http.MethodPost, "/api/presets", user.Token, deepWork); status != http.StatusConflict {
+		t.Fatalf("expected 409 for a duplicate preset name, got %d", status)
+	}
+	status, body = requestJSON(t, engine, http.MethodPost, "/api/presets", user.Token, map[string]interface{}{
+		"name":                      "Meetings day",
+		"focusDurationSeconds":      1500,
+		"shortBreakDurationSeconds": 0,
+		"longBreakDurationSeconds":  900,
+	})
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a zero duration, got %d: %s", status, string(body))
+	}
+
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/start", user.Token, map[string]int{"baseVersion": 1})
 	if status != http.StatusOK {
-		t.Fatalf("get state failed with status %d: %s", status, string(body))
+		t.Fatalf("expected 200 on start, got %d", status)
 	}
-	var stateResp stateEnvelope
-	if err := json.Unmarshal(body, &stateResp); err != nil {
-		t.Fatalf("unmarshal state response: %v", err)
+	activatePath := "/api/presets/" + created.Preset.ID + "/activate"
+	if status, _ = requestJSON(t, engine, http.MethodPost, activatePath, user.Token, map[string]int{"baseVersion": 1}); status != http.StatusConflict {
+		t.Fatalf("expected 409 activating with a stale baseVersion, got %d", status)
 	}
-	return stateResp
+
+	var activated struct {
+		State struct {
+			Version              int    `json:"version"`
+			Status               string `json:"status"`
+			RemainingSeconds     int    `json:"remainingSeconds"`
+			FocusDurationSeconds int    `json:"focusDurationSeconds"`
+			LongBreakInterval    int    `json:"longBreakInterval"`
+		} `json:"state"`
+	}
+	status, body = requestJSON(t, engine, http.MethodPost, activatePath, user.Token, map[string]int{"baseVersion": 2})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on activate, got %d: %s", status, string(body))
+	}
+	if err := json.Unmarshal(body, &activated); err != nil {
+		t.Fatalf("unmarshal state: %v", err)
+	}
+	if activated.State.Status != "running" || activated.State.FocusDurationSeconds != 3000 || activated.State.LongBreakInterval != 3 {
+		t.Fatalf("expected the preset applied to the running timer, got %s", string(body))
+	}
+	if activated.State.RemainingSeconds > 1500 {
+		t.Fatalf("expected the running session to keep its remaining time, got %d", activated.State.RemainingSeconds)
+	}
+
+	status, body = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/reset", user.Token, map[string]int{"baseVersion": activated.State.Version})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on reset, got %d", status)
+	}
+	if err := json.Unmarshal(body, &activated); err != nil {
+		t.Fatalf("unmarshal state: %v", err)
+	}
+	if activated.State.RemainingSeconds != 3000 {
+		t.Fatalf("expected the next session to use the preset, got %d", activated.State.RemainingSeconds)
+	}
+
+	deepWork["name"] = "  Deep work 50/10/30  "
+	status, body = requestJSON(t, engine, http.MethodPut, "/api/presets/"+created.Preset.ID, user.Token, deepWork)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on update preset, got %d: %s", status, string(body))
+	}
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/presets", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on list presets, got %d", status)
+	}
+	var listed struct {
+		Presets []struct {
+			Name string `json:"name"`
+		} `json:"presets"`
+	}
+	if err := json.Unmarshal(body, &listed); err != nil {
+		t.Fatalf("unmarshal presets: %v", err)
+	}
+	if len(listed.Presets) != 1 || listed.Presets[0].Name != "Deep work 50/10/30" {
+		t.Fatalf("expected the renamed preset, got %s", string(body))
+	}
+
+	other := registerUser(t, engine, "other-presets@example.com", "123456")
+	if status, _ = requestJSON(t, engine, http.MethodPost, activatePath, other.Token, map[string]int{"baseVersion": 1}); status != http.StatusNotFound {
+		t.Fatalf("expected 404 activating another user's preset, got %d", status)
+	}
+	if status, _ = requestJSON(t, engine, http.MethodDelete, "/api/presets/"+created.Preset.ID, user.Token, nil); status != http.StatusNoContent {
+		t.Fatalf("expected 204 on delete preset, got %d", status)
+	}
+	if status, _ = requestJSON(t, engine, http.MethodGet, "/api/presets/"+created.Preset.ID, user.Token, nil); status != http.StatusNotFound {
+		t.Fatalf("expected 404 after delete, got %d", status)
+	}
+}
+
+func TestCustomModes(t *testing.T) {
+	engine := setupTestEngine(t)
+	user := registerUser(t, engine, "modes@example.com", "123456")
+
+	review := map[string]interface{}{
+		"key":             "review",
+		"name":            "Review",
+		"durationSeconds": 1,
+		"color":           "#4f46e5",
+		"countsAsFocus":   true,
+	}
+	status, body := requestJSON(t, engine, http.MethodPost, "/api/modes", user.Token, review)
+	if status != http.StatusCreated {
+		t.Fatalf("expected 201 on create mode, got %d: %s", status, string(body))
+	}
+	var created modeEnvelope
+	if err := json.Unmarshal(body, &created); err != nil {
+		t.Fatalf("unmarshal mode: %v", err)
+	}
+	if created.Mode.Key != "review" || created.Mode.Color != "#4F46E5" {
+		t.Fatalf("unexpected mode: %s", string(body))
+	}
+	if status, _ = requestJSON(t, engine, http.MethodPost, "/api/modes", user.Token, review); status != http.StatusConflict {
+		t.Fatalf("expected 409 for a duplicate key, got %d", status)
+	}
+	for _, key := range []string{"focus", "Deep Work", ""} {
+		status, body = requestJSON(t, engine, http.MethodPost, "/api/modes", user.Token, map[string]interface{}{
+			"key":             key,
+			"name":            "Invalid",
+			"durationSeconds": 600,
+		})
+		if status != http.StatusBadRequest {
+			t.Fatalf("expected 400 for key %q, got %d: %s", key, status, string(body))
+		}
+	}
+
+	var listed struct {
+		Modes []struct {
+			Key     string `json:"key"`
+			BuiltIn bool   `json:"builtIn"`
+		} `json:"modes"`
+	}
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/modes", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on list modes, got %d", status)
+	}
+	if err := json.Unmarshal(body, &listed); err != nil {
+		t.Fatalf("unmarshal modes: %v", err)
+	}
+	if len(listed.Modes) != 4 || !listed.Modes[0].BuiltIn || listed.Modes[3].Key != "review" || listed.Modes[3].BuiltIn {
+		t.Fatalf("expected the built-in modes followed by review, got %s", string(body))
+	}
+
+	other := registerUser(t, engine, "other-modes@example.com", "123456")
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/mode", other.Token, map[string]interface{}{
+		"baseVersion": 1,
+		"mode":        "review",
+	})
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 switching to another user's mode, got %d", status)
+	}
+
+	var switched struct {
+		State struct {
+			Version          int    `json:"version"`
+			Mode             string `json:"mode"`
+			RemainingSeconds int    `json:"remainingSeconds"`
+		} `json:"state"`
+	}
+	status, body = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/mode", user.Token, map[string]interface{}{
+		"baseVersion": 1,
+		"mode":        "review",
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 switching to a custom mode, got %d: %s", status, string(body))
+	}
+	if err := json.Unmarshal(body, &switched); err != nil {
+		t.Fatalf("unmarshal state: %v", err)
+	}
+	if switched.State.Mode != "review" || switched.State.RemainingSeconds != 1 {
+		t.Fatalf("expected the custom mode duration, got %s", string(body))
+	}
+	if status, _ = requestJSON(t, engine, http.MethodDelete, "/api/modes/"+created.Mode.ID, user.Token, nil); status != http.StatusConflict {
+		t.Fatalf("expected 409 deleting the mode the timer is on, got %d", status)
+	}
+
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/start", user.Token, map[string]int{"baseVersion": switched.State.Version})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on start, got %d", status)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	state := getState(t, engine, user.Token)
+	if state.State.Mode != "short_break" || state.State.CompletedFocusCount != 1 {
+		t.Fatalf("expected a mode counting as focus to lead to a break, got %+v", state.State)
+	}
+
+	var history historyEnvelope
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/history?mode=review", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on history, got %d: %s", status, string(body))
+	}
+	if err := json.Unmarshal(body, &history); err != nil {
+		t.Fatalf("unmarshal history: %v", err)
+	}
+	if len(history.Sessions) != 1 || history.Sessions[0].Status != "completed" {
+		t.Fatalf("expected one completed review session, got %s", string(body))
+	}
+
+	var stats statsEnvelope
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/stats", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on stats, got %d", status)
+	}
+	if err := json.Unmarshal(body, &stats); err != nil {
+		t.Fatalf("unmarshal stats: %v", err)
+	}
+	if stats.Stats.Today.CompletedFocusSessions != 1 {
+		t.Fatalf("expected the review session counted as focus, got %s", string(body))
+	}
+
+	review["name"] = "Code review"
+	review["key"] = "code_review"
+	if status, _ = requestJSON(t, engine, http.MethodPut, "/api/modes/"+created.Mode.ID, user.Token, review); status != http.StatusBadRequest {
+		t.Fatalf("expected 400 changing the key, got %d", status)
+	}
+	delete(review, "key")
+	if status, body = requestJSON(t, engine, http.MethodPut, "/api/modes/"+created.Mode.ID, user.Token, review); status != http.StatusOK {
+		t.Fatalf("expected 200 on update mode, got %d: %s", status, string(body))
+	}
+	if status, _ = requestJSON(t, engine, http.MethodDelete, "/api/modes/"+created.Mode.ID, user.Token, nil); status != http.StatusNoContent {
+		t.Fatalf("expected 204 on delete mode, got %d", status)
+	}
+
+	// The session keeps counting as focus after its mode is gone.
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/stats", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on stats, got %d", status)
+	}
+	if err := json.Unmarshal(body, &stats); err != nil {
+		t.Fatalf("unmarshal stats: %v", err)
+	}
+	if stats.Stats.Today.CompletedFocusSessions != 1 {
+		t.Fatalf("expected the review session still counted as focus, got %s", string(body))
+	}
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/export?format=ics", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on export, got %d", status)
+	}
+	if strings.Count(string(body), "BEGIN:VEVENT") != 1 {
+		t.Fatalf("expected the review session in the calendar, got %s", string(body))
+	}
+
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/mode", user.Token, map[string]interface{}{
+		"baseVersion": state.State.Version,
+		"mode":        "review",
+	})
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 switching to a deleted mode, got %d", status)
+	}
+}
+
+func TestFocusGoals(t *testing.T) {
+	engine := setupTestEngine(t)
+	user := registerUser(t, engine, "goals@example.com", "123456")
+
+	if status, _ := requestJSON(t, engine, http.MethodGet, "/api/pomodoro/goals", user.Token, nil); status != http.StatusNotFound {
+		t.Fatalf("expected 404 before goals are set, got %d", status)
+	}
+	invalid := []map[string]interface{}{
+		{"timeZone": "UTC"},
+		{"dailyPomodoros": 0},
+		{"dailyPomodoros": 4, "timeZone": "Mars/Olympus"},
+		{"dailyFocusSeconds": 3600, "dailyMaxFocusSeconds": 1800},
+	}
+	for _, goals := range invalid {
+		if status, body := requestJSON(t, engine, http.MethodPut, "/api/pomodoro/goals", user.Token, goals); status != http.StatusBadRequest {
+			t.Fatalf("expected 400 for goals %v, got %d: %s", goals, status, string(body))
+		}
+	}
+
+	status, body := requestJSON(t, engine, http.MethodPut, "/api/pomodoro/goals", user.Token, map[string]interface{}{
+		"timeZone":             "Asia/Shanghai",
+		"dailyPomodoros":       1,
+		"weeklyPomodoros":      5,
+		"dailyMaxFocusSeconds": 1,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on update goals, got %d: %s", status, string(body))
+	}
+
+	// Goal progress is part of the state, so a goal change is a new version.
+	if version := getState(t, engine, user.Token).State.Version; version != 2 {
+		t.Fatalf("expected version 2 after updating goals, got %d", version)
+	}
+
+	status, _ = requestJSON(t, engine, http.MethodPut, "/api/pomodoro/settings", user.Token, map[string]interface{}{
+		"baseVersion":               2,
+		"focusDurationSeconds":      1,
+		"shortBreakDurationSeconds": 60,
+		"longBreakDurationSeconds":  120,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on settings, got %d", status)
+	}
+
+	type goalState struct {
+		State struct {
+			Version      int `json:"version"`
+			GoalProgress *struct {
+				TimeZone string `json:"timeZone"`
+				Today    struct {
+					CompletedPomodoros int  `json:"completedPomodoros"`
+					FocusSeconds       int  `json:"focusSeconds"`
+					Achieved           bool `json:"achieved"`
+				} `json:"today"`
+				ThisWeek struct {
+					CompletedPomodoros int  `json:"completedPomodoros"`
+					Achieved           bool `json:"achieved"`
+				} `json:"thisWeek"`
+				Overworked bool `json:"overworked"`
+			} `json:"goalProgress"`
+		} `json:"state"`
+	}
+	completeFocus := func(baseVersion int) goalState {
+		t.Helper()
+		if status, body := requestJSON(t, engine, http.MethodPost, "/api/pomodoro/start", user.Token, map[string]int{"baseVersion": baseVersion}); status != http.StatusOK {
+			t.Fatalf("expected 200 on start, got %d: %s", status, string(body))
+		}
+		time.Sleep(1100 * time.Millisecond)
+		status, body := requestJSON(t, engine, http.MethodGet, "/api/pomodoro/state", user.Token, nil)
+		if status != http.StatusOK {
+			t.Fatalf("expected 200 on state, got %d", status)
+		}
+		var state goalState
+		if err := json.Unmarshal(body, &state); err != nil {
+			t.Fatalf("unmarshal state: %v", err)
+		}
+		if state.State.GoalProgress == nil {
+			t.Fatalf("expected goal progress in the state, got %s", string(body))
+		}
+		return state
+	}
+
+	state := completeFocus(3)
+	progress := state.State.GoalProgress
+	if progress.TimeZone != "Asia/Shanghai" || progress.Today.CompletedPomodoros != 1 || !progress.Today.Achieved {
+		t.Fatalf("expected the daily goal achieved, got %+v", *progress)
+	}
+	if progress.ThisWeek.CompletedPomodoros != 1 || progress.ThisWeek.Achieved || progress.Overworked {
+		t.Fatalf("expected the weekly goal open and no overwork, got %+v", *progress)
+	}
+
+	status, body = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/mode", user.Token, map[string]interface{}{
+		"baseVersion": state.State.Version,
+		"mode":        "focus",
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on switch mode, got %d: %s", status, string(body))
+	}
+	var switched stateEnvelope
+	if err := json.Unmarshal(body, &switched); err != nil {
+		t.Fatalf("unmarshal state: %v", err)
+	}
+	state = completeFocus(switched.State.Version)
+	if progress := state.State.GoalProgress; progress.Today.FocusSeconds < 2 || !progress.Overworked {
+		t.Fatalf("expected overwork past the daily maximum, got %+v", *progress)
+	}
+
+	var goals struct {
+		Goals struct {
+			DailyPomodoros    *int `json:"dailyPomodoros"`
+			DailyFocusSeconds *int `json:"dailyFocusSeconds"`
+		} `json:"goals"`
+		Progress struct {
+			Today struct {
+				CompletedPomodoros int `json:"completedPomodoros"`
+			} `json:"today"`
+		} `json:"progress"`
+	}
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/goals", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on get goals, got %d: %s", status, string(body))
+	}
+	if err := json.Unmarshal(body, &goals); err != nil {
+		t.Fatalf("unmarshal goals: %v", err)
+	}
+	if goals.Goals.DailyPomodoros == nil || *goals.Goals.DailyPomodoros != 1 || goals.Goals.DailyFocusSeconds != nil {
+		t.Fatalf("expected the stored goals, got %s", string(body))
+	}
+	if goals.Progress.Today.CompletedPomodoros != 2 {
+		t.Fatalf("expected 2 pomodoros today, got %d", goals.Progress.Today.CompletedPomodoros)
+	}
+
+	if status, _ = requestJSON(t, engine, http.MethodDelete, "/api/pomodoro/goals", user.Token, nil); status != http.StatusNoContent {
+		t.Fatalf("expected 204 on delete goals, got %d", status)
+	}
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/state", user.Token, nil)
+	if status != http.StatusOK || strings.Contains(string(body), "goalProgress") {
+		t.Fatalf("expected no goal progress after deleting the goals, got %d: %s", status, string(body))
+	}
+}
+
+func TestGroupRooms(t *testing.T) {
+	engine := setupTestEngine(t)
+	owner := registerUser(t, engine, "room-owner@example.com", "123456")
+	member := registerUser(t, engine, "room-member@example.com", "123456")
+	outsider := registerUser(t, engine, "room-outsider@example.com", "123456")
+
+	status, body := requestJSON(t, engine, http.MethodPost, "/api/rooms", owner.Token, map[string]interface{}{
+		"name":                      "Focus sprint",
+		"focusDurationSeconds":      1,
+		"shortBreakDurationSeconds": 60,
+	})
+	if status != http.StatusCreated {
+		t.Fatalf("expected 201 on create room, got %d: %s", status, string(body))
+	}
+	var created struct {
+		Room struct {
+			ID         string `json:"id"`
+			InviteCode string `json:"inviteCode"`
+		} `json:"room"`
+	}
+	if err := json.Unmarshal(body, &created); err != nil {
+		t.Fatalf("unmarshal room: %v", err)
+	}
+	roomPath := "/api/rooms/" + created.Room.ID
+
+	status, body = requestJSON(t, engine, http.MethodPost, "/api/rooms/join", member.Token, map[string]string{
+		"inviteCode": strings.ToLower(created.Room.InviteCode),
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on join, got %d: %s", status, string(body))
+	}
+	if status, _ = requestJSON(t, engine, http.MethodGet, roomPath+"/state", outsider.Token, nil); status != http.StatusNotFound {
+		t.Fatalf("expected 404 for a non-member, got %d", status)
+	}
+	if status, _ = requestJSON(t, engine, http.MethodPost, roomPath+"/start", member.Token, map[string]int{"baseVersion": 1}); status != http.StatusForbidden {
+		t.Fatalf("expected 403 when a member starts the timer, got %d", status)
+	}
+
+	status, body = requestJSON(t, engine, http.MethodPost, roomPath+"/start", owner.Token, map[string]int{"baseVersion": 1})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on room start, got %d: %s", status, string(body))
+	}
+	if status, _ = requestJSON(t, engine, http.MethodPost, roomPath+"/reset", owner.Token, map[string]int{"baseVersion": 1}); status != http.StatusConflict {
+		t.Fatalf("expected 409 with a stale baseVersion, got %d", status)
+	}
+
+	// The personal timer of a member is not affected by the room.
+	if state := getState(t, engine, member.Token); state.State.Status != "idle" {
+		t.Fatalf("expected the member's own timer to stay idle, got %s", state.State.Status)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	status, body = requestJSON(t, engine, http.MethodGet, roomPath+"/state", member.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on room state, got %d: %s", status, string(body))
+	}
+	var roomState stateEnvelope
+	if err := json.Unmarshal(body, &roomState); err != nil {
+		t.Fatalf("unmarshal room state: %v", err)
+	}
+	if roomState.State.Mode != "short_break" || roomState.State.Status != "idle" || roomState.State.CompletedFocusCount != 1 {
+		t.Fatalf("expected the focus phase to complete, got %s", string(body))
+	}
+
+	for _, user := range []authResponse{owner, member} {
+		status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/history", user.Token, nil)
+		if status != http.StatusOK {
+			t.Fatalf("expected 200 on history, got %d", status)
+		}
+		var history historyEnvelope
+		if err := json.Unmarshal(body, &history); err != nil {
+			t.Fatalf("unmarshal history: %v", err)
+		}
+		if len(history.Sessions) != 1 || history.Sessions[0].Mode != "focus" || history.Sessions[0].Status != "completed" {
+			t.Fatalf("expected one completed focus session for %s, got %s", user.User.Email, string(body))
+		}
+	}
+
+	if status, _ = requestJSON(t, engine, http.MethodDelete, roomPath+"/members/"+owner.User.ID, owner.Token, nil); status != http.StatusBadRequest {
+		t.Fatalf("expected 400 when the owner leaves, got %d", status)
+	}
+	if status, _ = requestJSON(t, engine, http.MethodDelete, roomPath+"/members/"+member.User.ID, member.Token, nil); status != http.StatusNoContent {
+		t.Fatalf("expected 204 when a member leaves, got %d", status)
+	}
+	if status, _ = requestJSON(t, engine, http.MethodGet, roomPath+"/state", member.Token, nil); status != http.StatusNotFound {
+		t.Fatalf("expected 404 after leaving, got %d", status)
+	}
+	if status, _ = requestJSON(t, engine, http.MethodDelete, roomPath, owner.Token, nil); status != http.StatusNoContent {
+		t.Fatalf("expected 204 on delete room, got %d", status)
+	}
+}
+
+func TestHistoryPagination(t *testing.T) {
+	engine := setupTestEngine(t)
+	user := registerUser(t, engine, "history@example.com", "123456")
+
+	version := 1
+	for i := 0; i < 5; i++ {
+		for _, action := range []string{"start", "reset"} {
+			status, _ := requestJSON(t, engine, http.MethodPost, "/api/pomodoro/"+action, user.Token, map[string]int{"baseVersion": version})
+			if status != http.StatusOK {
+				t.Fatalf("expected 200 on %s, got %d", action, status)
+			}
+			version++
+		}
+	}
+
+	seen := make(map[string]bool)
+	path := "/api/pomodoro/history?limit=2&mode=focus&status=cancelled"
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("pagination did not terminate")
+		}
+		status, body := requestJSON(t, engine, http.MethodGet, path, user.Token, nil)
+		if status != http.StatusOK {
+			t.Fatalf("expected 200 for history page, got %d: %s", status, string(body))
+		}
+		var page historyEnvelope
+		if err := json.Unmarshal(body, &page); err != nil {
+			t.Fatalf("unmarshal history page: %v", err)
+		}
+		for _, session := range page.Sessions {
+			if seen[session.ID] {
+				t.Fatalf("session %s returned twice", session.ID)
+			}
+			seen[session.ID] = true
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		path = "/api/pomodoro/history?limit=2&mode=focus&status=cancelled&cursor=" + *page.NextCursor
+	}
+	if len(seen) != 5 {
+		t.Fatalf("expected to walk 5 sessions, got %d", len(seen))
+	}
+
+	status, body := requestJSON(t, engine, http.MethodGet, "/api/pomodoro/history?status=completed", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 for filtered history, got %d", status)
+	}
+	var completed historyEnvelope
+	if err := json.Unmarshal(body, &completed); err != nil {
+		t.Fatalf("unmarshal filtered history: %v", err)
+	}
+	if len(completed.Sessions) != 0 || completed.NextCursor != nil {
+		t.Fatalf("expected no completed sessions, got %d", len(completed.Sessions))
+	}
+
+	status, _ = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/history?cursor=not-a-cursor", user.Token, nil)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for malformed cursor, got %d", status)
+	}
+}
+
+func TestSessionPauseTracking(t *testing.T) {
+	engine := setupTestEngine(t)
+	user := registerUser(t, engine, "pauses@example.com", "123456")
+
+	version := 1
+	for i, action := range []string{"start", "pause", "start", "pause", "reset"} {
+		if i == 2 {
+			time.Sleep(1100 * time.Millisecond)
+		}
+		status, body := requestJSON(t, engine, http.MethodPost, "/api/pomodoro/"+action, user.Token, map[string]int{
+			"baseVersion": version,
+		})
+		if status != http.StatusOK {
+			t.Fatalf("expected 200 on %s, got %d: %s", action, status, string(body))
+		}
+		version++
+	}
+
+	status, body := requestJSON(t, engine, http.MethodGet, "/api/pomodoro/history?limit=1", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 for history, got %d", status)
+	}
+	var history historyEnvelope
+	if err := json.Unmarshal(body, &history); err != nil {
+		t.Fatalf("unmarshal history: %v", err)
+	}
+	if len(history.Sessions) != 1 {
+		t.Fatalf("expected one session, got %d", len(history.Sessions))
+	}
+	session := history.Sessions[0]
+	if session.Status != "cancelled" || session.PauseCount != 2 || len(session.Pauses) != 2 {
+		t.Fatalf("expected a cancelled session with two pauses, got %s", string(body))
+	}
+	if session.PausedSeconds < 1 {
+		t.Fatalf("expected at least one paused second, got %d", session.PausedSeconds)
+	}
+	for _, pause := range session.Pauses {
+		if pause.ResumedAt == nil || pause.ResumedAt.Before(pause.PausedAt) {
+			t.Fatalf("expected every pause to be closed, got %s", string(body))
+		}
+	}
+}
+
+func TestSessionExport(t *testing.T) {
+	engine := setupTestEngine(t)
+	user := registerUser(t, engine, "export@example.com", "123456")
+
+	status, body := requestJSON(t, engine, http.MethodPost, "/api/tasks", user.Token, map[string]interface{}{
+		"title": "Draft, review; ship",
+	})
+	if status != http.StatusCreated {
+		t.Fatalf("expected 201 on create task, got %d: %s", status, string(body))
+	}
+	var created taskEnvelope
+	if err := json.Unmarshal(body, &created); err != nil {
+		t.Fatalf("unmarshal task: %v", err)
+	}
+
+	status, _ = requestJSON(t, engine, http.MethodPut, "/api/pomodoro/task", user.Token, map[string]interface{}{
+		"baseVersion": 1,
+		"taskId":      created.Task.ID,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on set current task, got %d", status)
+	}
+	status, _ = requestJSON(t, engine, http.MethodPut, "/api/pomodoro/settings", user.Token, map[string]interface{}{
+		"baseVersion":               2,
+		"focusDurationSeconds":      1,
+		"shortBreakDurationSeconds": 60,
+		"longBreakDurationSeconds":  120,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on settings, got %d", status)
+	}
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/start", user.Token, map[string]int{"baseVersion": 3})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on start, got %d", status)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	state := getState(t, engine, user.Token)
+	for _, action := range []string{"start", "reset"} {
+		status, _ = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/"+action, user.Token, map[string]int{
+			"baseVersion": state.State.Version,
+		})
+		if status != http.StatusOK {
+			t.Fatalf("expected 200 on %s, got %d", action, status)
+		}
+		state.State.Version++
+	}
+
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/export?format=json", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 for json export, got %d: %s", status, string(body))
+	}
+	var exported historyEnvelope
+	if err := json.Unmarshal(body, &exported); err != nil {
+		t.Fatalf("unmarshal json export: %v", err)
+	}
+	if len(exported.Sessions) != 2 {
+		t.Fatalf("expected two exported sessions, got %s", string(body))
+	}
+
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/export?format=csv", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 for csv export, got %d", status)
+	}
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "id,mode,status,task_id") {
+		t.Fatalf("expected a header and two rows, got %q", string(body))
+	}
+
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/export?format=ics", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 for ics export, got %d", status)
+	}
+	calendar := string(body)
+	if strings.Count(calendar, "BEGIN:VEVENT") != 1 {
+		t.Fatalf("expected one event for the completed focus session, got %q", calendar)
+	}
+	if !strings.Contains(calendar, `SUMMARY:Focus: Draft\, review\; ship`+"\r\n") {
+		t.Fatalf("expected an escaped task summary, got %q", calendar)
+	}
+
+	status, _ = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/export?format=xml", user.Token, nil)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown format, got %d", status)
+	}
+}
+
+func TestSessionImport(t *testing.T) {
+	engine := setupTestEngine(t)
+	user := registerUser(t, engine, "import@example.com", "123456")
+
+	payload := map[string]interface{}{
+		"sessions": []map[string]interface{}{
+			{"mode": "focus", "status": "completed", "plannedDurationSeconds": 1500, "startedAt": "2025-03-01T09:00:00Z"},
+			{"mode": "focus", "status": "completed", "plannedDurationSeconds": 1500, "startedAt": "2025-03-01T09:00:00.5Z"},
+			{"mode": "focus", "status": "running", "plannedDurationSeconds": 1500, "startedAt": "2025-03-01T10:00:00Z"},
+			{"mode": "nap", "status": "completed", "plannedDurationSeconds": 1500, "startedAt": "2025-03-01T11:00:00Z"},
+			{"mode": "short_break", "status": "cancelled", "plannedDurationSeconds": 300, "actualDurationSeconds": 120, "startedAt": "2025-03-01T12:00:00Z"},
+		},
+	}
+	status, body := requestJSON(t, engine, http.MethodPost, "/api/pomodoro/import", user.Token, payload)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on json import, got %d: %s", status, string(body))
+	}
+	var report importReportEnvelope
+	if err := json.Unmarshal(body, &report); err != nil {
+		t.Fatalf("unmarshal import report: %v", err)
+	}
+	if report.Accepted != 2 || report.Rejected != 3 || len(report.Rows) != 5 {
+		t.Fatalf("expected 2 accepted and 3 rejected rows, got %s", string(body))
+	}
+	for i, code := range []string{"", "duplicate", "invalid_status", "invalid_mode", ""} {
+		row := report.Rows[i]
+		if row.Row != i+1 || row.Code != code || (code == "") != (row.Status == "accepted") {
+			t.Fatalf("unexpected report for row %d: %+v", i+1, row)
+		}
+	}
+
+	status, body = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/import", user.Token, payload)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on repeated import, got %d", status)
+	}
+	if err := json.Unmarshal(body, &report); err != nil {
+		t.Fatalf("unmarshal import report: %v", err)
+	}
+	if report.Accepted != 0 || report.Rows[0].Code != "duplicate" || report.Rows[4].Code != "duplicate" {
+		t.Fatalf("expected previously imported rows to be duplicates, got %s", string(body))
+	}
+
+	csvBody := "mode,status,started_at,planned_duration_seconds,actual_duration_seconds\n" +
+		"focus,cancelled,2025-03-02T09:00:00Z,1500,600\n" +
+		"focus,completed,2025-03-02T10:00:00Z,1500,9999\n"
+	req := httptest.NewRequest(http.MethodPost, "/api/pomodoro/import", strings.NewReader(csvBody))
+	req.Header.Set("Content-Type", "text/csv")
+	req.Header.Set("Authorization", "Bearer "+user.Token)
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200 on csv import, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &report); err != nil {
+		t.Fatalf("unmarshal import report: %v", err)
+	}
+	if report.Accepted != 1 || report.Rows[1].Code != "invalid_actual_duration" {
+		t.Fatalf("expected one accepted csv row, got %s", recorder.Body.String())
+	}
+
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/history", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 for history, got %d", status)
+	}
+	var history historyEnvelope
+	if err := json.Unmarshal(body, &history); err != nil {
+		t.Fatalf("unmarshal history: %v", err)
+	}
+	if len(history.Sessions) != 3 {
+		t.Fatalf("expected three imported sessions in history, got %d", len(history.Sessions))
+	}
+	if history.Sessions[0].Status != "cancelled" || history.Sessions[0].Mode != "focus" {
+		t.Fatalf("unexpected newest imported session: %s", string(body))
+	}
+
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/import", user.Token, map[string]interface{}{"sessions": []int{}})
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for empty import, got %d", status)
+	}
+}
+
+func TestPomodoroAutoAdvanceStops(t *testing.T) {
+	engine, database := setupTestEngineWithDB(t)
+	user := registerUser(t, engine, "autostop@example.com", "123456")
+
+	status, body := requestJSON(t, engine, http.MethodPut, "/api/pomodoro/settings", user.Token, map[string]interface{}{
+		"baseVersion":               1,
+		"focusDurationSeconds":      1,
+		"shortBreakDurationSeconds": 1,
+		"longBreakDurationSeconds":  1,
+		"autoStartBreaks":           true,
+		"autoStartFocus":            true,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on settings, got %d: %s", status, string(body))
+	}
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/start", user.Token, map[string]int{"baseVersion": 2})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on start, got %d", status)
+	}
+
+	// Backdate the timer as if the server had been down for a minute, so
+	// dozens of phases ran out, more than one settlement completes.
+	startedAt := time.Now().UTC().Add(-time.Minute).Format("2006-01-02T15:04:05.000000000Z07:00")
+	for _, query := range []string{
+		`UPDATE pomodoro_states SET started_at = ? WHERE user_id = ?`,
+		`UPDATE pomodoro_sessions SET started_at = ? WHERE user_id = ?`,
+	} {
+		if _, err := database.Exec(query, startedAt, user.User.ID); err != nil {
+			t.Fatalf("backdate timer: %v", err)
+		}
+	}
+
+	state := getState(t, engine, user.Token)
+	if state.State.Status != "idle" || !state.State.AutoStopped {
+		t.Fatalf("expected an auto-stopped idle timer, got %+v", state.State)
+	}
+
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/history?limit=20", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 for history, got %d", status)
+	}
+	var history historyEnvelope
+	if err := json.Unmarshal(body, &history); err != nil {
+		t.Fatalf("unmarshal history: %v", err)
+	}
+	if len(history.Sessions) != 8 {
+		t.Fatalf("expected 8 settled sessions, got %d", len(history.Sessions))
+	}
+	for _, session := range history.Sessions {
+		if session.Status != "completed" {
+			t.Fatalf("expected every settled session to be completed, got %s", session.Status)
+		}
+	}
+
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/events/log?limit=2", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 for event log, got %d", status)
+	}
+	var eventLog eventLogEnvelope
+	if err := json.Unmarshal(body, &eventLog); err != nil {
+		t.Fatalf("unmarshal event log: %v", err)
+	}
+	if len(eventLog.Events) != 2 || eventLog.Events[0].Type != "auto_stop" || eventLog.Events[0].ToStatus != "idle" ||
+		eventLog.Events[1].Type != "complete" || eventLog.Events[1].ToStatus != "running" {
+		t.Fatalf("expected complete then auto_stop, got %s", string(body))
+	}
+
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/reset", user.Token, map[string]int{"baseVersion": state.State.Version})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on reset, got %d", status)
+	}
+	if state = getState(t, engine, user.Token); state.State.AutoStopped {
+		t.Fatalf("expected reset to clear autoStopped, got %+v", state.State)
+	}
+}
+
+func TestOfflineSyncReplay(t *testing.T) {
+	engine := setupTestEngine(t)
+	user := registerUser(t, engine, "sync@example.com", "123456")
+
+	now := time.Now().UTC()
+	status, body := requestJSON(t, engine, http.MethodPost, "/api/pomodoro/sync", user.Token, map[string]interface{}{
+		"sentAt": now,
+		"commands": []map[string]interface{}{
+			{"id": "c1", "type": "start", "at": now.Add(-30 * time.Second)},
+			{"id": "c2", "type": "pause", "at": now.Add(-20 * time.Second)},
+			{"id": "c3", "type": "start", "at": now.Add(-10 * time.Second)},
+		},
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on sync, got %d: %s", status, string(body))
+	}
+	var synced syncEnvelope
+	if err := json.Unmarshal(body, &synced); err != nil {
+		t.Fatalf("unmarshal sync response: %v", err)
+	}
+	for i, result := range synced.Results {
+		if result.Status != "applied" || result.Version != i+2 {
+			t.Fatalf("expected command %d to be applied at version %d, got %s", i+1, i+2, string(body))
+		}
+	}
+	// 1500s focus, 10s paused offline, 20s run in total.
+	if synced.State.Status != "running" || synced.State.RemainingSeconds < 1478 || synced.State.RemainingSeconds > 1480 {
+		t.Fatalf("expected a running timer with about 1480s left, got %s", string(body))
+	}
+
+	// The device clock runs an hour fast; its pause five seconds ago is
+	// shifted back onto the server clock.
+	skewed := now.Add(time.Hour)
+	status, body = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/sync", user.Token, map[string]interface{}{
+		"sentAt": skewed,
+		"commands": []map[string]interface{}{
+			{"id": "late", "type": "start", "at": skewed.Add(-time.Minute)},
+			{"id": "pause", "type": "pause", "at": skewed.Add(-5 * time.Second)},
+			{"id": "again", "type": "pause", "at": skewed.Add(-4 * time.Second)},
+			{"id": "bad", "type": "mode", "mode": "nap", "at": skewed.Add(-3 * time.Second)},
+		},
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on skewed sync, got %d: %s", status, string(body))
+	}
+	if err := json.Unmarshal(body, &synced); err != nil {
+		t.Fatalf("unmarshal sync response: %v", err)
+	}
+	for i, expected := range []string{"superseded", "applied", "noop", "rejected"} {
+		if synced.Results[i].Status != expected {
+			t.Fatalf("expected command %d to be %s, got %s", i+1, expected, string(body))
+		}
+	}
+	if synced.Results[3].Code != "invalid_mode" {
+		t.Fatalf("expected invalid_mode for the bad command, got %s", synced.Results[3].Code)
+	}
+	if drift := synced.Results[1].EffectiveAt.Sub(now.Add(-5 * time.Second)); drift < 0 || drift > 2*time.Second {
+		t.Fatalf("expected the pause to take effect about 5s ago, drift %s", drift)
+	}
+	if synced.State.Status != "paused" || synced.State.Version != 5 {
+		t.Fatalf("expected a paused state at version 5, got %s", string(body))
+	}
+
+	state := getState(t, engine, user.Token)
+	if state.State.Version != 5 || state.State.Status != "paused" {
+		t.Fatalf("expected synced state to be stored, got %+v", state.State)
+	}
+
+	status, body = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/sync", user.Token, map[string]interface{}{
+		"commands": []map[string]interface{}{},
+	})
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for empty sync, got %d: %s", status, string(body))
+	}
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	engine := setupTestEngine(t)
+	user := registerUser(t, engine, "retry@example.com", "123456")
+	other := registerUser(t, engine, "retry-other@example.com", "123456")
+
+	first := idempotentRequest(t, engine, "/api/pomodoro/start", user.Token, "start-1", map[string]int{"baseVersion": 1})
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected 200 on start, got %d: %s", first.Code, first.Body.String())
+	}
+
+	// Another device moves the state on before the retry arrives.
+	status, _ := requestJSON(t, engine, http.MethodPost, "/api/pomodoro/pause", user.Token, map[string]int{"baseVersion": 2})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on pause, got %d", status)
+	}
+
+	retry := idempotentRequest(t, engine, "/api/pomodoro/start", user.Token, "start-1", map[string]int{"baseVersion": 1})
+	if retry.Code != http.StatusOK || retry.Body.String() != first.Body.String() {
+		t.Fatalf("expected the original start response on retry, got %d: %s", retry.Code, retry.Body.String())
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected replayed header on retry")
+	}
+	if state := getState(t, engine, user.Token); state.State.Version != 3 || state.State.Status != "paused" {
+		t.Fatalf("expected retry not to run start again, got %+v", state.State)
+	}
+
+	reused := idempotentRequest(t, engine, "/api/pomodoro/start", user.Token, "start-1", map[string]int{"baseVersion": 3})
+	var apiErr apiErrorEnvelope
+	if err := json.Unmarshal(reused.Body.Bytes(), &apiErr); err != nil {
+		t.Fatalf("unmarshal reused key response: %v", err)
+	}
+	if reused.Code != http.StatusUnprocessableEntity || apiErr.Error.Code != "idempotency_key_reused" {
+		t.Fatalf("expected 422 idempotency_key_reused, got %d: %s", reused.Code, reused.Body.String())
+	}
+
+	// Conflicts are stored too, so the retry sees the same 409.
+	conflict := idempotentRequest(t, engine, "/api/pomodoro/reset", user.Token, "reset-1", map[string]int{"baseVersion": 1})
+	if conflict.Code != http.StatusConflict {
+		t.Fatalf("expected 409 on stale reset, got %d", conflict.Code)
+	}
+	conflictRetry := idempotentRequest(t, engine, "/api/pomodoro/reset", user.Token, "reset-1", map[string]int{"baseVersion": 1})
+	if conflictRetry.Code != http.StatusConflict || conflictRetry.Body.String() != conflict.Body.String() {
+		t.Fatalf("expected the stored 409 on retry, got %d: %s", conflictRetry.Code, conflictRetry.Body.String())
+	}
+
+	// Keys are scoped per user.
+	otherStart := idempotentRequest(t, engine, "/api/pomodoro/start", other.Token, "start-1", map[string]int{"baseVersion": 1})
+	if otherStart.Code != http.StatusOK || otherStart.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("expected a fresh start for another user, got %d: %s", otherStart.Code, otherStart.Body.String())
+	}
+
+	tooLong := idempotentRequest(t, engine, "/api/pomodoro/pause", user.Token, strings.Repeat("k", 256), map[string]int{"baseVersion": 3})
+	if tooLong.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an oversized key, got %d", tooLong.Code)
+	}
+
+	// The query is part of the request, so reusing a key with another
+	// import format is rejected rather than replayed.
+	imported := idempotentRequest(t, engine, "/api/pomodoro/import?format=json", user.Token, "import-1", map[string]interface{}{"sessions": []interface{}{}})
+	if imported.Code >= http.StatusInternalServerError {
+		t.Fatalf("expected a stored import response, got %d: %s", imported.Code, imported.Body.String())
+	}
+	otherFormat := idempotentRequest(t, engine, "/api/pomodoro/import?format=csv", user.Token, "import-1", map[string]interface{}{"sessions": []interface{}{}})
+	if otherFormat.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 when the query changes under a key, got %d: %s", otherFormat.Code, otherFormat.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/pomodoro/start", io.MultiReader(
+		strings.NewReader(`{"baseVersion":3,"pad":"`),
+		bytes.NewReader(bytes.Repeat([]byte("x"), 32<<20)),
+		strings.NewReader(`"}`),
+	))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+user.Token)
+	req.Header.Set("Idempotency-Key", "oversized-body")
+	oversized := httptest.NewRecorder()
+	engine.ServeHTTP(oversized, req)
+	if oversized.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for an oversized body, got %d: %s", oversized.Code, oversized.Body.String())
+	}
+}
+
+func TestPomodoroStats(t *testing.T) {
+	engine := setupTestEngine(t)
+	user := registerUser(t, engine, "stats@example.com", "123456")
+
+	status, _ := requestJSON(t, engine, http.MethodPut, "/api/pomodoro/settings", user.Token, map[string]interface{}{
+		"baseVersion":               1,
+		"focusDurationSeconds":      1,
+		"shortBreakDurationSeconds": 60,
+		"longBreakDurationSeconds":  120,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on settings, got %d", status)
+	}
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/start", user.Token, map[string]int{"baseVersion": 2})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on start, got %d", status)
+	}
+	time.Sleep(1100 * time.Millisecond)
+
+	status, body := requestJSON(t, engine, http.MethodGet, "/api/pomodoro/stats?tz=Asia/Shanghai&days=7", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on stats, got %d: %s", status, string(body))
+	}
+	var stats statsEnvelope
+	if err := json.Unmarshal(body, &stats); err != nil {
+		t.Fatalf("unmarshal stats: %v", err)
+	}
+	if stats.Stats.TimeZone != "Asia/Shanghai" {
+		t.Fatalf("expected Asia/Shanghai time zone, got %s", stats.Stats.TimeZone)
+	}
+	localToday := time.Now().In(time.FixedZone("CST", 8*3600)).Format("2006-01-02")
+	if stats.Stats.Today.Start != localToday || stats.Stats.Today.CompletedFocusSessions != 1 {
+		t.Fatalf("expected 1 session on %s, got %+v", localToday, stats.Stats.Today)
+	}
+	if len(stats.Stats.Daily) != 7 {
+		t.Fatalf("expected 7 daily totals, got %d", len(stats.Stats.Daily))
+	}
+	if stats.Stats.Streak.CurrentDays != 1 || stats.Stats.Streak.LongestDays != 1 {
+		t.Fatalf("expected 1 day streak, got %+v", stats.Stats.Streak)
+	}
+	if stats.Stats.CompletionRate != 1 {
+		t.Fatalf("expected completion rate 1, got %f", stats.Stats.CompletionRate)
+	}
+
+	// The last offset change in New York is at most a few months back. A
+	// session late on the evening before it belongs to that evening's date,
+	// which only holds when the database applies the offset of the time the
+	// session started. Sessions older than the returned series are ignored.
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load time zone: %v", err)
+	}
+	transition, _ := time.Now().In(newYork).ZoneBounds()
+	eveningBefore := time.Date(transition.Year(), transition.Month(), transition.Day()-1, 23, 30, 0, 0, newYork)
+	status, body = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/import", user.Token, map[string]interface{}{
+		"sessions": []map[string]interface{}{
+			{"mode": "focus", "status": "completed", "plannedDurationSeconds": 1500, "startedAt": eveningBefore.UTC().Format(time.RFC3339)},
+			{"mode": "focus", "status": "completed", "plannedDurationSeconds": 1500, "startedAt": "2020-01-10T12:00:00Z"},
+		},
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on import, got %d: %s", status, string(body))
+	}
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/stats?tz=America/New_York&days=366", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on stats, got %d: %s", status, string(body))
+	}
+	var yearStats struct {
+		Stats struct {
+			Daily []struct {
+				Start                  string `json:"start"`
+				CompletedFocusSessions int    `json:"completedFocusSessions"`
+			} `json:"daily"`
+			ByMode map[string]struct {
+				Sessions int `json:"sessions"`
+			} `json:"byMode"`
+		} `json:"stats"`
+	}
+	if err := json.Unmarshal(body, &yearStats); err != nil {
+		t.Fatalf("unmarshal stats: %v", err)
+	}
+	counts := make(map[string]int)
+	for _, day := range yearStats.Stats.Daily {
+		counts[day.Start] = day.CompletedFocusSessions
+	}
+	if day := eveningBefore.Format("2006-01-02"); counts[day] != 1 {
+		t.Fatalf("expected the session on %s, got %d there", day, counts[day])
+	}
+	if yearStats.Stats.ByMode["focus"].Sessions != 2 {
+		t.Fatalf("expected only sessions within the range by mode, got %+v", yearStats.Stats.ByMode)
+	}
+
+	status, _ = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/stats?tz=Mars/Olympus", user.Token, nil)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown time zone, got %d", status)
+	}
+}
+
+func TestPomodoroStatsStreakBeyondRange(t *testing.T) {
+	engine := setupTestEngine(t)
+	user := registerUser(t, engine, "streak@example.com", "123456")
+
+	// 400 consecutive days up to yesterday, after an earlier 2 day streak,
+	// reach further back than any of the returned series.
+	yesterday := time.Now().UTC().AddDate(0, 0, -1)
+	day := time.Date(yesterday.Year(), yesterday.Month(), yesterday.Day(), 12, 0, 0, 0, time.UTC)
+	sessions := make([]map[string]interface{}, 0, 402)
+	for i := 0; i < 400; i++ {
+		sessions = append(sessions, map[string]interface{}{
+			"mode":                   "focus",
+			"status":                 "completed",
+			"plannedDurationSeconds": 1500,
+			"startedAt":              day.AddDate(0, 0, -i).Format(time.RFC3339),
+		})
+	}
+	for _, i := range []int{402, 403} {
+		sessions = append(sessions, map[string]interface{}{
+			"mode":                   "focus",
+			"status":                 "completed",
+			"plannedDurationSeconds": 1500,
+			"startedAt":              day.AddDate(0, 0, -i).Format(time.RFC3339),
+		})
+	}
+	status, body := requestJSON(t, engine, http.MethodPost, "/api/pomodoro/import", user.Token, map[string]interface{}{"sessions": sessions})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on import, got %d: %s", status, string(body))
+	}
+
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/stats?days=7", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on stats, got %d: %s", status, string(body))
+	}
+	var stats statsEnvelope
+	if err := json.Unmarshal(body, &stats); err != nil {
+		t.Fatalf("unmarshal stats: %v", err)
+	}
+	if stats.Stats.Streak.CurrentDays != 400 || stats.Stats.Streak.LongestDays != 400 {
+		t.Fatalf("expected a 400 day streak, got %+v", stats.Stats.Streak)
+	}
+}
+
+func TestPomodoroEventStream(t *testing.T) {
+	engine := setupTestEngine(t)
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+
+	user := registerUser(t, engine, "stream@example.com", "123456")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/pomodoro/events", nil)
+	if err != nil {
+		t.Fatalf("build events request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+user.Token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("open events stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 for events stream, got %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/event-stream") {
+		t.Fatalf("unexpected content type: %s", contentType)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	id, initial := readStateEvent(t, reader)
+	if id != "1" || initial.State.Version != 1 {
+		t.Fatalf("expected initial event for version 1, got id %s version %d", id, initial.State.Version)
+	}
+
+	status, _ := requestJSON(t, engine, http.MethodPost, "/api/pomodoro/start", user.Token, map[string]int{"baseVersion": 1})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on start, got %d", status)
+	}
+
+	id, pushed := readStateEvent(t, reader)
+	if id != "2" || pushed.State.Version != 2 {
+		t.Fatalf("expected pushed event for version 2, got id %s version %d", id, pushed.State.Version)
+	}
+
+	status, _ = requestJSON(t, engine, http.MethodPut, "/api/pomodoro/goals", user.Token, map[string]int{"dailyPomodoros": 4})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on update goals, got %d", status)
+	}
+	id, pushed = readStateEvent(t, reader)
+	if id != "3" || pushed.State.Version != 3 {
+		t.Fatalf("expected pushed event for version 3 after updating goals, got id %s version %d", id, pushed.State.Version)
+	}
+
+	if status, _ = requestJSON(t, engine, http.MethodDelete, "/api/pomodoro/goals", user.Token, nil); status != http.StatusNoContent {
+		t.Fatalf("expected 204 on delete goals, got %d", status)
+	}
+	id, pushed = readStateEvent(t, reader)
+	if id != "4" || pushed.State.Version != 4 {
+		t.Fatalf("expected pushed event for version 4 after deleting goals, got id %s version %d", id, pushed.State.Version)
+	}
+}
+
+func TestPomodoroWebSocketCommands(t *testing.T) {
+	engine := setupTestEngine(t)
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+
+	user := registerUser(t, engine, "socket@example.com", "123456")
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/pomodoro/ws"
+	foreign, err := websocket.NewConfig(wsURL, "https://evil.example.com")
+	if err != nil {
+		t.Fatalf("build websocket config: %v", err)
+	}
+	foreign.Header.Set("Authorization", "Bearer "+user.Token)
+	if conn, err := websocket.DialConfig(foreign); err == nil {
+		conn.Close()
+		t.Fatalf("expected websocket from a foreign origin to be refused")
+	}
+
+	config, err := websocket.NewConfig(wsURL, "http://localhost:5173")
+	if err != nil {
+		t.Fatalf("build websocket config: %v", err)
+	}
+	config.Header.Set("Authorization", "Bearer "+user.Token)
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		t.Fatalf("dial websocket: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	var initial socketReply
+	if err := websocket.JSON.Receive(conn, &initial); err != nil {
+		t.Fatalf("receive initial state: %v", err)
+	}
+	if initial.Type != "state" || initial.State.Version != 1 {
+		t.Fatalf("expected initial state version 1, got %+v", initial)
+	}
+
+	if err := websocket.JSON.Send(conn, map[string]interface{}{"id": "c1", "type": "start", "baseVersion": 1}); err != nil {
+		t.Fatalf("send start command: %v", err)
+	}
+	var started socketReply
+	if err := websocket.JSON.Receive(conn, &started); err != nil {
+		t.Fatalf("receive start reply: %v", err)
+	}
+	if started.ID != "c1" || started.Type != "state" || started.State.Version != 2 {
+		t.Fatalf("expected state version 2 for c1, got %+v", started)
+	}
+
+	if err := websocket.JSON.Send(conn, map[string]interface{}{"id": "c2", "type": "pause", "baseVersion": 1}); err != nil {
+		t.Fatalf("send stale pause command: %v", err)
+	}
+	var conflict socketReply
+	if err := websocket.JSON.Receive(conn, &conflict); err != nil {
+		t.Fatalf("receive conflict reply: %v", err)
+	}
+	if conflict.ID != "c2" || conflict.Type != "error" || conflict.Error.Code != "state_conflict" {
+		t.Fatalf("expected state_conflict for c2, got %+v", conflict)
+	}
+	if conflict.Error.Details.State.Version != 2 {
+		t.Fatalf("expected conflict details at version 2, got %d", conflict.Error.Details.State.Version)
+	}
+}
+
+func TestStreamsCloseAfterLogout(t *testing.T) {
+	engine := setupTestEngine(t)
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+
+	user := registerUser(t, engine, "revoked-stream@example.com", "123456")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/pomodoro/events", nil)
+	if err != nil {
+		t.Fatalf("build events request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+user.Token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("open events stream: %v", err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	readStateEvent(t, reader)
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/pomodoro/ws"
+	config, err := websocket.NewConfig(wsURL, "http://localhost:5173")
+	if err != nil {
+		t.Fatalf("build websocket config: %v", err)
+	}
+	config.Header.Set("Authorization", "Bearer "+user.Token)
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		t.Fatalf("dial websocket: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	var initial socketReply
+	if err := websocket.JSON.Receive(conn, &initial); err != nil {
+		t.Fatalf("receive initial state: %v", err)
+	}
+
+	status, _ := requestJSON(t, engine, http.MethodPost, "/api/auth/logout", user.Token, nil)
+	if status != http.StatusNoContent {
+		t.Fatalf("expected 204 on logout, got %d", status)
+	}
+
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		t.Fatalf("expected the events stream to end after logout, got %v", err)
+	}
+	var next socketReply
+	if err := websocket.JSON.Receive(conn, &next); err != io.EOF {
+		t.Fatalf("expected the socket to close after logout, got %v (%+v)", err, next)
+	}
+}
+
+func TestRefreshRotationAndLogout(t *testing.T) {
+	engine := setupTestEngine(t)
+	user := registerUser(t, engine, "refresh@example.com", "123456")
+
+	status, body := requestJSON(t, engine, http.MethodPost, "/api/auth/refresh", "", map[string]string{
+		"refreshToken": user.RefreshToken,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on refresh, got %d: %s", status, string(body))
+	}
+	var rotated authResponse
+	if err := json.Unmarshal(body, &rotated); err != nil {
+		t.Fatalf("unmarshal refresh response: %v", err)
+	}
+	if rotated.Token == "" || rotated.RefreshToken == "" || rotated.RefreshToken == user.RefreshToken {
+		t.Fatal("expected a fresh token pair after refresh")
+	}
+
+	getState(t, engine, rotated.Token)
+	status, _ = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/state", user.Token, nil)
+	if status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for rotated access token, got %d", status)
+	}
+
+	// Replaying the rotated refresh token revokes the whole family.
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/auth/refresh", "", map[string]string{
+		"refreshToken": user.RefreshToken,
+	})
+	if status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 on refresh token reuse, got %d", status)
+	}
+	status, _ = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/state", rotated.Token, nil)
+	if status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 after family revocation, got %d", status)
+	}
+
+	session := loginUser(t, engine, "refresh@example.com", "123456")
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/auth/logout", session.Token, nil)
+	if status != http.StatusNoContent {
+		t.Fatalf("expected 204 on logout, got %d", status)
+	}
+	status, _ = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/state", session.Token, nil)
+	if status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 after logout, got %d", status)
+	}
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/auth/refresh", "", map[string]string{
+		"refreshToken": session.RefreshToken,
+	})
+	if status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 refreshing a logged out session, got %d", status)
+	}
+}
+
+func TestAuthRateLimitAndLockout(t *testing.T) {
+	engine := setupTestEngine(t)
+	registerUser(t, engine, "locked@example.com", "123456")
+
+	// The test engine locks an email after 3 failures for one minute.
+	for i := 0; i < 3; i++ {
+		status, body := requestJSON(t, engine, http.MethodPost, "/api/auth/login", "", map[string]string{
+			"email":    "locked@example.com",
+			"password": "wrong-password",
+		})
+		if status != http.StatusUnauthorized {
+			t.Fatalf("expected 401 on failed login %d, got %d: %s", i+1, status, string(body))
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"email":"Locked@example.com","password":"123456"}`))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	var apiErr apiErrorEnvelope
+	if err := json.Unmarshal(recorder.Body.Bytes(), &apiErr); err != nil {
+		t.Fatalf("unmarshal lockout response: %v", err)
+	}
+	if recorder.Code != http.StatusTooManyRequests || apiErr.Error.Code != "account_locked" {
+		t.Fatalf("expected 429 account_locked with the right password, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if retryAfter, _ := strconv.Atoi(recorder.Header().Get("Retry-After")); retryAfter < 55 || retryAfter > 60 {
+		t.Fatalf("expected Retry-After of about 60s, got %q", recorder.Header().Get("Retry-After"))
+	}
+
+	// Five requests for the email have used up its bucket.
+	status, body := requestJSON(t, engine, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    "locked@example.com",
+		"password": "123456",
+	})
+	if err := json.Unmarshal(body, &apiErr); err != nil {
+		t.Fatalf("unmarshal rate limit response: %v", err)
+	}
+	if status != http.StatusTooManyRequests || apiErr.Error.Code != "rate_limited" {
+		t.Fatalf("expected 429 rate_limited for the email, got %d: %s", status, string(body))
+	}
+
+	// The client IP has six of its twelve requests left.
+	for i := 0; i < 6; i++ {
+		status, _ = requestJSON(t, engine, http.MethodPost, "/api/auth/login", "", map[string]string{
+			"email":    fmt.Sprintf("guess-%d@example.com", i),
+			"password": "123456",
+		})
+		if status != http.StatusUnauthorized {
+			t.Fatalf("expected 401 for an unknown email, got %d", status)
+		}
+	}
+	status, body = requestJSON(t, engine, http.MethodPost, "/api/auth/register", "", map[string]string{
+		"email":    "fresh@example.com",
+		"password": "123456",
+	})
+	if status != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 once the IP is out of tokens, got %d: %s", status, string(body))
+	}
+}
+
+func TestForgotPasswordHidesMailFailures(t *testing.T) {
+	engine, outbox := setupTestEngineWithOutbox(t)
+	registerUser(t, engine, "unlucky@example.com", "123456")
+
+	// A file in place of the outbox directory makes every send fail.
+	if err := os.RemoveAll(outbox); err != nil {
+		t.Fatalf("remove outbox: %v", err)
+	}
+	if err := os.WriteFile(outbox, nil, 0o600); err != nil {
+		t.Fatalf("block outbox: %v", err)
+	}
+
+	knownStatus, knownBody := requestJSON(t, engine, http.MethodPost, "/api/auth/password/forgot", "", map[string]string{"email": "unlucky@example.com"})
+	unknownStatus, unknownBody := requestJSON(t, engine, http.MethodPost, "/api/auth/password/forgot", "", map[string]string{"email": "nobody@example.com"})
+	if knownStatus != http.StatusAccepted || unknownStatus != http.StatusAccepted {
+		t.Fatalf("expected 202 for both emails, got %d and %d", knownStatus, unknownStatus)
+	}
+	if !bytes.Equal(knownBody, unknownBody) {
+		t.Fatalf("expected identical responses, got %s and %s", string(knownBody), string(unknownBody))
+	}
+}
+
+func TestPasswordResetAndEmailVerification(t *testing.T) {
+	engine, outbox := setupTestEngineWithOutbox(t)
+	user := registerUser(t, engine, "recover@example.com", "123456")
+
+	verifyToken := lastMailedToken(t, outbox, "/verify-email")
+	status, body := requestJSON(t, engine, http.MethodPost, "/api/auth/email/verify", "", map[string]string{"token": verifyToken})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on email verification, got %d: %s", status, string(body))
+	}
+	var verified struct {
+		User struct {
+			EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
+		} `json:"user"`
+	}
+	if err := json.Unmarshal(body, &verified); err != nil {
+		t.Fatalf("unmarshal verification response: %v", err)
+	}
+	if verified.User.EmailVerifiedAt == nil {
+		t.Fatalf("expected emailVerifiedAt to be set, got %s", string(body))
+	}
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/auth/email/verify", "", map[string]string{"token": verifyToken})
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 when reusing a verification token, got %d", status)
+	}
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/auth/email/verification", user.Token, nil)
+	if status != http.StatusConflict {
+		t.Fatalf("expected 409 when resending for a verified email, got %d", status)
+	}
+
+	// Unknown emails are accepted without sending anything.
+	mailed := len(outboxMessages(t, outbox))
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/auth/password/forgot", "", map[string]string{"email": "nobody@example.com"})
+	if status != http.StatusAccepted || len(outboxMessages(t, outbox)) != mailed {
+		t.Fatalf("expected 202 and no mail for an unknown email, got %d", status)
+	}
+
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/auth/password/forgot", "", map[string]string{"email": "Recover@example.com"})
+	if status != http.StatusAccepted {
+		t.Fatalf("expected 202 on forgot password, got %d", status)
+	}
+	resetToken := lastMailedToken(t, outbox, "/reset-password")
+
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/auth/password/reset", "", map[string]string{
+		"token":    resetToken,
+		"password": "654321",
+	})
+	if status != http.StatusNoContent {
+		t.Fatalf("expected 204 on password reset, got %d", status)
+	}
+
+	// Existing sessions are signed out and the old password stops working.
+	status, _ = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/state", user.Token, nil)
+	if status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a session from before the reset, got %d", status)
+	}
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    "recover@example.com",
+		"password": "123456",
+	})
+	if status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with the old password, got %d", status)
+	}
+	loginUser(t, engine, "recover@example.com", "654321")
+
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/auth/password/reset", "", map[string]string{
+		"token":    resetToken,
+		"password": "abcdef",
+	})
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 when reusing a reset token, got %d", status)
+	}
+}
+
+func TestAccountManagement(t *testing.T) {
+	engine := setupTestEngine(t)
+	user := registerUser(t, engine, "owner@example.com", "123456")
+	otherSession := loginUser(t, engine, "owner@example.com", "123456")
+	registerUser(t, engine, "taken@example.com", "123456")
+
+	status, body := requestJSON(t, engine, http.MethodPut, "/api/account/password", user.Token, map[string]string{
+		"currentPassword": "wrong-password",
+		"newPassword":     "654321",
+	})
+	if status != http.StatusForbidden {
+		t.Fatalf("expected 403 with a wrong current password, got %d: %s", status, string(body))
+	}
+	status, body = requestJSON(t, engine, http.MethodPut, "/api/account/password", user.Token, map[string]string{
+		"currentPassword": "123456",
+		"newPassword":     "654321",
+	})
+	if status != http.StatusNoContent {
+		t.Fatalf("expected 204 on password change, got %d: %s", status, string(body))
+	}
+
+	// The session that changed the password stays signed in, others do not.
+	if status, _ = requestJSON(t, engine, http.MethodGet, "/api/account", user.Token, nil); status != http.StatusOK {
+		t.Fatalf("expected the current session to stay valid, got %d", status)
+	}
+	if status, _ = requestJSON(t, engine, http.MethodGet, "/api/account", otherSession.Token, nil); status != http.StatusUnauthorized {
+		t.Fatalf("expected other sessions to be signed out, got %d", status)
+	}
+	loginUser(t, engine, "owner@example.com", "654321")
+
+	status, body = requestJSON(t, engine, http.MethodPut, "/api/account/email", user.Token, map[string]string{
+		"email":           "Taken@example.com",
+		"currentPassword": "654321",
+	})
+	if status != http.StatusConflict {
+		t.Fatalf("expected 409 for an email in use, got %d: %s", status, string(body))
+	}
+	status, body = requestJSON(t, engine, http.MethodPut, "/api/account/email", user.Token, map[string]string{
+		"email":           "New-Owner@example.com",
+		"currentPassword": "654321",
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on email change, got %d: %s", status, string(body))
+	}
+	var changed struct {
+		User struct {
+			Email           string     `json:"email"`
+			EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
+		} `json:"user"`
+	}
+	if err := json.Unmarshal(body, &changed); err != nil {
+		t.Fatalf("unmarshal email change response: %v", err)
+	}
+	if changed.User.Email != "new-owner@example.com" || changed.User.EmailVerifiedAt != nil {
+		t.Fatalf("expected an unverified new email, got %s", string(body))
+	}
+	loginUser(t, engine, "new-owner@example.com", "654321")
+
+	status, _ = requestJSON(t, engine, http.MethodDelete, "/api/account", user.Token, map[string]string{"currentPassword": "123456"})
+	if status != http.StatusForbidden {
+		t.Fatalf("expected 403 when deleting with a wrong password, got %d", status)
+	}
+	status, body = requestJSON(t, engine, http.MethodDelete, "/api/account", user.Token, map[string]string{"currentPassword": "654321"})
+	if status != http.StatusNoContent {
+		t.Fatalf("expected 204 on account deletion, got %d: %s", status, string(body))
+	}
+	if status, _ = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/state", user.Token, nil); status != http.StatusUnauthorized {
+		t.Fatalf("expected tokens of a deleted account to be rejected, got %d", status)
+	}
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    "new-owner@example.com",
+		"password": "654321",
+	})
+	if status != http.StatusUnauthorized {
+		t.Fatalf("expected login to fail after deletion, got %d", status)
+	}
+}
+
+func TestPomodoroEventLog(t *testing.T) {
+	engine := setupTestEngine(t)
+	user := registerUser(t, engine, "eventlog@example.com", "123456")
+
+	for i, action := range []string{"start", "pause", "reset"} {
+		status, body := requestJSON(t, engine, http.MethodPost, "/api/pomodoro/"+action, user.Token, map[string]int{
+			"baseVersion": i + 1,
+		})
+		if status != http.StatusOK {
+			t.Fatalf("expected 200 on %s, got %d: %s", action, status, string(body))
+		}
+	}
+
+	status, body := requestJSON(t, engine, http.MethodGet, "/api/pomodoro/events/log?limit=2", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 for event log, got %d", status)
+	}
+	var firstPage eventLogEnvelope
+	if err := json.Unmarshal(body, &firstPage); err != nil {
+		t.Fatalf("unmarshal event log: %v", err)
+	}
+	if len(firstPage.Events) != 2 || firstPage.NextCursor == nil {
+		t.Fatalf("expected a full first page with a cursor, got %s", string(body))
+	}
+	reset, pause := firstPage.Events[0], firstPage.Events[1]
+	if reset.Type != "reset" || reset.FromStatus != "paused" || reset.ToStatus != "idle" || reset.Version != 4 {
+		t.Fatalf("unexpected reset event: %+v", reset)
+	}
+	if pause.Type != "pause" || pause.FromStatus != "running" || pause.ToStatus != "paused" || pause.Version != 3 {
+		t.Fatalf("unexpected pause event: %+v", pause)
+	}
+	if reset.DeviceID == nil || *reset.DeviceID != user.Device.ID {
+		t.Fatalf("expected reset attributed to device %s, got %v", user.Device.ID, reset.DeviceID)
+	}
+
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/events/log?limit=2&cursor="+*firstPage.NextCursor, user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 for second event page, got %d", status)
+	}
+	var secondPage eventLogEnvelope
+	if err := json.Unmarshal(body, &secondPage); err != nil {
+		t.Fatalf("unmarshal second event page: %v", err)
+	}
+	if len(secondPage.Events) != 1 || secondPage.NextCursor != nil || secondPage.Events[0].Type != "start" {
+		t.Fatalf("expected only the start event on the last page, got %s", string(body))
+	}
+	if secondPage.Events[0].SessionID == nil || reset.SessionID == nil || *secondPage.Events[0].SessionID != *reset.SessionID {
+		t.Fatal("expected start and reset to reference the same session")
+	}
+
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/events/log?sessionId="+*reset.SessionID, user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 filtering by session, got %d", status)
+	}
+	var sessionEvents eventLogEnvelope
+	if err := json.Unmarshal(body, &sessionEvents); err != nil {
+		t.Fatalf("unmarshal session events: %v", err)
+	}
+	if len(sessionEvents.Events) != 3 {
+		t.Fatalf("expected 3 events for the session, got %d", len(sessionEvents.Events))
+	}
+
+	status, _ = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/events/log?cursor=abc", user.Token, nil)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for malformed cursor, got %d", status)
+	}
+}
+
+func TestDeviceRegistry(t *testing.T) {
+	engine := setupTestEngine(t)
+
+	status, body := requestJSON(t, engine, http.MethodPost, "/api/auth/register", "", map[string]interface{}{
+		"email":    "devices@example.com",
+		"password": "123456",
+		"device":   map[string]string{"name": "Laptop", "platform": "web"},
+	})
+	if status != http.StatusCreated {
+		t.Fatalf("register failed with status %d: %s", status, string(body))
+	}
+	var laptop authResponse
+	if err := json.Unmarshal(body, &laptop); err != nil {
+		t.Fatalf("unmarshal register response: %v", err)
+	}
+	if laptop.Device.ID == "" || laptop.Device.Name != "Laptop" {
+		t.Fatalf("expected a registered laptop device, got %+v", laptop.Device)
+	}
+
+	status, body = requestJSON(t, engine, http.MethodPost, "/api/auth/login", "", map[string]interface{}{
+		"email":    "devices@example.com",
+		"password": "123456",
+		"device":   map[string]string{"name": "Phone", "platform": "ios"},
+	})
+	if status != http.StatusOK {
+		t.Fatalf("login failed with status %d: %s", status, string(body))
+	}
+	var phone authResponse
+	if err := json.Unmarshal(body, &phone); err != nil {
+		t.Fatalf("unmarshal login response: %v", err)
+	}
+
+	initial := getState(t, engine, phone.Token)
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/start", laptop.Token, map[string]int{
+		"baseVersion": initial.State.Version,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on start, got %d", status)
+	}
+
+	status, body = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/pause", phone.Token, map[string]int{
+		"baseVersion": initial.State.Version,
+	})
+	if status != http.StatusConflict {
+		t.Fatalf("expected 409 for stale version, got %d", status)
+	}
+	var conflict apiErrorEnvelope
+	if err := json.Unmarshal(body, &conflict); err != nil {
+		t.Fatalf("unmarshal conflict response: %v", err)
+	}
+	if conflict.Error.Details.Device.ID != laptop.Device.ID || conflict.Error.Details.Device.Name != "Laptop" {
+		t.Fatalf("expected conflict attributed to the laptop, got %+v", conflict.Error.Details.Device)
+	}
+
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/devices", phone.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 listing devices, got %d", status)
+	}
+	var devices devicesEnvelope
+	if err := json.Unmarshal(body, &devices); err != nil {
+		t.Fatalf("unmarshal devices response: %v", err)
+	}
+	if len(devices.Devices) != 2 || devices.CurrentDeviceID != phone.Device.ID {
+		t.Fatalf("expected two devices with the phone current, got %s", string(body))
+	}
+
+	status, _ = requestJSON(t, engine, http.MethodDelete, "/api/devices/"+laptop.Device.ID, phone.Token, nil)
+	if status != http.StatusNoContent {
+		t.Fatalf("expected 204 revoking device, got %d", status)
+	}
+	status, _ = requestJSON(t, engine, http.MethodGet, "/api/pomodoro/state", laptop.Token, nil)
+	if status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for revoked device, got %d", status)
+	}
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/auth/refresh", "", map[string]string{
+		"refreshToken": laptop.RefreshToken,
+	})
+	if status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 refreshing a revoked device, got %d", status)
+	}
+	getState(t, engine, phone.Token)
+
+	status, _ = requestJSON(t, engine, http.MethodDelete, "/api/devices/"+laptop.Device.ID, phone.Token, nil)
+	if status != http.StatusNotFound {
+		t.Fatalf("expected 404 revoking a revoked device, got %d", status)
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	engine := setupTestEngine(t)
+	user := registerUser(t, engine, "webhooks@example.com", "123456")
+
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	deliveries := make(chan received, 16)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- received{header: r.Header.Clone(), body: body}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(receiver.Close)
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(failing.Close)
+
+	for _, invalid := range []map[string]interface{}{
+		{"url": "ftp://example.com/hook", "events": []string{"session.started"}},
+		{"url": "/relative", "events": []string{"session.started"}},
+		{"url": receiver.URL, "events": []string{}},
+		{"url": receiver.URL, "events": []string{"session.exploded"}},
+	} {
+		status, body := requestJSON(t, engine, http.MethodPost, "/api/webhooks", user.Token, invalid)
+		if status != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %v, got %d: %s", invalid, status, string(body))
+		}
+	}
+
+	status, body := requestJSON(t, engine, http.MethodPost, "/api/webhooks", user.Token, map[string]interface{}{
+		"url":    receiver.URL,
+		"events": []string{"session.cancelled", "session.started", "session.paused", "session.started"},
+	})
+	if status != http.StatusCreated {
+		t.Fatalf("expected 201 on create webhook, got %d: %s", status, string(body))
+	}
+	var created webhookEnvelope
+	if err := json.Unmarshal(body, &created); err != nil {
+		t.Fatalf("unmarshal webhook: %v", err)
+	}
+	webhook := created.Webhook
+	if webhook.Secret == "" || !webhook.Active ||
+		strings.Join(webhook.Events, ",") != "session.started,session.paused,session.cancelled" {
+		t.Fatalf("unexpected webhook: %s", string(body))
+	}
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/webhooks/"+webhook.ID, user.Token, nil)
+	if status != http.StatusOK || strings.Contains(string(body), webhook.Secret) {
+		t.Fatalf("expected the secret to be hidden after creation, got %d: %s", status, string(body))
+	}
+
+	expectDelivery := func(secret, eventType string) {
+		t.Helper()
+		var delivery received
+		select {
+		case delivery = <-deliveries:
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timed out waiting for %s", eventType)
+		}
+		if got := delivery.header.Get(service.WebhookEventHeader); got != eventType {
+			t.Fatalf("expected %s, got %s", eventType, got)
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(delivery.header.Get(service.WebhookTimestampHeader) + "."))
+		mac.Write(delivery.body)
+		if delivery.header.Get(service.WebhookSignatureHeader) != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+			t.Fatalf("signature mismatch for %s", eventType)
+		}
+		var payload service.WebhookPayload
+		if err := json.Unmarshal(delivery.body, &payload); err != nil {
+			t.Fatalf("unmarshal payload: %v", err)
+		}
+		if payload.Type != eventType || payload.ID == "" || payload.Session.UserID != user.User.ID {
+			t.Fatalf("unexpected payload: %s", string(delivery.body))
+		}
+	}
+
+	// Resuming is not subscribed to, so only three events are sent.
+	for i, action := range []string{"start", "pause", "start", "reset"} {
+		status, _ := requestJSON(t, engine, http.MethodPost, "/api/pomodoro/"+action, user.Token, map[string]int{"baseVersion": i + 1})
+		if status != http.StatusOK {
+			t.Fatalf("expected 200 on %s, got %d", action, status)
+		}
+	}
+	expectDelivery(webhook.Secret, "session.started")
+	expectDelivery(webhook.Secret, "session.paused")
+	expectDelivery(webhook.Secret, "session.cancelled")
+	select {
+	case extra := <-deliveries:
+		t.Fatalf("unexpected delivery: %s", string(extra.body))
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	waitForDeliveries := func(webhookID string, done func(deliveryLogEnvelope) bool) deliveryLogEnvelope {
+		t.Helper()
+		deadline := time.Now().Add(3 * time.Second)
+		for {
+			status, body := requestJSON(t, engine, http.MethodGet, "/api/webhooks/"+webhookID+"/deliveries", user.Token, nil)
+			if status != http.StatusOK {
+				t.Fatalf("expected 200 for deliveries, got %d: %s", status, string(body))
+			}
+			var log deliveryLogEnvelope
+			if err := json.Unmarshal(body, &log); err != nil {
+				t.Fatalf("unmarshal deliveries: %v", err)
+			}
+			if done(log) {
+				return log
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("deliveries did not settle: %s", string(body))
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	log := waitForDeliveries(webhook.ID, func(log deliveryLogEnvelope) bool {
+		for _, delivery := range log.Deliveries {
+			if delivery.Status != "delivered" {
+				return false
+			}
+		}
+		return len(log.Deliveries) == 3
+	})
+	if log.Deliveries[0].EventType != "session.cancelled" || log.Deliveries[0].Attempts != 1 ||
+		log.Deliveries[0].ResponseStatus == nil || *log.Deliveries[0].ResponseStatus != http.StatusOK {
+		t.Fatalf("unexpected newest delivery: %+v", log.Deliveries[0])
+	}
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/webhooks/"+webhook.ID+"/deliveries?limit=2", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 for first page, got %d", status)
+	}
+	if err := json.Unmarshal(body, &log); err != nil {
+		t.Fatalf("unmarshal deliveries: %v", err)
+	}
+	if len(log.Deliveries) != 2 || log.NextCursor == nil {
+		t.Fatalf("expected a first page of two with a cursor, got %s", string(body))
+	}
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/webhooks/"+webhook.ID+"/deliveries?limit=2&cursor="+*log.NextCursor, user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 for second page, got %d", status)
+	}
+	if err := json.Unmarshal(body, &log); err != nil {
+		t.Fatalf("unmarshal deliveries: %v", err)
+	}
+	if len(log.Deliveries) != 1 || log.Deliveries[0].EventType != "session.started" || log.NextCursor != nil {
+		t.Fatalf("expected the oldest delivery on the last page, got %s", string(body))
+	}
+
+	status, body = requestJSON(t, engine, http.MethodPost, "/api/webhooks", user.Token, map[string]interface{}{
+		"url":    failing.URL,
+		"events": []string{"session.started"},
+	})
+	if status != http.StatusCreated {
+		t.Fatalf("expected 201 on create failing webhook, got %d: %s", status, string(body))
+	}
+	var failingWebhook webhookEnvelope
+	if err := json.Unmarshal(body, &failingWebhook); err != nil {
+		t.Fatalf("unmarshal webhook: %v", err)
+	}
+
+	status, body = requestJSON(t, engine, http.MethodPost, "/api/webhooks/"+webhook.ID+"/secret", user.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on rotate secret, got %d: %s", status, string(body))
+	}
+	var rotated webhookEnvelope
+	if err := json.Unmarshal(body, &rotated); err != nil {
+		t.Fatalf("unmarshal webhook: %v", err)
+	}
+	if rotated.Webhook.Secret == "" || rotated.Webhook.Secret == webhook.Secret {
+		t.Fatalf("expected a new secret, got %s", string(body))
+	}
+
+	status, _ = requestJSON(t, engine, http.MethodPost, "/api/pomodoro/start", user.Token, map[string]int{"baseVersion": 5})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 on start, got %d", status)
+	}
+	expectDelivery(rotated.Webhook.Secret, "session.started")
+
+	log = waitForDeliveries(failingWebhook.Webhook.ID, func(log deliveryLogEnvelope) bool {
+		return len(log.Deliveries) == 1 && log.Deliveries[0].Status == "failed"
+	})
+	failed := log.Deliveries[0]
+	if failed.Attempts != 3 || failed.ResponseStatus == nil || *failed.ResponseStatus != http.StatusInternalServerError ||
+		failed.LastError == nil {
+		t.Fatalf("expected the delivery to fail after three attempts, got %+v", failed)
+	}
+	status, body = requestJSON(t, engine, http.MethodGet, "/api/webhooks/"+failingWebhook.Webhook.ID+"/deliveries?status=bogus", user.Token, nil)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown status, got %d: %s", status, string(body))
+	}
+
+	other := registerUser(t, engine, "webhooks-other@example.com", "123456")
+	if status, _ = requestJSON(t, engine, http.MethodGet, "/api/webhooks/"+webhook.ID, other.Token, nil); status != http.StatusNotFound {
+		t.Fatalf("expected 404 for another user's webhook, got %d", status)
+	}
+
+	if status, _ = requestJSON(t, engine, http.MethodDelete, "/api/webhooks/"+webhook.ID, user.Token, nil); status != http.StatusNoContent {
+		t.Fatalf("expected 204 on delete webhook, got %d", status)
+	}
+	if status, _ = requestJSON(t, engine, http.MethodGet, "/api/webhooks/"+webhook.ID, user.Token, nil); status != http.StatusNotFound {
+		t.Fatalf("expected 404 after delete, got %d", status)
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	engine := setupTestEngine(t)
+	req := httptest.NewRequest(http.MethodOptions, "/api/auth/login", nil)
+	req.Header.Set("Origin", "http://localhost:5173")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	recorder := httptest.NewRecorder()
+
+	engine.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 for preflight, got %d", recorder.Code)
+	}
+	if recorder.Header().Get("Access-Control-Allow-Origin") != "http://localhost:5173" {
+		t.Fatalf("unexpected allow-origin header: %s", recorder.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
+func setupTestEngine(t *testing.T) http.Handler {
+	t.Helper()
+	engine, _, _ := newTestEngine(t)
+	return engine
+}
+
+// setupTestEngineWithOutbox also returns the directory the test mailer
+// writes its messages to.
+func setupTestEngineWithOutbox(t *testing.T) (http.Handler, string) {
+	t.Helper()
+	engine, outboxDir, _ := newTestEngine(t)
+	return engine, outboxDir
+}
+
+// setupTestEngineWithDB also returns the database, for states no request
+// can produce, such as a timer that ran out while the server was down.
+func setupTestEngineWithDB(t *testing.T) (http.Handler, *sql.DB) {
+	t.Helper()
+	engine, _, database := newTestEngine(t)
+	return engine, database
+}
+
+func newTestEngine(t *testing.T) (http.Handler, string, *sql.DB) {
+	t.Helper()
+
+	database, err := db.OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = database.Close()
+	})
+
+	source, err := migrations.Source(db.DriverSQLite, "")
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if err := db.RunMigrations(database, db.SQLiteDialect{}, source); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+
+	userRepo := repository.NewUserRepository(database, db.SQLiteDialect{})
+	pomodoroRepo := repository.NewPomodoroRepository(database, db.SQLiteDialect{})
+	taskRepo := repository.NewTaskRepository(database, db.SQLiteDialect{})
+	presetRepo := repository.NewPresetRepository(database, db.SQLiteDialect{})
+	roomRepo := repository.NewRoomRepository(database, db.SQLiteDialect{})
+	goalRepo := repository.NewGoalRepository(database, db.SQLiteDialect{})
+	modeRepo := repository.NewModeRepository(database, db.SQLiteDialect{})
+	webhookRepo := repository.NewWebhookRepository(database, db.SQLiteDialect{})
+	refreshTokenRepo := repository.NewRefreshTokenRepository(database, db.SQLiteDialect{})
+	deviceRepo := repository.NewDeviceRepository(database, db.SQLiteDialect{})
+	idempotencyRepo := repository.NewIdempotencyRepository(database, db.SQLiteDialect{})
+	loginAttemptRepo := repository.NewLoginAttemptRepository(database, db.SQLiteDialect{})
+	userTokenRepo := repository.NewUserTokenRepository(database, db.SQLiteDialect{})
+
+	outboxDir := t.TempDir()
+	outbox, err := mailer.NewOutboxMailer(outboxDir, "Pomodoro <no-reply@example.com>")
+	if err != nil {
+		t.Fatalf("create outbox: %v", err)
+	}
+	authService := service.NewAuthService(
+		userRepo,
+		pomodoroRepo,
+		refreshTokenRepo,
+		deviceRepo,
+		loginAttemptRepo,
+		service.LockoutPolicy{Threshold: 3, Base: time.Minute, Max: time.Hour},
+		"test-secret",
+		time.Hour,
+		24*time.Hour,
+	)
+	pomodoroService := service.NewPomodoroService(
+		pomodoroRepo,
+		taskRepo,
+		deviceRepo,
+		goalRepo,
+		modeRepo,
+		webhookRepo,
+		service.NewStateHub(),
+		service.NewSessionFinalizer(),
+	)
+	roomService := service.NewRoomService(roomRepo, pomodoroService)
+	finalizerCtx, stopFinalizer := context.WithCancel(context.Background())
+	finalizerDone := make(chan struct{})
+	go func() {
+		defer close(finalizerDone)
+		pomodoroService.RunFinalizer(finalizerCtx, time.Minute, roomService)
+	}()
+	webhookService := service.NewWebhookService(webhookRepo, service.WebhookDeliverySettings{
+		PollInterval: 20 * time.Millisecond,
+		Timeout:      2 * time.Second,
+		MaxAttempts:  3,
+		RetryBase:    50 * time.Millisecond,
+		RetryMax:     200 * time.Millisecond,
+		// The receivers in these tests listen on loopback.
+		AllowPrivateNetworks: true,
+	})
+	deliveriesCtx, stopDeliveries := context.WithCancel(context.Background())
+	deliveriesDone := make(chan struct{})
+	go func() {
+		defer close(deliveriesDone)
+		webhookService.RunDeliveries(deliveriesCtx)
+	}()
+	// Registered after closing the database, so it runs first.
+	t.Cleanup(func() {
+		stopFinalizer()
+		stopDeliveries()
+		<-finalizerDone
+		<-deliveriesDone
+	})
+	taskService := service.NewTaskService(taskRepo, pomodoroService)
+	presetService := service.NewPresetService(presetRepo, pomodoroService)
+	modeService := service.NewModeService(modeRepo, pomodoroService)
+	deviceService := service.NewDeviceService(deviceRepo, refreshTokenRepo)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, time.Hour)
+	accountService := service.NewAccountService(
+		userRepo,
+		userTokenRepo,
+		refreshTokenRepo,
+		loginAttemptRepo,
+		outbox,
+		service.AccountMailSettings{
+			AppBaseURL:           "http://localhost:5173",
+			PasswordResetTTL:     time.Hour,
+			EmailVerificationTTL: time.Hour,
+		},
+	)
+
+	authHandler := handler.NewAuthHandler(authService, accountService)
+	pomodoroHandler := handler.NewPomodoroHandler(pomodoroService)
+	taskHandler := handler.NewTaskHandler(taskService)
+	presetHandler := handler.NewPresetHandler(presetService)
+	modeHandler := handler.NewModeHandler(modeService)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	roomHandler := handler.NewRoomHandler(roomService)
+	deviceHandler := handler.NewDeviceHandler(deviceService)
+	accountHandler := handler.NewAccountHandler(accountService)
+
+	engine := router.New(
+		authService,
+		authHandler,
+		pomodoroHandler,
+		taskHandler,
+		presetHandler,
+		modeHandler,
+		webhookHandler,
+		roomHandler,
+		deviceHandler,
+		accountHandler,
+		idempotencyService,
+		service.NewRateLimiter(6, 12),
+		service.NewRateLimiter(6, 5),
+		[]string{"http://localhost:5173"},
+		50*time.Millisecond,
+	)
+	return engine, outboxDir, database
+}
+
+func registerUser(t *testing.T, server http.Handler, email, password string) authResponse {
+	t.Helper()
+	status, body := requestJSON(t, server, http.MethodPost, "/api/auth/register", "", map[string]string{
+		"email":    email,
+		"password": password,
+	})
+	if status != http.StatusCreated {
+		t.Fatalf("register %s failed with status %d: %s", email, status, string(body))
+	}
+	var resp authResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("unmarshal register response: %v", err)
+	}
+	if resp.Token == "" {
+		t.Fatalf("empty token for user %s", email)
+	}
+	return resp
+}
+
+func loginUser(t *testing.T, server http.Handler, email, password string) authResponse {
+	t.Helper()
+	status, body := requestJSON(t, server, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    email,
+		"password": password,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("login %s failed with status %d: %s", email, status, string(body))
+	}
+	var resp authResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("unmarshal login response: %v", err)
+	}
+	return resp
+}
+
+func getState(t *testing.T, server http.Handler, token string) stateEnvelope {
+	t.Helper()
+	status, body := requestJSON(t, server, http.MethodGet, "/api/pomodoro/state", token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("get state failed with status %d: %s", status, string(body))
+	}
+	var stateResp stateEnvelope
+	if err := json.Unmarshal(body, &stateResp); err != nil {
+		t.Fatalf("unmarshal state response: %v", err)
+	}
+	return stateResp
+}
+
+// readStateEvent reads SSE lines until a complete state event arrives and
+// wraps its payload so it can be compared like a REST state response.
+func readStateEvent(t *testing.T, reader *bufio.Reader) (string, stateEnvelope) {
+	t.Helper()
+
+	var id string
+	var data string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event stream: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "" && data != "":
+			var envelope stateEnvelope
+			if err := json.Unmarshal([]byte(data), &envelope.State); err != nil {
+				t.Fatalf("unmarshal state event: %v", err)
+			}
+			return id, envelope
+		}
+	}
+}
+
+func idempotentRequest(
+	t *testing.T,
+	server http.Handler,
+	path, token, key string,
+	body interface{},
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("marshal request body: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Idempotency-Key", key)
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func outboxMessages(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read outbox: %v", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+// lastMailedToken extracts the token from the link to path in the most
+// recent outbox message.
+func lastMailedToken(t *testing.T, dir, path string) string {
+	t.Helper()
+	names := outboxMessages(t, dir)
+	if len(names) == 0 {
+		t.Fatalf("expected a message in the outbox")
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, names[len(names)-1]))
+	if err != nil {
+		t.Fatalf("read outbox message: %v", err)
+	}
+	match := regexp.MustCompile(regexp.QuoteMeta(path) + `\?token=([A-Za-z0-9_-]+)`).FindSubmatch(raw)
+	if match == nil {
+		t.Fatalf("expected a %s link in the last message:\n%s", path, raw)
+	}
+	return string(match[1])
 }
 
 func requestJSON(
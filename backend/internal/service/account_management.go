@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/mailer"
+	"pomodoro/backend/internal/model"
+	"pomodoro/backend/internal/repository"
+)
+
+func (s *AccountService) Get(ctx context.Context, userID string) (*model.User, *apperrors.APIError) {
+	user, apiErr := s.loadUser(ctx, userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	user.PasswordHash = ""
+	return user, nil
+}
+
+// ChangePassword keeps the session identified by currentTokenID signed in
+// and signs out every other one.
+func (s *AccountService) ChangePassword(
+	ctx context.Context,
+	userID string,
+	currentTokenID string,
+	currentPassword string,
+	newPassword string,
+) *apperrors.APIError {
+	if apiErr := validatePassword(newPassword); apiErr != nil {
+		return apiErr
+	}
+	user, apiErr := s.loadUser(ctx, userID)
+	if apiErr != nil {
+		return apiErr
+	}
+	if apiErr := checkCurrentPassword(user, currentPassword); apiErr != nil {
+		return apiErr
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return apperrors.Internal("failed to secure password")
+	}
+
+	now := time.Now().UTC()
+	tx, err := s.userTokenRepo.BeginTx(ctx)
+	if err != nil {
+		return apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	if err := s.userRepo.UpdatePasswordTx(ctx, tx, user.ID, string(passwordHash), now); err != nil {
+		return apperrors.Internal("failed to update password")
+	}
+	if err := s.userTokenRepo.InvalidateTx(ctx, tx, user.ID, model.TokenPurposePasswordReset, now); err != nil {
+		return apperrors.Internal("failed to invalidate reset tokens")
+	}
+	if err := s.tokenRepo.RevokeOthersForUserTx(ctx, tx, user.ID, currentTokenID, now); err != nil {
+		return apperrors.Internal("failed to revoke sessions")
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		return apperrors.Internal("failed to commit transaction")
+	}
+	return nil
+}
+
+// ChangeEmail moves the account to a new, unverified address. Links mailed
+// to the old address stop working, a verification link goes to the new one
+// and the old one is told about the change.
+func (s *AccountService) ChangeEmail(ctx context.Context, userID, email, currentPassword string) (*model.User, *apperrors.APIError) {
+	normalizedEmail := strings.ToLower(strings.TrimSpace(email))
+	if address, err := mail.ParseAddress(normalizedEmail); err != nil || address.Address != normalizedEmail {
+		return nil, apperrors.BadRequest("invalid_email", "email must be a valid address")
+	}
+
+	user, apiErr := s.loadUser(ctx, userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := checkCurrentPassword(user, currentPassword); apiErr != nil {
+		return nil, apiErr
+	}
+	if normalizedEmail == user.Email {
+		return nil, apperrors.BadRequest("email_unchanged", "email is already set to this address")
+	}
+
+	_, err := s.userRepo.GetByEmail(ctx, normalizedEmail)
+	if err == nil {
+		return nil, apperrors.Conflict("email_exists", "email already registered", nil)
+	}
+	if err != repository.ErrNotFound {
+		return nil, apperrors.Internal("failed to query user")
+	}
+
+	now := time.Now().UTC()
+	tx, err := s.userTokenRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	if err := s.userRepo.UpdateEmailTx(ctx, tx, user.ID, normalizedEmail, now); err != nil {
+		if err == repository.ErrDuplicate {
+			return nil, apperrors.Conflict("email_exists", "email already registered", nil)
+		}
+		return nil, apperrors.Internal("failed to update email")
+	}
+	for _, purpose := range []string{model.TokenPurposePasswordReset, model.TokenPurposeEmailVerification} {
+		if err := s.userTokenRepo.InvalidateTx(ctx, tx, user.ID, purpose, now); err != nil {
+			return nil, apperrors.Internal("failed to invalidate tokens")
+		}
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		return nil, apperrors.Internal("failed to commit transaction")
+	}
+
+	// The change is stored; a verification mail that fails to send can be
+	// requested again, and the notice is only a courtesy.
+	_ = s.SendEmailVerification(ctx, user.ID)
+	_ = s.send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your Pomodoro email was changed",
+		Body: fmt.Sprintf(
+			"The email of your Pomodoro account was changed from %s to %s.\n\n"+
+				"If you did not make this change, reset your password right away.\n",
+			user.Email,
+			normalizedEmail,
+		),
+	})
+
+	user.Email = normalizedEmail
+	user.EmailVerifiedAt = nil
+	user.UpdatedAt = now
+	user.PasswordHash = ""
+	return user, nil
+}
+
+// Delete removes the account and, through the foreign keys, all of its
+// data. Revoking the refresh tokens first fails every access token issued
+// with them on its next request.
+func (s *AccountService) Delete(ctx context.Context, userID, currentPassword string) *apperrors.APIError {
+	user, apiErr := s.loadUser(ctx, userID)
+	if apiErr != nil {
+		return apiErr
+	}
+	if apiErr := checkCurrentPassword(user, currentPassword); apiErr != nil {
+		return apiErr
+	}
+
+	tx, err := s.tokenRepo.BeginTx(ctx)
+	if err != nil {
+		return apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	if err := s.tokenRepo.RevokeAllForUserTx(ctx, tx, user.ID, time.Now().UTC()); err != nil {
+		return apperrors.Internal("failed to revoke sessions")
+	}
+	if err := s.userRepo.DeleteTx(ctx, tx, user.ID); err != nil {
+		if err == repository.ErrNotFound {
+			return apperrors.NotFound("user_not_found", "user not found")
+		}
+		return apperrors.Internal("failed to delete account")
+	}
+	if err := s.attemptRepo.ClearTx(ctx, tx, user.Email); err != nil {
+		return apperrors.Internal("failed to reset login attempts")
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		return apperrors.Internal("failed to commit transaction")
+	}
+	return nil
+}
+
+func (s *AccountService) loadUser(ctx context.Context, userID string) (*model.User, *apperrors.APIError) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err == repository.ErrNotFound {
+		return nil, apperrors.NotFound("user_not_found", "user not found")
+	}
+	if err != nil {
+		return nil, apperrors.Internal("failed to query user")
+	}
+	return user, nil
+}
+
+func checkCurrentPassword(user *model.User, password string) *apperrors.APIError {
+	if password == "" {
+		return apperrors.BadRequest("invalid_password", "currentPassword is required")
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return apperrors.New(http.StatusForbidden, "invalid_current_password", "current password is incorrect")
+	}
+	return nil
+}
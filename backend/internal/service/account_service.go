@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/mailer"
+	"pomodoro/backend/internal/model"
+	"pomodoro/backend/internal/repository"
+)
+
+// AccountMailSettings controls the links mailed to users. AppBaseURL is the
+// frontend the links open, e.g. https://pomodoro.example.com.
+type AccountMailSettings struct {
+	AppBaseURL           string
+	PasswordResetTTL     time.Duration
+	EmailVerificationTTL time.Duration
+}
+
+type AccountService struct {
+	userRepo      repository.UserStore
+	userTokenRepo repository.UserTokenStore
+	tokenRepo     repository.RefreshTokenStore
+	attemptRepo   repository.LoginAttemptStore
+	mailer        mailer.Mailer
+	settings      AccountMailSettings
+}
+
+func NewAccountService(
+	userRepo repository.UserStore,
+	userTokenRepo repository.UserTokenStore,
+	tokenRepo repository.RefreshTokenStore,
+	attemptRepo repository.LoginAttemptStore,
+	mail mailer.Mailer,
+	settings AccountMailSettings,
+) *AccountService {
+	settings.AppBaseURL = strings.TrimRight(settings.AppBaseURL, "/")
+	return &AccountService{
+		userRepo:      userRepo,
+		userTokenRepo: userTokenRepo,
+		tokenRepo:     tokenRepo,
+		attemptRepo:   attemptRepo,
+		mailer:        mail,
+		settings:      settings,
+	}
+}
+
+// ForgotPassword mails a reset link when the email belongs to an account.
+// It succeeds either way, so callers cannot probe which emails exist; a
+// failure after the account was found is logged rather than returned for
+// the same reason.
+func (s *AccountService) ForgotPassword(ctx context.Context, email string) *apperrors.APIError {
+	normalizedEmail := strings.ToLower(strings.TrimSpace(email))
+	if normalizedEmail == "" {
+		return apperrors.BadRequest("invalid_email", "email is required")
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, normalizedEmail)
+	if err == repository.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return apperrors.Internal("failed to query user")
+	}
+
+	token, apiErr := s.issueToken(ctx, user, model.TokenPurposePasswordReset, s.settings.PasswordResetTTL)
+	if apiErr != nil {
+		log.Printf("account: issue password reset of user %s: %s", user.ID, apiErr.Message)
+		return nil
+	}
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Pomodoro password",
+		Body: fmt.Sprintf(
+			"Someone asked to reset the password of your Pomodoro account.\n\n"+
+				"Open this link within %s to choose a new password:\n%s\n\n"+
+				"If this was not you, you can ignore this email.\n",
+			formatTTL(s.settings.PasswordResetTTL),
+			s.link("/reset-password", token),
+		),
+	})
+	if err != nil {
+		log.Printf("account: mail password reset of user %s: %v", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword. Every
+// session of the user is signed out and any login lockout is lifted. The
+// reset also proves ownership of the email, which verifies it.
+func (s *AccountService) ResetPassword(ctx context.Context, rawToken, password string) *apperrors.APIError {
+	if apiErr := validatePassword(password); apiErr != nil {
+		return apiErr
+	}
+
+	now := time.Now().UTC()
+	token, apiErr := s.lookupToken(ctx, rawToken, model.TokenPurposePasswordReset, now)
+	if apiErr != nil {
+		return apiErr
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return apperrors.Internal("failed to secure password")
+	}
+
+	tx, err := s.userTokenRepo.BeginTx(ctx)
+	if err != nil {
+		return apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	if apiErr := s.consumeTokenTx(ctx, tx, token, now); apiErr != nil {
+		return apiErr
+	}
+	user, err := s.userRepo.GetByIDTx(ctx, tx, token.UserID)
+	if err != nil {
+		return apperrors.Internal("failed to query user")
+	}
+	if err := s.userRepo.UpdatePasswordTx(ctx, tx, user.ID, string(passwordHash), now); err != nil {
+		return apperrors.Internal("failed to update password")
+	}
+	if err := s.userTokenRepo.InvalidateTx(ctx, tx, user.ID, model.TokenPurposePasswordReset, now); err != nil {
+		return apperrors.Internal("failed to invalidate reset tokens")
+	}
+	if user.Email == token.Email {
+		if err := s.userRepo.MarkEmailVerifiedTx(ctx, tx, user.ID, now); err != nil {
+			return apperrors.Internal("failed to verify email")
+		}
+	}
+	if err := s.tokenRepo.RevokeAllForUserTx(ctx, tx, user.ID, now); err != nil {
+		return apperrors.Internal("failed to revoke sessions")
+	}
+	if err := s.attemptRepo.ClearTx(ctx, tx, user.Email); err != nil {
+		return apperrors.Internal("failed to reset login attempts")
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		return apperrors.Internal("failed to commit transaction")
+	}
+	return nil
+}
+
+// SendEmailVerification mails a verification link for the current email of
+// the user, replacing any link sent before.
+func (s *AccountService) SendEmailVerification(ctx context.Context, userID string) *apperrors.APIError {
+	user, apiErr := s.loadUser(ctx, userID)
+	if apiErr != nil {
+		return apiErr
+	}
+	if user.EmailVerifiedAt != nil {
+		return apperrors.Conflict("email_already_verified", "email is already verified", nil)
+	}
+
+	token, apiErr := s.issueToken(ctx, user, model.TokenPurposeEmailVerification, s.settings.EmailVerificationTTL)
+	if apiErr != nil {
+		return apiErr
+	}
+	return s.send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your Pomodoro email",
+		Body: fmt.Sprintf(
+			"Confirm that %s is your email address by opening this link within %s:\n%s\n",
+			user.Email,
+			formatTTL(s.settings.EmailVerificationTTL),
+			s.link("/verify-email", token),
+		),
+	})
+}
+
+func (s *AccountService) VerifyEmail(ctx context.Context, rawToken string) (*model.User, *apperrors.APIError) {
+	now := time.Now().UTC()
+	token, apiErr := s.lookupToken(ctx, rawToken, model.TokenPurposeEmailVerification, now)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	tx, err := s.userTokenRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	if apiErr := s.consumeTokenTx(ctx, tx, token, now); apiErr != nil {
+		return nil, apiErr
+	}
+	user, err := s.userRepo.GetByIDTx(ctx, tx, token.UserID)
+	if err != nil {
+		return nil, apperrors.Internal("failed to query user")
+	}
+	if user.Email != token.Email {
+		return nil, invalidTokenError()
+	}
+	if err := s.userRepo.MarkEmailVerifiedTx(ctx, tx, user.ID, now); err != nil {
+		return nil, apperrors.Internal("failed to verify email")
+	}
+	if user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &now
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		return nil, apperrors.Internal("failed to commit transaction")
+	}
+
+	user.PasswordHash = ""
+	return user, nil
+}
+
+// issueToken stores a new token for the user's current email and returns
+// its raw value; earlier tokens for the same purpose stop working.
+func (s *AccountService) issueToken(ctx context.Context, user *model.User, purpose string, ttl time.Duration) (string, *apperrors.APIError) {
+	raw, err := randomToken()
+	if err != nil {
+		return "", apperrors.Internal("failed to generate token")
+	}
+
+	now := time.Now().UTC()
+	tx, err := s.userTokenRepo.BeginTx(ctx)
+	if err != nil {
+		return "", apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	if err := s.userTokenRepo.InvalidateTx(ctx, tx, user.ID, purpose, now); err != nil {
+		return "", apperrors.Internal("failed to invalidate tokens")
+	}
+	token := model.UserToken{
+		ID:        uuid.NewString(),
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		TokenHash: hashToken(raw),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	if err := s.userTokenRepo.CreateTx(ctx, tx, &token); err != nil {
+		return "", apperrors.Internal("failed to store token")
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		return "", apperrors.Internal("failed to commit transaction")
+	}
+	return raw, nil
+}
+
+func (s *AccountService) lookupToken(ctx context.Context, rawToken, purpose string, now time.Time) (*model.UserToken, *apperrors.APIError) {
+	if rawToken == "" {
+		return nil, apperrors.BadRequest("invalid_token", "token is required")
+	}
+
+	token, err := s.userTokenRepo.GetByHash(ctx, hashToken(rawToken))
+	if err == repository.ErrNotFound {
+		return nil, invalidTokenError()
+	}
+	if err != nil {
+		return nil, apperrors.Internal("failed to query token")
+	}
+	if token.Purpose != purpose || token.UsedAt != nil || !now.Before(token.ExpiresAt) {
+		return nil, invalidTokenError()
+	}
+	return token, nil
+}
+
+// consumeTokenTx fails when a concurrent request used the token after
+// lookupToken read it.
+func (s *AccountService) consumeTokenTx(ctx context.Context, tx *sql.Tx, token *model.UserToken, now time.Time) *apperrors.APIError {
+	if err := s.userTokenRepo.ConsumeTx(ctx, tx, token.ID, now); err != nil {
+		if err == repository.ErrNotFound {
+			return invalidTokenError()
+		}
+		return apperrors.Internal("failed to consume token")
+	}
+	return nil
+}
+
+func (s *AccountService) send(ctx context.Context, message mailer.Message) *apperrors.APIError {
+	if err := s.mailer.Send(ctx, message); err != nil {
+		return apperrors.Internal("failed to send email")
+	}
+	return nil
+}
+
+func (s *AccountService) link(path, token string) string {
+	return s.settings.AppBaseURL + path + "?token=" + url.QueryEscape(token)
+}
+
+func invalidTokenError() *apperrors.APIError {
+	return apperrors.BadRequest("invalid_token", "token is invalid or has expired")
+}
+
+func formatTTL(ttl time.Duration) string {
+	count, unit := int(ttl/time.Minute), "minute"
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		count, unit = int(ttl/time.Hour), "hour"
+	}
+	if count == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", count, unit)
+}
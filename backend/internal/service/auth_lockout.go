@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/repository"
+)
+
+// loginFailureResetAfter is how long an email must go without a failed
+// login before its count starts over.
+const loginFailureResetAfter = 24 * time.Hour
+
+// LockoutPolicy locks an email out of login once Threshold consecutive
+// attempts have failed. The first lockout lasts Base and every further
+// failure doubles it, up to Max. A zero Threshold disables lockout.
+type LockoutPolicy struct {
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+}
+
+func (p LockoutPolicy) duration(failures int) time.Duration {
+	if p.Threshold <= 0 || failures < p.Threshold {
+		return 0
+	}
+	lockout := p.Base
+	for i := p.Threshold; i < failures && lockout < p.Max; i++ {
+		lockout *= 2
+	}
+	if lockout > p.Max {
+		return p.Max
+	}
+	return lockout
+}
+
+// checkLockout runs before the password is compared, so a locked email
+// costs no bcrypt work.
+func (s *AuthService) checkLockout(ctx context.Context, email string, now time.Time) *apperrors.APIError {
+	if s.lockout.Threshold <= 0 {
+		return nil
+	}
+	attempt, err := s.attemptRepo.Get(ctx, email)
+	if err == repository.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return apperrors.Internal("failed to query login attempts")
+	}
+	if attempt.LockedUntil == nil || !now.Before(*attempt.LockedUntil) {
+		return nil
+	}
+	return apperrors.TooManyRequests(
+		"account_locked",
+		"too many failed login attempts, try again later",
+		attempt.LockedUntil.Sub(now),
+	)
+}
+
+func (s *AuthService) recordLoginFailure(ctx context.Context, email string, now time.Time) *apperrors.APIError {
+	if s.lockout.Threshold <= 0 {
+		return nil
+	}
+	failures, err := s.attemptRepo.RecordFailure(ctx, email, now, now.Add(-loginFailureResetAfter))
+	if err != nil {
+		return apperrors.Internal("failed to record login attempt")
+	}
+	if lockout := s.lockout.duration(failures); lockout > 0 {
+		if err := s.attemptRepo.Lock(ctx, email, now.Add(lockout)); err != nil {
+			return apperrors.Internal("failed to record login attempt")
+		}
+	}
+	return nil
+}
@@ -2,8 +2,13 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
@@ -14,39 +19,87 @@ import (
 	"pomodoro/backend/internal/repository"
 )
 
+const (
+	refreshTokenBytes   = 32
+	maxDeviceNameLength = 100
+	maxPlatformLength   = 50
+	// deviceTouchInterval throttles last-seen writes so that every request
+	// does not turn into a database write.
+	deviceTouchInterval = time.Minute
+	// dummyPasswordHash is compared against on logins for unknown emails, so
+	// they take as long as a wrong password for a registered one. Its cost
+	// matches bcrypt.DefaultCost used for real passwords.
+	dummyPasswordHash = "$2a$10$5MC6c.Kk7dH00vFU/3YpeuKRRVqQ5o2Dh5DbNlsnfmJuUGQSB8mbq"
+)
+
 type AuthService struct {
-	userRepo     *repository.UserRepository
-	pomodoroRepo *repository.PomodoroRepository
-	jwtSecret    []byte
-	tokenTTL     time.Duration
+	userRepo        repository.UserStore
+	pomodoroRepo    repository.PomodoroStore
+	tokenRepo       repository.RefreshTokenStore
+	deviceRepo      repository.DeviceStore
+	attemptRepo     repository.LoginAttemptStore
+	lockout         LockoutPolicy
+	jwtSecret       []byte
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
 }
 
 func NewAuthService(
-	userRepo *repository.UserRepository,
-	pomodoroRepo *repository.PomodoroRepository,
+	userRepo repository.UserStore,
+	pomodoroRepo repository.PomodoroStore,
+	tokenRepo repository.RefreshTokenStore,
+	deviceRepo repository.DeviceStore,
+	attemptRepo repository.LoginAttemptStore,
+	lockout LockoutPolicy,
 	jwtSecret string,
-	tokenTTL time.Duration,
+	accessTokenTTL time.Duration,
+	refreshTokenTTL time.Duration,
 ) *AuthService {
 	return &AuthService{
-		userRepo:     userRepo,
-		pomodoroRepo: pomodoroRepo,
-		jwtSecret:    []byte(jwtSecret),
-		tokenTTL:     tokenTTL,
+		userRepo:        userRepo,
+		pomodoroRepo:    pomodoroRepo,
+		tokenRepo:       tokenRepo,
+		deviceRepo:      deviceRepo,
+		attemptRepo:     attemptRepo,
+		lockout:         lockout,
+		jwtSecret:       []byte(jwtSecret),
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
 	}
 }
 
 type AuthResult struct {
-	Token string     `json:"token"`
-	User  model.User `json:"user"`
+	Token            string        `json:"token"`
+	ExpiresAt        time.Time     `json:"expiresAt"`
+	RefreshToken     string        `json:"refreshToken"`
+	RefreshExpiresAt time.Time     `json:"refreshExpiresAt"`
+	User             model.User    `json:"user"`
+	Device           *model.Device `json:"device,omitempty"`
+}
+
+type TokenClaims struct {
+	UserID    string
+	TokenID   string
+	DeviceID  string
+	ExpiresAt time.Time
 }
 
-func (s *AuthService) Register(ctx context.Context, email, password string) (*AuthResult, *apperrors.APIError) {
+// DeviceInput describes the client signing in. A known, not revoked ID is
+// reused so that a device keeps one entry across logins.
+type DeviceInput struct {
+	ID       string
+	Name     string
+	Platform string
+	IP       string
+}
+
+func (s *AuthService) Register(ctx context.Context, email, password string, deviceInput DeviceInput) (*AuthResult, *apperrors.APIError) {
 	normalizedEmail := strings.ToLower(strings.TrimSpace(email))
 	if normalizedEmail == "" {
 		return nil, apperrors.BadRequest("invalid_email", "email is required")
 	}
-	if len(password) < 6 {
-		return nil, apperrors.BadRequest("invalid_password", "password must be at least 6 characters")
+	if apiErr := validatePassword(password); apiErr != nil {
+		return nil, apiErr
 	}
 
 	_, err := s.userRepo.GetByEmail(ctx, normalizedEmail)
@@ -72,7 +125,7 @@ func (s *AuthService) Register(ctx context.Context, email, password string) (*Au
 	}
 
 	if err := s.userRepo.Create(ctx, &user); err != nil {
-		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		if err == repository.ErrDuplicate {
 			return nil, apperrors.Conflict("email_exists", "email already registered", nil)
 		}
 		return nil, apperrors.Internal("failed to create user")
@@ -82,49 +135,165 @@ func (s *AuthService) Register(ctx context.Context, email, password string) (*Au
 		return nil, apperrors.Internal("failed to initialize user state")
 	}
 
-	token, apiErr := s.issueToken(user)
-	if apiErr != nil {
-		return nil, apiErr
-	}
-
-	user.PasswordHash = ""
-	return &AuthResult{
-		Token: token,
-		User:  user,
-	}, nil
+	return s.signIn(ctx, user, deviceInput)
 }
 
-func (s *AuthService) Login(ctx context.Context, email, password string) (*AuthResult, *apperrors.APIError) {
+func (s *AuthService) Login(ctx context.Context, email, password string, deviceInput DeviceInput) (*AuthResult, *apperrors.APIError) {
 	normalizedEmail := strings.ToLower(strings.TrimSpace(email))
 	if normalizedEmail == "" || password == "" {
 		return nil, apperrors.BadRequest("invalid_credentials", "email and password are required")
 	}
 
+	now := time.Now().UTC()
+	if apiErr := s.checkLockout(ctx, normalizedEmail, now); apiErr != nil {
+		return nil, apiErr
+	}
+
+	// Unknown emails count as failures too and still pay for a bcrypt
+	// comparison, so neither lockout nor response time reveals which
+	// addresses are registered.
 	user, err := s.userRepo.GetByEmail(ctx, normalizedEmail)
-	if err == repository.ErrNotFound {
+	if err != nil && err != repository.ErrNotFound {
+		return nil, apperrors.Internal("failed to query user")
+	}
+	passwordHash := dummyPasswordHash
+	if err == nil {
+		passwordHash = user.PasswordHash
+	}
+	if bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)) != nil || err == repository.ErrNotFound {
+		if apiErr := s.recordLoginFailure(ctx, normalizedEmail, now); apiErr != nil {
+			return nil, apiErr
+		}
 		return nil, apperrors.Unauthorized("invalid email or password")
 	}
+
+	if s.lockout.Threshold > 0 {
+		if err := s.attemptRepo.Clear(ctx, normalizedEmail); err != nil {
+			return nil, apperrors.Internal("failed to reset login attempts")
+		}
+	}
+
+	return s.signIn(ctx, *user, deviceInput)
+}
+
+// Refresh rotates a refresh token: the presented token and its access token
+// are revoked and a new pair in the same family is issued. Presenting an
+// already rotated token is treated as theft and revokes the whole family.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*AuthResult, *apperrors.APIError) {
+	if refreshToken == "" {
+		return nil, apperrors.BadRequest("invalid_refresh_token", "refreshToken is required")
+	}
+
+	now := time.Now().UTC()
+	stored, err := s.tokenRepo.GetByHash(ctx, hashToken(refreshToken))
+	if err == repository.ErrNotFound {
+		return nil, apperrors.Unauthorized("invalid refresh token")
+	}
+	if err != nil {
+		return nil, apperrors.Internal("failed to query refresh token")
+	}
+
+	if stored.RevokedAt != nil {
+		if stored.ReplacedBy != nil {
+			if err := s.tokenRepo.RevokeFamily(ctx, stored.FamilyID, now); err != nil {
+				return nil, apperrors.Internal("failed to revoke refresh tokens")
+			}
+		}
+		return nil, apperrors.Unauthorized("refresh token revoked")
+	}
+	if !now.Before(stored.ExpiresAt) {
+		return nil, apperrors.Unauthorized("refresh token expired")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, stored.UserID)
+	if err == repository.ErrNotFound {
+		return nil, apperrors.Unauthorized("invalid refresh token")
+	}
 	if err != nil {
 		return nil, apperrors.Internal("failed to query user")
 	}
 
-	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
-		return nil, apperrors.Unauthorized("invalid email or password")
+	tx, err := s.tokenRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, apperrors.Internal("failed to start transaction")
 	}
+	defer tx.Rollback()
 
-	token, apiErr := s.issueToken(*user)
+	pair, apiErr := s.newTokenPair(*user, stored.FamilyID, stored.DeviceID, now)
 	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	user.PasswordHash = ""
-	return &AuthResult{
-		Token: token,
-		User:  *user,
-	}, nil
+	if err := s.tokenRepo.RotateTx(ctx, tx, stored.ID, pair.record.ID, now); err != nil {
+		if err == repository.ErrNotFound {
+			return nil, apperrors.Unauthorized("refresh token revoked")
+		}
+		return nil, apperrors.Internal("failed to rotate refresh token")
+	}
+	if err := s.tokenRepo.CreateTx(ctx, tx, &pair.record); err != nil {
+		return nil, apperrors.Internal("failed to store refresh token")
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		return nil, apperrors.Internal("failed to commit transaction")
+	}
+
+	return &pair.result, nil
+}
+
+func (s *AuthService) Logout(ctx context.Context, tokenID string) *apperrors.APIError {
+	if err := s.tokenRepo.Revoke(ctx, tokenID, time.Now().UTC()); err != nil {
+		return apperrors.Internal("failed to revoke token")
+	}
+	return nil
+}
+
+// Authenticate verifies the access token signature and that the session it
+// belongs to has not been revoked by logout, refresh token rotation or a
+// remote device sign-out. It also records the device as recently seen.
+func (s *AuthService) Authenticate(ctx context.Context, tokenString, clientIP string) (*TokenClaims, *apperrors.APIError) {
+	claims, apiErr := s.parseToken(tokenString)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	stored, apiErr := s.activeToken(ctx, claims.UserID, claims.TokenID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if stored.DeviceID != nil {
+		claims.DeviceID = *stored.DeviceID
+		now := time.Now().UTC()
+		// Last-seen tracking is best effort and must not reject the request.
+		_ = s.deviceRepo.Touch(ctx, claims.DeviceID, clientIP, now, now.Add(-deviceTouchInterval))
+	}
+
+	return claims, nil
+}
+
+// CheckToken reports whether the session behind an already authenticated
+// access token is still active. Long-lived requests call it repeatedly.
+func (s *AuthService) CheckToken(ctx context.Context, userID, tokenID string) *apperrors.APIError {
+	_, apiErr := s.activeToken(ctx, userID, tokenID)
+	return apiErr
+}
+
+func (s *AuthService) activeToken(ctx context.Context, userID, tokenID string) (*model.RefreshToken, *apperrors.APIError) {
+	stored, err := s.tokenRepo.GetByID(ctx, tokenID)
+	if err == repository.ErrNotFound {
+		return nil, apperrors.Unauthorized("token revoked")
+	}
+	if err != nil {
+		return nil, apperrors.Internal("failed to verify token")
+	}
+	if stored.RevokedAt != nil || stored.UserID != userID {
+		return nil, apperrors.Unauthorized("token revoked")
+	}
+	return stored, nil
 }
 
-func (s *AuthService) ParseToken(tokenString string) (string, *apperrors.APIError) {
+func (s *AuthService) parseToken(tokenString string) (*TokenClaims, *apperrors.APIError) {
 	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if token.Method != jwt.SigningMethodHS256 {
 			return nil, jwt.ErrSignatureInvalid
@@ -132,33 +301,161 @@ func (s *AuthService) ParseToken(tokenString string) (string, *apperrors.APIErro
 		return s.jwtSecret, nil
 	})
 	if err != nil || !token.Valid {
-		return "", apperrors.Unauthorized("invalid token")
+		return nil, apperrors.Unauthorized("invalid token")
 	}
 
 	claims, ok := token.Claims.(*jwt.RegisteredClaims)
 	if !ok {
-		return "", apperrors.Unauthorized("invalid token")
+		return nil, apperrors.Unauthorized("invalid token")
 	}
 
 	if claims.Subject == "" {
-		return "", apperrors.Unauthorized("invalid token subject")
+		return nil, apperrors.Unauthorized("invalid token subject")
 	}
+	if claims.ID == "" {
+		return nil, apperrors.Unauthorized("invalid token id")
+	}
+
+	result := TokenClaims{UserID: claims.Subject, TokenID: claims.ID}
+	if claims.ExpiresAt != nil {
+		result.ExpiresAt = claims.ExpiresAt.Time
+	}
+	return &result, nil
+}
 
-	return claims.Subject, nil
+type tokenPair struct {
+	result AuthResult
+	record model.RefreshToken
 }
 
-func (s *AuthService) issueToken(user model.User) (string, *apperrors.APIError) {
+func (s *AuthService) signIn(ctx context.Context, user model.User, deviceInput DeviceInput) (*AuthResult, *apperrors.APIError) {
 	now := time.Now().UTC()
+	device, apiErr := s.registerDevice(ctx, user.ID, deviceInput, now)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	pair, apiErr := s.newTokenPair(user, uuid.NewString(), &device.ID, now)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if err := s.tokenRepo.Create(ctx, &pair.record); err != nil {
+		return nil, apperrors.Internal("failed to store refresh token")
+	}
+	pair.result.Device = device
+	return &pair.result, nil
+}
+
+func (s *AuthService) registerDevice(ctx context.Context, userID string, input DeviceInput, now time.Time) (*model.Device, *apperrors.APIError) {
+	name := strings.TrimSpace(input.Name)
+	platform := strings.TrimSpace(input.Platform)
+	if utf8.RuneCountInString(name) > maxDeviceNameLength {
+		return nil, apperrors.BadRequest("invalid_device", "device name must be at most 100 characters")
+	}
+	if utf8.RuneCountInString(platform) > maxPlatformLength {
+		return nil, apperrors.BadRequest("invalid_device", "device platform must be at most 50 characters")
+	}
+
+	if input.ID != "" {
+		device, err := s.deviceRepo.GetByID(ctx, userID, input.ID)
+		if err != nil && err != repository.ErrNotFound {
+			return nil, apperrors.Internal("failed to query device")
+		}
+		if err == nil && device.RevokedAt == nil {
+			if name != "" {
+				device.Name = name
+			}
+			if platform != "" {
+				device.Platform = platform
+			}
+			device.LastIP = input.IP
+			device.LastSeenAt = now
+			err = s.deviceRepo.UpdateDetails(ctx, device)
+			if err == nil {
+				return device, nil
+			}
+			if err != repository.ErrNotFound {
+				return nil, apperrors.Internal("failed to update device")
+			}
+		}
+	}
+
+	if name == "" {
+		name = model.DefaultDeviceName
+	}
+	device := model.Device{
+		ID:         uuid.NewString(),
+		UserID:     userID,
+		Name:       name,
+		Platform:   platform,
+		LastIP:     input.IP,
+		LastSeenAt: now,
+		CreatedAt:  now,
+	}
+	if err := s.deviceRepo.Create(ctx, &device); err != nil {
+		return nil, apperrors.Internal("failed to register device")
+	}
+	return &device, nil
+}
+
+func (s *AuthService) newTokenPair(user model.User, familyID string, deviceID *string, now time.Time) (*tokenPair, *apperrors.APIError) {
+	tokenID := uuid.NewString()
+	accessExpiresAt := now.Add(s.accessTokenTTL)
 	claims := jwt.RegisteredClaims{
 		Subject:   user.ID,
-		ID:        uuid.NewString(),
+		ID:        tokenID,
 		IssuedAt:  jwt.NewNumericDate(now),
-		ExpiresAt: jwt.NewNumericDate(now.Add(s.tokenTTL)),
+		ExpiresAt: jwt.NewNumericDate(accessExpiresAt),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	signed, err := token.SignedString(s.jwtSecret)
 	if err != nil {
-		return "", apperrors.Internal("failed to sign token")
+		return nil, apperrors.Internal("failed to sign token")
 	}
-	return signed, nil
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, apperrors.Internal("failed to generate refresh token")
+	}
+	refreshExpiresAt := now.Add(s.refreshTokenTTL)
+
+	user.PasswordHash = ""
+	return &tokenPair{
+		result: AuthResult{
+			Token:            signed,
+			ExpiresAt:        accessExpiresAt,
+			RefreshToken:     refreshToken,
+			RefreshExpiresAt: refreshExpiresAt,
+			User:             user,
+		},
+		record: model.RefreshToken{
+			ID:        tokenID,
+			UserID:    user.ID,
+			DeviceID:  deviceID,
+			FamilyID:  familyID,
+			TokenHash: hashToken(refreshToken),
+			ExpiresAt: refreshExpiresAt,
+			CreatedAt: now,
+		},
+	}, nil
+}
+
+func validatePassword(password string) *apperrors.APIError {
+	if len(password) < 6 {
+		return apperrors.BadRequest("invalid_password", "password must be at least 6 characters")
+	}
+	return nil
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/model"
+	"pomodoro/backend/internal/repository"
+)
+
+type deviceContextKey struct{}
+
+type DeviceService struct {
+	repo      repository.DeviceStore
+	tokenRepo repository.RefreshTokenStore
+}
+
+func NewDeviceService(repo repository.DeviceStore, tokenRepo repository.RefreshTokenStore) *DeviceService {
+	return &DeviceService{repo: repo, tokenRepo: tokenRepo}
+}
+
+// WithDeviceID attaches the authenticated device to a request context so
+// that state mutations can record which device made them.
+func WithDeviceID(ctx context.Context, deviceID string) context.Context {
+	return context.WithValue(ctx, deviceContextKey{}, deviceID)
+}
+
+func deviceIDFromContext(ctx context.Context) *string {
+	deviceID, ok := ctx.Value(deviceContextKey{}).(string)
+	if !ok || deviceID == "" {
+		return nil
+	}
+	return &deviceID
+}
+
+func (s *DeviceService) List(ctx context.Context, userID string) ([]model.Device, *apperrors.APIError) {
+	devices, err := s.repo.ListActive(ctx, userID)
+	if err != nil {
+		return nil, apperrors.Internal("failed to list devices")
+	}
+	return devices, nil
+}
+
+// Revoke signs a device out: the device is marked revoked and every refresh
+// token bound to it stops working, which also invalidates its access tokens.
+func (s *DeviceService) Revoke(ctx context.Context, userID, deviceID string) *apperrors.APIError {
+	now := time.Now().UTC()
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	if err := s.repo.RevokeTx(ctx, tx, userID, deviceID, now); err != nil {
+		if err == repository.ErrNotFound {
+			return apperrors.NotFound("device_not_found", "device not found")
+		}
+		return apperrors.Internal("failed to revoke device")
+	}
+	if err := s.tokenRepo.RevokeDeviceTx(ctx, tx, deviceID, now); err != nil {
+		return apperrors.Internal("failed to revoke device tokens")
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		return apperrors.Internal("failed to commit transaction")
+	}
+	return nil
+}
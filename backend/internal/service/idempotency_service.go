@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/model"
+	"pomodoro/backend/internal/repository"
+)
+
+// idempotencyStaleAfter bounds how long a key stays locked by a request that
+// never finished, e.g. because the process died while handling it.
+const idempotencyStaleAfter = time.Minute
+
+type IdempotencyService struct {
+	repo repository.IdempotencyStore
+	ttl  time.Duration
+}
+
+func NewIdempotencyService(repo repository.IdempotencyStore, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{repo: repo, ttl: ttl}
+}
+
+// Begin claims key for a request identified by requestHash. It returns nil
+// when the caller should process the request and then Complete or Release
+// the key, and the stored record when the response should be replayed.
+func (s *IdempotencyService) Begin(ctx context.Context, userID, key, requestHash string) (*model.IdempotencyKey, *apperrors.APIError) {
+	now := time.Now().UTC()
+	if err := s.repo.DeleteExpired(ctx, userID, now); err != nil {
+		return nil, apperrors.Internal("failed to check idempotency key")
+	}
+
+	record := &model.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+	err := s.repo.Create(ctx, record)
+	if err == nil {
+		return nil, nil
+	}
+	if err != repository.ErrDuplicate {
+		return nil, apperrors.Internal("failed to save idempotency key")
+	}
+
+	existing, err := s.repo.Get(ctx, userID, key)
+	if err != nil {
+		return nil, apperrors.Internal("failed to load idempotency key")
+	}
+	if existing.RequestHash != requestHash {
+		return nil, apperrors.New(
+			http.StatusUnprocessableEntity,
+			"idempotency_key_reused",
+			"idempotency key was already used for a different request",
+		)
+	}
+	if existing.CompletedAt != nil {
+		return existing, nil
+	}
+
+	err = s.repo.Reclaim(ctx, record, now.Add(-idempotencyStaleAfter))
+	if err == nil {
+		return nil, nil
+	}
+	if err != repository.ErrNotFound {
+		return nil, apperrors.Internal("failed to save idempotency key")
+	}
+	return nil, apperrors.Conflict(
+		"idempotency_in_progress",
+		"a request with this idempotency key is still being processed",
+		nil,
+	)
+}
+
+// Complete stores the response so that retries with the same key receive
+// it instead of running the request again.
+func (s *IdempotencyService) Complete(ctx context.Context, userID, key string, statusCode int, body string) *apperrors.APIError {
+	err := s.repo.Complete(ctx, userID, key, statusCode, body, time.Now().UTC())
+	if err != nil && err != repository.ErrNotFound {
+		return apperrors.Internal("failed to save idempotent response")
+	}
+	return nil
+}
+
+// Release frees key after a failure the client may retry.
+func (s *IdempotencyService) Release(ctx context.Context, userID, key string) *apperrors.APIError {
+	if err := s.repo.Delete(ctx, userID, key); err != nil {
+		return apperrors.Internal("failed to release idempotency key")
+	}
+	return nil
+}
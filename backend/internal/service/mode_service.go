@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/model"
+	"pomodoro/backend/internal/repository"
+)
+
+const maxModeNameLength = 50
+
+var modeColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+type ModeService struct {
+	repo            repository.ModeStore
+	pomodoroService *PomodoroService
+}
+
+// ModeInput describes a whole custom mode. Key is only read on create; a
+// mode keeps its key for life because timers and sessions refer to it.
+type ModeInput struct {
+	Key             string
+	Name            string
+	DurationSeconds int
+	Color           string
+	CountsAsFocus   bool
+}
+
+// ModeView is one entry of the modes a timer can switch to. Built-in modes
+// have no ID and take their duration from the timer settings.
+type ModeView struct {
+	ID              string `json:"id,omitempty"`
+	Key             string `json:"key"`
+	Name            string `json:"name"`
+	DurationSeconds int    `json:"durationSeconds"`
+	Color           string `json:"color"`
+	CountsAsFocus   bool   `json:"countsAsFocus"`
+	BuiltIn         bool   `json:"builtIn"`
+}
+
+func NewModeService(repo repository.ModeStore, pomodoroService *PomodoroService) *ModeService {
+	return &ModeService{repo: repo, pomodoroService: pomodoroService}
+}
+
+// List returns the built-in modes followed by the user's custom modes.
+func (s *ModeService) List(ctx context.Context, userID string) ([]ModeView, *apperrors.APIError) {
+	state, apiErr := s.pomodoroService.GetState(ctx, userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	custom, err := s.repo.List(ctx, userID)
+	if err != nil {
+		return nil, apperrors.Internal("failed to list modes")
+	}
+
+	durations := map[string]int{
+		model.ModeFocus:      state.FocusDurationSeconds,
+		model.ModeShortBreak: state.ShortBreakDurationSeconds,
+		model.ModeLongBreak:  state.LongBreakDurationSeconds,
+	}
+	names := map[string]string{
+		model.ModeFocus:      "Focus",
+		model.ModeShortBreak: "Short break",
+		model.ModeLongBreak:  "Long break",
+	}
+	colors := map[string]string{
+		model.ModeFocus:      model.ColorFocus,
+		model.ModeShortBreak: model.ColorShortBreak,
+		model.ModeLongBreak:  model.ColorLongBreak,
+	}
+
+	views := make([]ModeView, 0, len(builtInModes)+len(custom))
+	for _, key := range builtInModes {
+		views = append(views, ModeView{
+			Key:             key,
+			Name:            names[key],
+			DurationSeconds: durations[key],
+			Color:           colors[key],
+			CountsAsFocus:   key == model.ModeFocus,
+			BuiltIn:         true,
+		})
+	}
+	for _, mode := range custom {
+		views = append(views, ModeView{
+			ID:              mode.ID,
+			Key:             mode.Key,
+			Name:            mode.Name,
+			DurationSeconds: mode.DurationSeconds,
+			Color:           mode.Color,
+			CountsAsFocus:   mode.CountsAsFocus,
+		})
+	}
+	return views, nil
+}
+
+func (s *ModeService) Get(ctx context.Context, userID, modeID string) (*model.Mode, *apperrors.APIError) {
+	mode, err := s.repo.GetByID(ctx, userID, modeID)
+	if err == repository.ErrNotFound {
+		return nil, apperrors.NotFound("mode_not_found", "mode not found")
+	}
+	if err != nil {
+		return nil, apperrors.Internal("failed to get mode")
+	}
+	return mode, nil
+}
+
+func (s *ModeService) Create(ctx context.Context, userID string, input ModeInput) (*model.Mode, *apperrors.APIError) {
+	key := strings.TrimSpace(input.Key)
+	if isBuiltInMode(key) {
+		return nil, apperrors.BadRequest("invalid_key", "key is reserved for a built-in mode")
+	}
+	if !modeKeyPattern.MatchString(key) {
+		return nil, apperrors.BadRequest(
+			"invalid_key",
+			"key must start with a lowercase letter and use at most 30 lowercase letters, digits or underscores",
+		)
+	}
+
+	now := time.Now().UTC()
+	mode := model.Mode{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Key:       key,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if apiErr := applyModeInput(&mode, input); apiErr != nil {
+		return nil, apiErr
+	}
+
+	if err := s.repo.Create(ctx, &mode); err != nil {
+		if err == repository.ErrDuplicate {
+			return nil, apperrors.Conflict("mode_key_exists", "a mode with this key already exists", nil)
+		}
+		return nil, apperrors.Internal("failed to create mode")
+	}
+	return &mode, nil
+}
+
+func (s *ModeService) Update(ctx context.Context, userID, modeID string, input ModeInput) (*model.Mode, *apperrors.APIError) {
+	mode, apiErr := s.Get(ctx, userID, modeID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if key := strings.TrimSpace(input.Key); key != "" && key != mode.Key {
+		return nil, apperrors.BadRequest("invalid_key", "key cannot be changed")
+	}
+	if apiErr := applyModeInput(mode, input); apiErr != nil {
+		return nil, apiErr
+	}
+	mode.UpdatedAt = time.Now().UTC()
+
+	if err := s.repo.Update(ctx, mode); err != nil {
+		if err == repository.ErrNotFound {
+			return nil, apperrors.NotFound("mode_not_found", "mode not found")
+		}
+		return nil, apperrors.Internal("failed to update mode")
+	}
+	return mode, nil
+}
+
+// Delete refuses to remove the mode the timer is on. Sessions recorded in
+// the mode keep it in history.
+func (s *ModeService) Delete(ctx context.Context, userID, modeID string) *apperrors.APIError {
+	mode, apiErr := s.Get(ctx, userID, modeID)
+	if apiErr != nil {
+		return apiErr
+	}
+	return s.pomodoroService.DeleteMode(ctx, userID, mode)
+}
+
+func applyModeInput(mode *model.Mode, input ModeInput) *apperrors.APIError {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return apperrors.BadRequest("invalid_name", "name is required")
+	}
+	if utf8.RuneCountInString(name) > maxModeNameLength {
+		return apperrors.BadRequest("invalid_name", "name must be at most 50 characters")
+	}
+	if input.DurationSeconds <= 0 {
+		return apperrors.BadRequest("invalid_duration", "durationSeconds must be positive seconds")
+	}
+	color := strings.ToUpper(strings.TrimSpace(input.Color))
+	if color == "" {
+		color = model.DefaultModeColor
+	}
+	if !modeColorPattern.MatchString(color) {
+		return apperrors.BadRequest("invalid_color", "color must be a hex color like #4F46E5")
+	}
+
+	mode.Name = name
+	mode.DurationSeconds = input.DurationSeconds
+	mode.Color = color
+	mode.CountsAsFocus = input.CountsAsFocus
+	return nil
+}
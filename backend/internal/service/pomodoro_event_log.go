@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/model"
+	"pomodoro/backend/internal/repository"
+)
+
+const (
+	defaultEventLogLimit = 100
+	maxEventLogLimit     = 500
+)
+
+type EventLogQuery struct {
+	Limit     int
+	Cursor    string
+	SessionID string
+	From      string
+	To        string
+}
+
+type EventLogPage struct {
+	Events     []model.PomodoroEvent `json:"events"`
+	NextCursor *string               `json:"nextCursor"`
+}
+
+// GetEventLog pages through the append-only transition log, newest first.
+func (s *PomodoroService) GetEventLog(ctx context.Context, userID string, query EventLogQuery) (*EventLogPage, *apperrors.APIError) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultEventLogLimit
+	}
+	if limit > maxEventLogLimit {
+		limit = maxEventLogLimit
+	}
+
+	filter := repository.EventFilter{
+		SessionID: query.SessionID,
+		Limit:     limit + 1,
+	}
+	if query.From != "" {
+		from, err := time.Parse(time.RFC3339, query.From)
+		if err != nil {
+			return nil, apperrors.BadRequest("invalid_from", "from must be an RFC 3339 timestamp")
+		}
+		filter.From = &from
+	}
+	if query.To != "" {
+		to, err := time.Parse(time.RFC3339, query.To)
+		if err != nil {
+			return nil, apperrors.BadRequest("invalid_to", "to must be an RFC 3339 timestamp")
+		}
+		filter.To = &to
+	}
+	if query.Cursor != "" {
+		beforeID, err := strconv.ParseInt(query.Cursor, 10, 64)
+		if err != nil || beforeID <= 0 {
+			return nil, apperrors.BadRequest("invalid_cursor", "cursor is malformed")
+		}
+		filter.BeforeID = beforeID
+	}
+
+	events, err := s.repo.ListEvents(ctx, userID, filter)
+	if err != nil {
+		return nil, apperrors.Internal("failed to get event log")
+	}
+
+	page := EventLogPage{Events: events}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		nextCursor := strconv.FormatInt(page.Events[limit-1].ID, 10)
+		page.NextCursor = &nextCursor
+	}
+	return &page, nil
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/model"
+)
+
+const (
+	ExportFormatJSON = "json"
+	ExportFormatCSV  = "csv"
+	ExportFormatICS  = "ics"
+)
+
+const (
+	icsTimeLayout   = "20060102T150405Z"
+	icsMaxLineBytes = 75
+)
+
+var exportContentTypes = map[string]string{
+	ExportFormatJSON: "application/json; charset=utf-8",
+	ExportFormatCSV:  "text/csv; charset=utf-8",
+	ExportFormatICS:  "text/calendar; charset=utf-8",
+}
+
+var sessionCSVHeader = []string{
+	"id",
+	"mode",
+	"status",
+	"task_id",
+	"planned_duration_seconds",
+	"actual_duration_seconds",
+	"pause_count",
+	"paused_seconds",
+	"started_at",
+	"ended_at",
+	"created_at",
+	"updated_at",
+}
+
+// sessionEncoder streams sessions in one export format: begin and end frame
+// the document, write is called once per session.
+type sessionEncoder interface {
+	begin() error
+	write(session model.PomodoroSession) error
+	end() error
+}
+
+// ExportContentType validates format and returns the media type it is
+// served with.
+func ExportContentType(format string) (string, *apperrors.APIError) {
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		return "", apperrors.BadRequest("invalid_format", "format must be one of json, csv, ics")
+	}
+	return contentType, nil
+}
+
+// ExportSessions writes every session of the user to w as it is read, so
+// memory use does not grow with the size of the history.
+func (s *PomodoroService) ExportSessions(ctx context.Context, userID, format string, w io.Writer) *apperrors.APIError {
+	if _, apiErr := ExportContentType(format); apiErr != nil {
+		return apiErr
+	}
+
+	now := time.Now().UTC()
+	var encoder sessionEncoder
+	switch format {
+	case ExportFormatCSV:
+		encoder = &csvSessionEncoder{w: csv.NewWriter(w)}
+	case ExportFormatICS:
+		tasks, err := s.taskRepo.List(ctx, userID, "")
+		if err != nil {
+			return apperrors.Internal("failed to load tasks")
+		}
+		titles := make(map[string]string, len(tasks))
+		for _, task := range tasks {
+			titles[task.ID] = task.Title
+		}
+		encoder = &icsSessionEncoder{w: w, taskTitles: titles, stamp: now}
+	default:
+		encoder = &jsonSessionEncoder{w: w, exportedAt: now}
+	}
+
+	if err := encoder.begin(); err != nil {
+		return apperrors.Internal("failed to export sessions")
+	}
+	if err := s.repo.EachSession(ctx, userID, encoder.write); err != nil {
+		return apperrors.Internal("failed to export sessions")
+	}
+	if err := encoder.end(); err != nil {
+		return apperrors.Internal("failed to export sessions")
+	}
+	return nil
+}
+
+type jsonSessionEncoder struct {
+	w          io.Writer
+	exportedAt time.Time
+	count      int
+}
+
+func (e *jsonSessionEncoder) begin() error {
+	exportedAt, err := json.Marshal(e.exportedAt)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(e.w, `{"exportedAt":%s,"sessions":[`, exportedAt)
+	return err
+}
+
+func (e *jsonSessionEncoder) write(session model.PomodoroSession) error {
+	payload, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	if e.count > 0 {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.count++
+	_, err = e.w.Write(payload)
+	return err
+}
+
+func (e *jsonSessionEncoder) end() error {
+	_, err := io.WriteString(e.w, "]}\n")
+	return err
+}
+
+type csvSessionEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvSessionEncoder) begin() error {
+	return e.w.Write(sessionCSVHeader)
+}
+
+func (e *csvSessionEncoder) write(session model.PomodoroSession) error {
+	taskID := ""
+	if session.TaskID != nil {
+		taskID = *session.TaskID
+	}
+	endedAt := ""
+	if session.EndedAt != nil {
+		endedAt = session.EndedAt.UTC().Format(time.RFC3339)
+	}
+	return e.w.Write([]string{
+		session.ID,
+		session.Mode,
+		session.Status,
+		taskID,
+		strconv.Itoa(session.PlannedDurationSeconds),
+		strconv.Itoa(session.ActualDurationSeconds),
+		strconv.Itoa(session.PauseCount),
+		strconv.Itoa(session.PausedSeconds),
+		session.StartedAt.UTC().Format(time.RFC3339),
+		endedAt,
+		session.CreatedAt.UTC().Format(time.RFC3339),
+		session.UpdatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+func (e *csvSessionEncoder) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// icsSessionEncoder emits one VEVENT per completed session recorded as focus
+// time, in focus or a custom mode counting as focus; breaks and unfinished
+// sessions are left out of the calendar.
+type icsSessionEncoder struct {
+	w          io.Writer
+	taskTitles map[string]string
+	stamp      time.Time
+}
+
+func (e *icsSessionEncoder) begin() error {
+	return e.lines(
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Pomodoro Sync//Session Export//EN",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:Pomodoro focus sessions",
+	)
+}
+
+func (e *icsSessionEncoder) write(session model.PomodoroSession) error {
+	if !session.CountsAsFocus || session.Status != model.SessionStatusCompleted {
+		return nil
+	}
+
+	endedAt := session.StartedAt.Add(time.Duration(session.ActualDurationSeconds) * time.Second)
+	if session.EndedAt != nil {
+		endedAt = *session.EndedAt
+	}
+
+	summary := "Focus session"
+	if session.TaskID != nil {
+		if title, ok := e.taskTitles[*session.TaskID]; ok {
+			summary = "Focus: " + title
+		}
+	}
+	description := fmt.Sprintf("Focused %d min", session.ActualDurationSeconds/60)
+	if session.PauseCount > 0 {
+		description += fmt.Sprintf(", paused %d times", session.PauseCount)
+	}
+
+	return e.lines(
+		"BEGIN:VEVENT",
+		"UID:"+session.ID+"@pomodoro",
+		"DTSTAMP:"+e.stamp.Format(icsTimeLayout),
+		"DTSTART:"+session.StartedAt.UTC().Format(icsTimeLayout),
+		"DTEND:"+endedAt.UTC().Format(icsTimeLayout),
+		"SUMMARY:"+escapeICSText(summary),
+		"DESCRIPTION:"+escapeICSText(description),
+		"TRANSP:OPAQUE",
+		"END:VEVENT",
+	)
+}
+
+func (e *icsSessionEncoder) end() error {
+	return e.lines("END:VCALENDAR")
+}
+
+func (e *icsSessionEncoder) lines(lines ...string) error {
+	var builder strings.Builder
+	for _, line := range lines {
+		foldICSLine(&builder, line)
+	}
+	_, err := io.WriteString(e.w, builder.String())
+	return err
+}
+
+func escapeICSText(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(value)
+}
+
+// foldICSLine writes line with CRLF endings, folding it into continuation
+// lines of at most 75 octets without splitting a UTF-8 sequence (RFC 5545
+// section 3.1).
+func foldICSLine(builder *strings.Builder, line string) {
+	limit := icsMaxLineBytes
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		builder.WriteString(line[:cut])
+		builder.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space of a continuation line counts towards its length.
+		limit = icsMaxLineBytes - 1
+	}
+	builder.WriteString(line)
+	builder.WriteString("\r\n")
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/model"
+	"pomodoro/backend/internal/repository"
+)
+
+type GoalPeriodProgress struct {
+	Start              string `json:"start"`
+	CompletedPomodoros int    `json:"completedPomodoros"`
+	FocusSeconds       int    `json:"focusSeconds"`
+	TargetPomodoros    *int   `json:"targetPomodoros,omitempty"`
+	TargetFocusSeconds *int   `json:"targetFocusSeconds,omitempty"`
+	Achieved           bool   `json:"achieved"`
+}
+
+// GoalProgress reports how far the completed focus sessions of the current
+// day and week are towards the user's goals. Overworked is set once today's
+// focus time exceeds DailyMaxFocusSeconds.
+type GoalProgress struct {
+	TimeZone             string             `json:"timeZone"`
+	Today                GoalPeriodProgress `json:"today"`
+	ThisWeek             GoalPeriodProgress `json:"thisWeek"`
+	DailyMaxFocusSeconds *int               `json:"dailyMaxFocusSeconds,omitempty"`
+	Overworked           bool               `json:"overworked"`
+}
+
+type GoalsView struct {
+	Goals    model.FocusGoals `json:"goals"`
+	Progress GoalProgress     `json:"progress"`
+}
+
+type UpdateGoalsInput struct {
+	TimeZone             string
+	DailyPomodoros       *int
+	DailyFocusSeconds    *int
+	WeeklyPomodoros      *int
+	WeeklyFocusSeconds   *int
+	DailyMaxFocusSeconds *int
+}
+
+func (s *PomodoroService) GetGoals(ctx context.Context, userID string) (*GoalsView, *apperrors.APIError) {
+	// Reading the state settles a session that ran out since the last
+	// request, so it counts towards the goals.
+	if _, apiErr := s.GetState(ctx, userID); apiErr != nil {
+		return nil, apiErr
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	goals, err := s.goalRepo.GetTx(ctx, tx, userID)
+	if err == repository.ErrNotFound {
+		return nil, apperrors.NotFound("goals_not_found", "focus goals not set")
+	}
+	if err != nil {
+		return nil, apperrors.Internal("failed to get focus goals")
+	}
+	progress, apiErr := s.computeGoalProgress(ctx, tx, goals, time.Now().UTC())
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		return nil, apperrors.Internal("failed to commit transaction")
+	}
+	return &GoalsView{Goals: *goals, Progress: *progress}, nil
+}
+
+// UpdateGoals replaces all goals of the user; targets left out are no
+// longer tracked. The goal progress in the state changes with them, so the
+// change is recorded as an update_goals transition and published.
+func (s *PomodoroService) UpdateGoals(ctx context.Context, userID string, input UpdateGoalsInput) (*GoalsView, *apperrors.APIError) {
+	if apiErr := validateGoals(input); apiErr != nil {
+		return nil, apiErr
+	}
+	loc, apiErr := loadTimeZone(input.TimeZone)
+	if apiErr != nil {
+		return nil, apperrors.BadRequest("invalid_time_zone", "timeZone must be an IANA time zone name")
+	}
+
+	now := time.Now().UTC()
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	state, apiErr := s.getStateForUpdate(ctx, tx, userID, now)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	before := *state
+
+	goals := model.FocusGoals{
+		UserID:               userID,
+		TimeZone:             loc.String(),
+		DailyPomodoros:       input.DailyPomodoros,
+		DailyFocusSeconds:    input.DailyFocusSeconds,
+		WeeklyPomodoros:      input.WeeklyPomodoros,
+		WeeklyFocusSeconds:   input.WeeklyFocusSeconds,
+		DailyMaxFocusSeconds: input.DailyMaxFocusSeconds,
+		UpdatedAt:            now,
+	}
+	if err := s.goalRepo.UpsertTx(ctx, tx, &goals); err != nil {
+		return nil, apperrors.Internal("failed to save focus goals")
+	}
+	if apiErr := s.saveTransition(ctx, tx, model.EventUpdateGoals, &before, state, now); apiErr != nil {
+		return nil, apiErr
+	}
+
+	view, apiErr := s.stateView(ctx, tx, state, now)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		return nil, apperrors.Internal("failed to commit transaction")
+	}
+
+	s.publish(state, view)
+	return &GoalsView{Goals: goals, Progress: *view.GoalProgress}, nil
+}
+
+// DeleteGoals stops tracking goals, recorded and published like UpdateGoals.
+func (s *PomodoroService) DeleteGoals(ctx context.Context, userID string) *apperrors.APIError {
+	now := time.Now().UTC()
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	state, apiErr := s.getStateForUpdate(ctx, tx, userID, now)
+	if apiErr != nil {
+		return apiErr
+	}
+	before := *state
+
+	if err := s.goalRepo.DeleteTx(ctx, tx, userID); err != nil {
+		if err == repository.ErrNotFound {
+			return apperrors.NotFound("goals_not_found", "focus goals not set")
+		}
+		return apperrors.Internal("failed to delete focus goals")
+	}
+	if apiErr := s.saveTransition(ctx, tx, model.EventUpdateGoals, &before, state, now); apiErr != nil {
+		return apiErr
+	}
+
+	view, apiErr := s.stateView(ctx, tx, state, now)
+	if apiErr != nil {
+		return apiErr
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		return apperrors.Internal("failed to commit transaction")
+	}
+
+	s.publish(state, view)
+	return nil
+}
+
+// stateView is toStateView with the user's goal progress and auto-stop
+// attached. It reads through tx, so callers build the view before committing.
+func (s *PomodoroService) stateView(ctx context.Context, tx *sql.Tx, state *model.PomodoroState, now time.Time) (StateView, *apperrors.APIError) {
+	view := s.toStateView(state, now)
+
+	if state.Status == model.StatusIdle {
+		lastEvent, err := s.repo.LastEventTypeTx(ctx, tx, state.UserID)
+		if err != nil {
+			return StateView{}, apperrors.Internal("failed to get last event")
+		}
+		view.AutoStopped = lastEvent == model.EventAutoStop
+	}
+
+	goals, err := s.goalRepo.GetTx(ctx, tx, state.UserID)
+	if err == repository.ErrNotFound {
+		return view, nil
+	}
+	if err != nil {
+		return StateView{}, apperrors.Internal("failed to get focus goals")
+	}
+	progress, apiErr := s.computeGoalProgress(ctx, tx, goals, now)
+	if apiErr != nil {
+		return StateView{}, apiErr
+	}
+	view.GoalProgress = progress
+	return view, nil
+}
+
+// computeGoalProgress counts completed focus sessions that started since
+// local midnight and since Monday's local midnight in the goals' time zone.
+func (s *PomodoroService) computeGoalProgress(ctx context.Context, tx *sql.Tx, goals *model.FocusGoals, now time.Time) (*GoalProgress, *apperrors.APIError) {
+	loc, apiErr := loadTimeZone(goals.TimeZone)
+	if apiErr != nil {
+		return nil, apperrors.Internal("stored goal time zone is invalid")
+	}
+
+	local := now.In(loc)
+	year, month, day := local.Date()
+	dayStart := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	weekStart := time.Date(year, month, day-(int(local.Weekday())+6)%7, 0, 0, 0, 0, loc)
+
+	today, err := s.repo.SumCompletedFocusTx(ctx, tx, goals.UserID, dayStart, time.Date(year, month, day+1, 0, 0, 0, 0, loc))
+	if err != nil {
+		return nil, apperrors.Internal("failed to sum focus sessions")
+	}
+	week, err := s.repo.SumCompletedFocusTx(ctx, tx, goals.UserID, weekStart, weekStart.AddDate(0, 0, 7))
+	if err != nil {
+		return nil, apperrors.Internal("failed to sum focus sessions")
+	}
+
+	progress := GoalProgress{
+		TimeZone:             goals.TimeZone,
+		Today:                newGoalPeriodProgress(dayStart, today, goals.DailyPomodoros, goals.DailyFocusSeconds),
+		ThisWeek:             newGoalPeriodProgress(weekStart, week, goals.WeeklyPomodoros, goals.WeeklyFocusSeconds),
+		DailyMaxFocusSeconds: goals.DailyMaxFocusSeconds,
+	}
+	if goals.DailyMaxFocusSeconds != nil {
+		progress.Overworked = today.Seconds > *goals.DailyMaxFocusSeconds
+	}
+	return &progress, nil
+}
+
+// newGoalPeriodProgress marks a period achieved when it has at least one
+// target and meets all of them.
+func newGoalPeriodProgress(start time.Time, total repository.FocusTotal, targetPomodoros, targetSeconds *int) GoalPeriodProgress {
+	progress := GoalPeriodProgress{
+		Start:              start.Format(dateLayout),
+		CompletedPomodoros: total.Sessions,
+		FocusSeconds:       total.Seconds,
+		TargetPomodoros:    targetPomodoros,
+		TargetFocusSeconds: targetSeconds,
+	}
+	progress.Achieved = (targetPomodoros != nil || targetSeconds != nil) &&
+		(targetPomodoros == nil || total.Sessions >= *targetPomodoros) &&
+		(targetSeconds == nil || total.Seconds >= *targetSeconds)
+	return progress
+}
+
+func validateGoals(input UpdateGoalsInput) *apperrors.APIError {
+	goals := []*int{
+		input.DailyPomodoros,
+		input.DailyFocusSeconds,
+		input.WeeklyPomodoros,
+		input.WeeklyFocusSeconds,
+		input.DailyMaxFocusSeconds,
+	}
+	tracked := false
+	for _, target := range goals {
+		if target == nil {
+			continue
+		}
+		if *target <= 0 {
+			return apperrors.BadRequest("invalid_goal", "goals must be positive numbers")
+		}
+		tracked = true
+	}
+	if !tracked {
+		return apperrors.BadRequest("invalid_goal", "at least one goal is required")
+	}
+	if input.DailyMaxFocusSeconds != nil && input.DailyFocusSeconds != nil &&
+		*input.DailyMaxFocusSeconds < *input.DailyFocusSeconds {
+		return apperrors.BadRequest("invalid_goal", "dailyMaxFocusSeconds must not be below dailyFocusSeconds")
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/model"
+	"pomodoro/backend/internal/repository"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 200
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+type HistoryQuery struct {
+	Limit  int
+	Cursor string
+	Mode   string
+	Status string
+	From   string
+	To     string
+}
+
+type HistoryPage struct {
+	Sessions   []model.PomodoroSession `json:"sessions"`
+	NextCursor *string                 `json:"nextCursor"`
+}
+
+func (s *PomodoroService) GetHistory(ctx context.Context, userID string, query HistoryQuery) (*HistoryPage, *apperrors.APIError) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+
+	if query.Mode != "" && !isValidModeKey(query.Mode) {
+		return nil, invalidModeError()
+	}
+	if query.Status != "" && !isValidSessionStatus(query.Status) {
+		return nil, apperrors.BadRequest("invalid_status", "status must be one of running, completed, cancelled")
+	}
+
+	filter := repository.SessionFilter{
+		Mode:   query.Mode,
+		Status: query.Status,
+		Limit:  limit + 1,
+	}
+	if query.From != "" {
+		from, err := time.Parse(time.RFC3339, query.From)
+		if err != nil {
+			return nil, apperrors.BadRequest("invalid_from", "from must be an RFC 3339 timestamp")
+		}
+		filter.From = &from
+	}
+	if query.To != "" {
+		to, err := time.Parse(time.RFC3339, query.To)
+		if err != nil {
+			return nil, apperrors.BadRequest("invalid_to", "to must be an RFC 3339 timestamp")
+		}
+		filter.To = &to
+	}
+	if query.Cursor != "" {
+		cursor, err := decodeHistoryCursor(query.Cursor)
+		if err != nil {
+			return nil, apperrors.BadRequest("invalid_cursor", "cursor is malformed")
+		}
+		filter.After = cursor
+	}
+
+	sessions, err := s.repo.ListSessions(ctx, userID, filter)
+	if err != nil {
+		return nil, apperrors.Internal("failed to get history")
+	}
+
+	page := HistoryPage{Sessions: sessions}
+	hasMore := len(sessions) > limit
+	if hasMore {
+		page.Sessions = sessions[:limit]
+	}
+
+	sessionIDs := make([]string, 0, len(page.Sessions))
+	for _, session := range page.Sessions {
+		sessionIDs = append(sessionIDs, session.ID)
+	}
+	pauses, err := s.repo.ListPauses(ctx, sessionIDs)
+	if err != nil {
+		return nil, apperrors.Internal("failed to get session pauses")
+	}
+	for i := range page.Sessions {
+		page.Sessions[i].Pauses = pauses[page.Sessions[i].ID]
+	}
+
+	if hasMore {
+		last := page.Sessions[limit-1]
+		nextCursor := encodeHistoryCursor(last.StartedAt, last.ID)
+		page.NextCursor = &nextCursor
+	}
+	return &page, nil
+}
+
+// History cursors are opaque to clients; they encode the sort key of the
+// last session on the page.
+func encodeHistoryCursor(startedAt time.Time, id string) string {
+	raw := startedAt.UTC().Format(time.RFC3339Nano) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeHistoryCursor(cursor string) (*repository.SessionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	startedAtRaw, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, errInvalidCursor
+	}
+	startedAt, err := time.Parse(time.RFC3339Nano, startedAtRaw)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	return &repository.SessionCursor{StartedAt: startedAt, ID: id}, nil
+}
@@ -0,0 +1,432 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/model"
+	"pomodoro/backend/internal/repository"
+)
+
+// maxImportRows caps one import so a single request cannot hold the write
+// transaction indefinitely.
+const maxImportRows = 50000
+
+const (
+	ImportRowAccepted = "accepted"
+	ImportRowRejected = "rejected"
+)
+
+// importRequiredColumns are the CSV columns without which no row could be
+// valid; the remaining export columns are optional and id, created_at and
+// updated_at are ignored.
+var importRequiredColumns = []string{"mode", "status", "started_at", "planned_duration_seconds"}
+
+type ImportRowResult struct {
+	Row       int        `json:"row"`
+	Status    string     `json:"status"`
+	SessionID string     `json:"sessionId,omitempty"`
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+	Code      string     `json:"code,omitempty"`
+	Message   string     `json:"message,omitempty"`
+}
+
+type ImportReport struct {
+	Accepted int               `json:"accepted"`
+	Rejected int               `json:"rejected"`
+	Rows     []ImportRowResult `json:"rows"`
+}
+
+// importRecord is one row of an import file with values still as written,
+// so CSV and JSON share the same validation.
+type importRecord struct {
+	TaskID                 string
+	Mode                   string
+	Status                 string
+	PlannedDurationSeconds string
+	ActualDurationSeconds  string
+	PauseCount             string
+	PausedSeconds          string
+	StartedAt              string
+	EndedAt                string
+	// parseErr is set when the row itself could not be decoded.
+	parseErr *apperrors.APIError
+}
+
+// jsonImportSession mirrors the session fields of the JSON export; numbers
+// are decoded as json.Number so they can share the CSV validation.
+type jsonImportSession struct {
+	TaskID                 *string     `json:"taskId"`
+	Mode                   string      `json:"mode"`
+	Status                 string      `json:"status"`
+	PlannedDurationSeconds json.Number `json:"plannedDurationSeconds"`
+	ActualDurationSeconds  json.Number `json:"actualDurationSeconds"`
+	PauseCount             json.Number `json:"pauseCount"`
+	PausedSeconds          json.Number `json:"pausedSeconds"`
+	StartedAt              string      `json:"startedAt"`
+	EndedAt                *string     `json:"endedAt"`
+}
+
+// ImportContentFormat validates format for import; only the tabular export
+// formats can be read back.
+func ImportContentFormat(format string) *apperrors.APIError {
+	if format != ExportFormatJSON && format != ExportFormatCSV {
+		return apperrors.BadRequest("invalid_format", "format must be one of json, csv")
+	}
+	return nil
+}
+
+// ImportSessions validates every row, skips rows whose start second is
+// already taken, and inserts the rest in one transaction. Rejected rows do
+// not fail the import; database errors roll back all of it.
+func (s *PomodoroService) ImportSessions(ctx context.Context, userID, format string, r io.Reader) (*ImportReport, *apperrors.APIError) {
+	if apiErr := ImportContentFormat(format); apiErr != nil {
+		return nil, apiErr
+	}
+
+	var records []importRecord
+	var apiErr *apperrors.APIError
+	if format == ExportFormatCSV {
+		records, apiErr = parseImportCSV(r)
+	} else {
+		records, apiErr = parseImportJSON(r)
+	}
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if len(records) == 0 {
+		return nil, apperrors.BadRequest("empty_import", "import contains no sessions")
+	}
+
+	modes, apiErr := s.focusModes(ctx, userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	report := ImportReport{Rows: make([]ImportRowResult, 0, len(records))}
+	seen := map[int64]bool{}
+	tasks := map[string]bool{}
+	for i, record := range records {
+		result := ImportRowResult{Row: i + 1}
+		session, rowErr := buildImportedSession(record, userID, modes, now)
+		if rowErr == nil && session.TaskID != nil {
+			rowErr = s.checkImportTask(ctx, tx, userID, *session.TaskID, tasks)
+		}
+		if rowErr == nil {
+			result.StartedAt = &session.StartedAt
+			rowErr = s.checkImportDuplicate(ctx, tx, userID, session.StartedAt, seen)
+		}
+		if rowErr != nil && rowErr.Status == http.StatusInternalServerError {
+			return nil, rowErr
+		}
+		if rowErr != nil {
+			result.Status = ImportRowRejected
+			result.Code = rowErr.Code
+			result.Message = rowErr.Message
+			report.Rejected++
+			report.Rows = append(report.Rows, result)
+			continue
+		}
+
+		if err := s.repo.InsertSessionTx(ctx, tx, session); err != nil {
+			return nil, apperrors.Internal("failed to import session")
+		}
+		if session.TaskID != nil && session.CountsAsFocus && session.Status == model.SessionStatusCompleted {
+			if err := s.taskRepo.IncrementCompletedTx(ctx, tx, *session.TaskID, now); err != nil {
+				return nil, apperrors.Internal("failed to update task progress")
+			}
+		}
+		result.Status = ImportRowAccepted
+		result.SessionID = session.ID
+		report.Accepted++
+		report.Rows = append(report.Rows, result)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, apperrors.Internal("failed to commit import")
+	}
+	return &report, nil
+}
+
+// checkImportDuplicate treats sessions starting within the same second as
+// one, which also matches second-precision CSV exports of stored sessions.
+func (s *PomodoroService) checkImportDuplicate(
+	ctx context.Context,
+	tx *sql.Tx,
+	userID string,
+	startedAt time.Time,
+	seen map[int64]bool,
+) *apperrors.APIError {
+	second := startedAt.Truncate(time.Second)
+	if seen[second.Unix()] {
+		return apperrors.BadRequest("duplicate", "another row in this import starts at the same time")
+	}
+	exists, err := s.repo.HasSessionStartedTx(ctx, tx, userID, second, second.Add(time.Second))
+	if err != nil {
+		return apperrors.Internal("failed to check for duplicates")
+	}
+	if exists {
+		return apperrors.BadRequest("duplicate", "a session starting at this time already exists")
+	}
+	seen[second.Unix()] = true
+	return nil
+}
+
+func (s *PomodoroService) checkImportTask(
+	ctx context.Context,
+	tx *sql.Tx,
+	userID, taskID string,
+	known map[string]bool,
+) *apperrors.APIError {
+	found, ok := known[taskID]
+	if !ok {
+		_, err := s.taskRepo.GetByIDTx(ctx, tx, userID, taskID)
+		if err != nil && err != repository.ErrNotFound {
+			return apperrors.Internal("failed to read task")
+		}
+		found = err == nil
+		known[taskID] = found
+	}
+	if !found {
+		return apperrors.NotFound("task_not_found", "taskId does not match one of your tasks")
+	}
+	return nil
+}
+
+// buildImportedSession applies the same rules as the schema's CHECK
+// constraints, plus those that keep history and stats meaningful: running
+// sessions and future start times are rejected. modes holds the modes the
+// user has, as returned by focusModes.
+func buildImportedSession(
+	record importRecord,
+	userID string,
+	modes map[string]bool,
+	now time.Time,
+) (*model.PomodoroSession, *apperrors.APIError) {
+	if record.parseErr != nil {
+		return nil, record.parseErr
+	}
+
+	if _, ok := modes[record.Mode]; !ok {
+		return nil, invalidModeError()
+	}
+	if record.Status != model.SessionStatusCompleted && record.Status != model.SessionStatusCancelled {
+		return nil, apperrors.BadRequest("invalid_status", "status must be one of completed, cancelled")
+	}
+
+	startedAt, err := time.Parse(time.RFC3339Nano, record.StartedAt)
+	if err != nil {
+		return nil, apperrors.BadRequest("invalid_started_at", "startedAt must be an RFC 3339 timestamp")
+	}
+	startedAt = startedAt.UTC()
+	if startedAt.After(now) {
+		return nil, apperrors.BadRequest("invalid_started_at", "startedAt is in the future")
+	}
+
+	planned, ok := parseImportInt(record.PlannedDurationSeconds)
+	if !ok || planned <= 0 {
+		return nil, apperrors.BadRequest("invalid_planned_duration", "plannedDurationSeconds must be a positive integer")
+	}
+
+	var endedAt *time.Time
+	if record.EndedAt != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, record.EndedAt)
+		if err != nil {
+			return nil, apperrors.BadRequest("invalid_ended_at", "endedAt must be an RFC 3339 timestamp")
+		}
+		parsed = parsed.UTC()
+		if parsed.Before(startedAt) {
+			return nil, apperrors.BadRequest("invalid_ended_at", "endedAt must not be before startedAt")
+		}
+		endedAt = &parsed
+	}
+
+	// A completed session without an explicit actual duration ran its plan.
+	actual := 0
+	if record.Status == model.SessionStatusCompleted {
+		actual = planned
+	}
+	if record.ActualDurationSeconds != "" {
+		actual, ok = parseImportInt(record.ActualDurationSeconds)
+		if !ok || actual < 0 || actual > planned {
+			return nil, apperrors.BadRequest("invalid_actual_duration", "actualDurationSeconds must be between 0 and plannedDurationSeconds")
+		}
+	}
+
+	pauseCount, pausedSeconds := 0, 0
+	if record.PauseCount != "" {
+		if pauseCount, ok = parseImportInt(record.PauseCount); !ok || pauseCount < 0 {
+			return nil, apperrors.BadRequest("invalid_pause_count", "pauseCount must be a non-negative integer")
+		}
+	}
+	if record.PausedSeconds != "" {
+		if pausedSeconds, ok = parseImportInt(record.PausedSeconds); !ok || pausedSeconds < 0 {
+			return nil, apperrors.BadRequest("invalid_paused_seconds", "pausedSeconds must be a non-negative integer")
+		}
+	}
+
+	if endedAt == nil {
+		ended := startedAt.Add(time.Duration(actual+pausedSeconds) * time.Second)
+		endedAt = &ended
+	}
+
+	session := &model.PomodoroSession{
+		ID:                     uuid.NewString(),
+		UserID:                 userID,
+		Mode:                   record.Mode,
+		CountsAsFocus:          modes[record.Mode],
+		PlannedDurationSeconds: planned,
+		ActualDurationSeconds:  actual,
+		StartedAt:              startedAt,
+		EndedAt:                endedAt,
+		Status:                 record.Status,
+		PauseCount:             pauseCount,
+		PausedSeconds:          pausedSeconds,
+		CreatedAt:              now,
+		UpdatedAt:              now,
+	}
+	if record.TaskID != "" {
+		taskID := record.TaskID
+		session.TaskID = &taskID
+	}
+	return session, nil
+}
+
+func parseImportCSV(r io.Reader) ([]importRecord, *apperrors.APIError) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperrors.BadRequest("invalid_csv", "csv header could not be read")
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range importRequiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, apperrors.BadRequest("invalid_csv", "csv is missing the "+name+" column")
+		}
+	}
+
+	records := []importRecord{}
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if len(records) == maxImportRows {
+			return nil, tooManyImportRows()
+		}
+		var parseErr *csv.ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			return nil, apperrors.BadRequest("invalid_csv", "csv body could not be read")
+		}
+		if err != nil {
+			records = append(records, importRecord{
+				parseErr: apperrors.BadRequest("invalid_row", parseErr.Err.Error()),
+			})
+			continue
+		}
+
+		value := func(name string) string {
+			index, ok := columns[name]
+			if !ok || index >= len(fields) {
+				return ""
+			}
+			return strings.TrimSpace(fields[index])
+		}
+		records = append(records, importRecord{
+			TaskID:                 value("task_id"),
+			Mode:                   value("mode"),
+			Status:                 value("status"),
+			PlannedDurationSeconds: value("planned_duration_seconds"),
+			ActualDurationSeconds:  value("actual_duration_seconds"),
+			PauseCount:             value("pause_count"),
+			PausedSeconds:          value("paused_seconds"),
+			StartedAt:              value("started_at"),
+			EndedAt:                value("ended_at"),
+		})
+	}
+	return records, nil
+}
+
+// parseImportJSON reads the export document, {"sessions": [...]}. Rows
+// are decoded one by one so a malformed row is reported rather than failing
+// the whole import.
+func parseImportJSON(r io.Reader) ([]importRecord, *apperrors.APIError) {
+	var document struct {
+		Sessions []json.RawMessage `json:"sessions"`
+	}
+	if err := json.NewDecoder(r).Decode(&document); err != nil {
+		return nil, apperrors.BadRequest("invalid_json", "import must be a JSON object with a sessions array")
+	}
+	if len(document.Sessions) > maxImportRows {
+		return nil, tooManyImportRows()
+	}
+
+	records := make([]importRecord, 0, len(document.Sessions))
+	for _, raw := range document.Sessions {
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		var item jsonImportSession
+		if err := decoder.Decode(&item); err != nil {
+			records = append(records, importRecord{
+				parseErr: apperrors.BadRequest("invalid_row", "session is not a valid object"),
+			})
+			continue
+		}
+
+		record := importRecord{
+			Mode:                   item.Mode,
+			Status:                 item.Status,
+			PlannedDurationSeconds: item.PlannedDurationSeconds.String(),
+			ActualDurationSeconds:  item.ActualDurationSeconds.String(),
+			PauseCount:             item.PauseCount.String(),
+			PausedSeconds:          item.PausedSeconds.String(),
+			StartedAt:              item.StartedAt,
+		}
+		if item.TaskID != nil {
+			record.TaskID = *item.TaskID
+		}
+		if item.EndedAt != nil {
+			record.EndedAt = *item.EndedAt
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func tooManyImportRows() *apperrors.APIError {
+	return apperrors.BadRequest("too_many_rows", fmt.Sprintf("import is limited to %d sessions", maxImportRows))
+}
+
+func parseImportInt(raw string) (int, bool) {
+	value, err := strconv.Atoi(raw)
+	return value, err == nil
+}
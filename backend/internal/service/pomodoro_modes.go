@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/model"
+	"pomodoro/backend/internal/repository"
+)
+
+var modeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,29}$`)
+
+// builtInModes are the modes every timer has, in the order they are listed.
+var builtInModes = []string{model.ModeFocus, model.ModeShortBreak, model.ModeLongBreak}
+
+func isBuiltInMode(mode string) bool {
+	return mode == model.ModeFocus || mode == model.ModeShortBreak || mode == model.ModeLongBreak
+}
+
+// isValidModeKey only checks the shape of a mode, for filters that may name
+// a custom mode deleted since.
+func isValidModeKey(mode string) bool {
+	return isBuiltInMode(mode) || modeKeyPattern.MatchString(mode)
+}
+
+func invalidModeError() *apperrors.APIError {
+	return apperrors.BadRequest("invalid_mode", "mode must be focus, short_break, long_break or one of your custom modes")
+}
+
+// findMode returns the custom mode of userID with key mode, or nil when the
+// user has none.
+func (s *PomodoroService) findMode(ctx context.Context, tx *sql.Tx, userID, mode string) (*model.Mode, *apperrors.APIError) {
+	custom, err := s.modeRepo.GetByKeyTx(ctx, tx, userID, mode)
+	if err == repository.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperrors.Internal("failed to read mode")
+	}
+	return custom, nil
+}
+
+// checkMode accepts the built-in modes and the custom modes of userID.
+func (s *PomodoroService) checkMode(ctx context.Context, tx *sql.Tx, userID, mode string) *apperrors.APIError {
+	if isBuiltInMode(mode) {
+		return nil
+	}
+	if !modeKeyPattern.MatchString(mode) {
+		return invalidModeError()
+	}
+	custom, apiErr := s.findMode(ctx, tx, userID, mode)
+	if apiErr != nil {
+		return apiErr
+	}
+	if custom == nil {
+		return invalidModeError()
+	}
+	return nil
+}
+
+func (s *PomodoroService) countsAsFocus(ctx context.Context, tx *sql.Tx, userID, mode string) (bool, *apperrors.APIError) {
+	switch mode {
+	case model.ModeFocus:
+		return true, nil
+	case model.ModeShortBreak, model.ModeLongBreak:
+		return false, nil
+	}
+	custom, apiErr := s.findMode(ctx, tx, userID, mode)
+	if apiErr != nil {
+		return false, apiErr
+	}
+	return custom != nil && custom.CountsAsFocus, nil
+}
+
+// durationForMode takes built-in durations from the timer settings and
+// custom ones from the mode. A custom mode deleted while the timer was on
+// it falls back to the focus duration.
+func (s *PomodoroService) durationForMode(ctx context.Context, tx *sql.Tx, state *model.PomodoroState) (int, *apperrors.APIError) {
+	switch state.Mode {
+	case model.ModeFocus:
+		return state.FocusDurationSeconds, nil
+	case model.ModeShortBreak:
+		return state.ShortBreakDurationSeconds, nil
+	case model.ModeLongBreak:
+		return state.LongBreakDurationSeconds, nil
+	}
+	custom, apiErr := s.findMode(ctx, tx, state.UserID, state.Mode)
+	if apiErr != nil {
+		return 0, apiErr
+	}
+	if custom == nil {
+		return state.FocusDurationSeconds, nil
+	}
+	return custom.DurationSeconds, nil
+}
+
+// focusModes maps every mode of userID, built-in or custom, to whether it
+// counts as focus time.
+func (s *PomodoroService) focusModes(ctx context.Context, userID string) (map[string]bool, *apperrors.APIError) {
+	custom, err := s.modeRepo.List(ctx, userID)
+	if err != nil {
+		return nil, apperrors.Internal("failed to list modes")
+	}
+	modes := map[string]bool{
+		model.ModeFocus:      true,
+		model.ModeShortBreak: false,
+		model.ModeLongBreak:  false,
+	}
+	for _, mode := range custom {
+		modes[mode.Key] = mode.CountsAsFocus
+	}
+	return modes, nil
+}
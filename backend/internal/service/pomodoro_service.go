@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"database/sql"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,23 +13,44 @@ import (
 	"pomodoro/backend/internal/repository"
 )
 
+// maxAutoAdvancePhases bounds how many phases one settlement completes when
+// a timer ran out long ago, as after downtime. Durations may be as short as
+// a second, so replaying all of them could mean any number of sessions; the
+// timer stops instead with an auto_stop event.
+const maxAutoAdvancePhases = 8
+
 type PomodoroService struct {
-	repo *repository.PomodoroRepository
+	repo        repository.PomodoroStore
+	taskRepo    repository.TaskStore
+	deviceRepo  repository.DeviceStore
+	goalRepo    repository.GoalStore
+	modeRepo    repository.ModeStore
+	webhookRepo repository.WebhookStore
+	hub         *StateHub
+	finalizer   *SessionFinalizer
 }
 
 type StateView struct {
-	UserID                    string     `json:"userId"`
-	Mode                      string     `json:"mode"`
-	Status                    string     `json:"status"`
-	RemainingSeconds          int        `json:"remainingSeconds"`
-	FocusDurationSeconds      int        `json:"focusDurationSeconds"`
-	ShortBreakDurationSeconds int        `json:"shortBreakDurationSeconds"`
-	LongBreakDurationSeconds  int        `json:"longBreakDurationSeconds"`
-	StartedAt                 *time.Time `json:"startedAt,omitempty"`
-	SessionID                 *string    `json:"sessionId,omitempty"`
-	Version                   int        `json:"version"`
-	UpdatedAt                 time.Time  `json:"updatedAt"`
-	ServerTime                time.Time  `json:"serverTime"`
+	UserID                    string        `json:"userId"`
+	Mode                      string        `json:"mode"`
+	Status                    string        `json:"status"`
+	RemainingSeconds          int           `json:"remainingSeconds"`
+	FocusDurationSeconds      int           `json:"focusDurationSeconds"`
+	ShortBreakDurationSeconds int           `json:"shortBreakDurationSeconds"`
+	LongBreakDurationSeconds  int           `json:"longBreakDurationSeconds"`
+	CompletedFocusCount       int           `json:"completedFocusCount"`
+	LongBreakInterval         int           `json:"longBreakInterval"`
+	AutoStartBreaks           bool          `json:"autoStartBreaks"`
+	AutoStartFocus            bool          `json:"autoStartFocus"`
+	CurrentTaskID             *string       `json:"currentTaskId,omitempty"`
+	StartedAt                 *time.Time    `json:"startedAt,omitempty"`
+	SessionID                 *string       `json:"sessionId,omitempty"`
+	Version                   int           `json:"version"`
+	UpdatedAt                 time.Time     `json:"updatedAt"`
+	UpdatedByDeviceID         *string       `json:"updatedByDeviceId,omitempty"`
+	AutoStopped               bool          `json:"autoStopped,omitempty"`
+	ServerTime                time.Time     `json:"serverTime"`
+	GoalProgress              *GoalProgress `json:"goalProgress,omitempty"`
 }
 
 type UpdateSettingsInput struct {
@@ -36,10 +58,31 @@ type UpdateSettingsInput struct {
 	FocusDurationSeconds      int
 	ShortBreakDurationSeconds int
 	LongBreakDurationSeconds  int
+	LongBreakInterval         *int
+	AutoStartBreaks           *bool
+	AutoStartFocus            *bool
 }
 
-func NewPomodoroService(repo *repository.PomodoroRepository) *PomodoroService {
-	return &PomodoroService{repo: repo}
+func NewPomodoroService(
+	repo repository.PomodoroStore,
+	taskRepo repository.TaskStore,
+	deviceRepo repository.DeviceStore,
+	goalRepo repository.GoalStore,
+	modeRepo repository.ModeStore,
+	webhookRepo repository.WebhookStore,
+	hub *StateHub,
+	finalizer *SessionFinalizer,
+) *PomodoroService {
+	return &PomodoroService{
+		repo:        repo,
+		taskRepo:    taskRepo,
+		deviceRepo:  deviceRepo,
+		goalRepo:    goalRepo,
+		modeRepo:    modeRepo,
+		webhookRepo: webhookRepo,
+		hub:         hub,
+		finalizer:   finalizer,
+	}
 }
 
 func (s *PomodoroService) GetState(ctx context.Context, userID string) (*StateView, *apperrors.APIError) {
@@ -58,18 +101,41 @@ func (s *PomodoroService) GetState(ctx context.Context, userID string) (*StateVi
 		return nil, apperrors.Internal("failed to get state")
 	}
 
+	loadedVersion := state.Version
 	if err := s.normalizeCompletedSession(ctx, tx, state, now); err != nil {
 		return nil, err
 	}
 
+	view, apiErr := s.stateView(ctx, tx, state, now)
+	if apiErr != nil {
+		return nil, apiErr
+	}
 	if commitErr := tx.Commit(); commitErr != nil {
 		return nil, apperrors.Internal("failed to commit transaction")
 	}
 
-	view := s.toStateView(state, now)
+	if state.Version != loadedVersion {
+		s.publish(state, view)
+	}
 	return &view, nil
 }
 
+// publish sends a committed state to the user's subscribers and hands its
+// deadline to the finalizer.
+func (s *PomodoroService) publish(state *model.PomodoroState, view StateView) {
+	s.hub.Publish(state.UserID, view)
+	s.finalizer.Schedule(state.UserID, runningDeadline(state))
+}
+
+func (s *PomodoroService) Subscribe(userID string) (<-chan StateView, func()) {
+	return s.hub.Subscribe(userID)
+}
+
+// CloseStreams ends every subscription, see StateHub.Close.
+func (s *PomodoroService) CloseStreams() {
+	s.hub.Close()
+}
+
 func (s *PomodoroService) Start(ctx context.Context, userID string, baseVersion int) (*StateView, *apperrors.APIError) {
 	now := time.Now().UTC()
 	tx, err := s.repo.BeginTx(ctx)
@@ -82,54 +148,36 @@ func (s *PomodoroService) Start(ctx context.Context, userID string, baseVersion
 	if apiErr != nil {
 		return nil, apiErr
 	}
+	before := *state
 
-	if apiErr := s.ensureVersion(baseVersion, state, now); apiErr != nil {
+	if apiErr := s.ensureVersion(ctx, tx, baseVersion, state, now); apiErr != nil {
 		return nil, apiErr
 	}
 
-	if state.Status == model.StatusRunning {
-		view := s.toStateView(state, now)
-		return &view, nil
-	}
-
-	if state.Status == model.StatusIdle {
-		state.RemainingSeconds = s.durationForMode(state)
+	changed, apiErr := s.applyStart(ctx, tx, stateSessions{s}, state, now)
+	if apiErr != nil {
+		return nil, apiErr
 	}
-
-	if state.SessionID == nil {
-		sessionID := uuid.NewString()
-		state.SessionID = &sessionID
-
-		session := model.PomodoroSession{
-			ID:                     sessionID,
-			UserID:                 userID,
-			Mode:                   state.Mode,
-			PlannedDurationSeconds: state.RemainingSeconds,
-			ActualDurationSeconds:  0,
-			StartedAt:              now,
-			Status:                 "running",
-			CreatedAt:              now,
-			UpdatedAt:              now,
-		}
-		if err := s.repo.InsertSessionTx(ctx, tx, &session); err != nil {
-			return nil, apperrors.Internal("failed to create focus session")
+	if !changed {
+		view, apiErr := s.stateView(ctx, tx, state, now)
+		if apiErr != nil {
+			return nil, apiErr
 		}
+		return &view, nil
 	}
-
-	state.Status = model.StatusRunning
-	state.StartedAt = &now
-	state.UpdatedAt = now
-	state.Version++
-
-	if err := s.repo.UpdateStateTx(ctx, tx, state); err != nil {
-		return nil, apperrors.Internal("failed to update state")
+	if apiErr := s.saveTransition(ctx, tx, model.EventStart, &before, state, now); apiErr != nil {
+		return nil, apiErr
 	}
 
+	view, apiErr := s.stateView(ctx, tx, state, now)
+	if apiErr != nil {
+		return nil, apiErr
+	}
 	if commitErr := tx.Commit(); commitErr != nil {
 		return nil, apperrors.Internal("failed to commit transaction")
 	}
 
-	view := s.toStateView(state, now)
+	s.publish(state, view)
 	return &view, nil
 }
 
@@ -145,31 +193,36 @@ func (s *PomodoroService) Pause(ctx context.Context, userID string, baseVersion
 	if apiErr != nil {
 		return nil, apiErr
 	}
+	before := *state
 
-	if apiErr := s.ensureVersion(baseVersion, state, now); apiErr != nil {
+	if apiErr := s.ensureVersion(ctx, tx, baseVersion, state, now); apiErr != nil {
 		return nil, apiErr
 	}
 
-	if state.Status != model.StatusRunning {
-		view := s.toStateView(state, now)
+	changed, apiErr := s.applyPause(ctx, tx, stateSessions{s}, state, now)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if !changed {
+		view, apiErr := s.stateView(ctx, tx, state, now)
+		if apiErr != nil {
+			return nil, apiErr
+		}
 		return &view, nil
 	}
-
-	state.RemainingSeconds = s.currentRemainingSeconds(state, now)
-	state.Status = model.StatusPaused
-	state.StartedAt = nil
-	state.UpdatedAt = now
-	state.Version++
-
-	if err := s.repo.UpdateStateTx(ctx, tx, state); err != nil {
-		return nil, apperrors.Internal("failed to update state")
+	if apiErr := s.saveTransition(ctx, tx, model.EventPause, &before, state, now); apiErr != nil {
+		return nil, apiErr
 	}
 
+	view, apiErr := s.stateView(ctx, tx, state, now)
+	if apiErr != nil {
+		return nil, apiErr
+	}
 	if commitErr := tx.Commit(); commitErr != nil {
 		return nil, apperrors.Internal("failed to commit transaction")
 	}
 
-	view := s.toStateView(state, now)
+	s.publish(state, view)
 	return &view, nil
 }
 
@@ -185,42 +238,32 @@ func (s *PomodoroService) Reset(ctx context.Context, userID string, baseVersion
 	if apiErr != nil {
 		return nil, apiErr
 	}
+	before := *state
 
-	if apiErr := s.ensureVersion(baseVersion, state, now); apiErr != nil {
+	if apiErr := s.ensureVersion(ctx, tx, baseVersion, state, now); apiErr != nil {
 		return nil, apiErr
 	}
 
-	if state.SessionID != nil {
-		remaining := s.currentRemainingSeconds(state, now)
-		if cancelErr := s.finishSession(ctx, tx, *state.SessionID, remaining, false, now); cancelErr != nil {
-			return nil, cancelErr
-		}
+	if apiErr := s.applyReset(ctx, tx, stateSessions{s}, state, now); apiErr != nil {
+		return nil, apiErr
 	}
-
-	state.Status = model.StatusIdle
-	state.StartedAt = nil
-	state.SessionID = nil
-	state.RemainingSeconds = s.durationForMode(state)
-	state.UpdatedAt = now
-	state.Version++
-
-	if err := s.repo.UpdateStateTx(ctx, tx, state); err != nil {
-		return nil, apperrors.Internal("failed to update state")
+	if apiErr := s.saveTransition(ctx, tx, model.EventReset, &before, state, now); apiErr != nil {
+		return nil, apiErr
 	}
 
+	view, apiErr := s.stateView(ctx, tx, state, now)
+	if apiErr != nil {
+		return nil, apiErr
+	}
 	if commitErr := tx.Commit(); commitErr != nil {
 		return nil, apperrors.Internal("failed to commit transaction")
 	}
 
-	view := s.toStateView(state, now)
+	s.publish(state, view)
 	return &view, nil
 }
 
 func (s *PomodoroService) SwitchMode(ctx context.Context, userID, mode string, baseVersion int) (*StateView, *apperrors.APIError) {
-	if !isValidMode(mode) {
-		return nil, apperrors.BadRequest("invalid_mode", "mode must be one of focus, short_break, long_break")
-	}
-
 	now := time.Now().UTC()
 	tx, err := s.repo.BeginTx(ctx)
 	if err != nil {
@@ -228,45 +271,47 @@ func (s *PomodoroService) SwitchMode(ctx context.Context, userID, mode string, b
 	}
 	defer tx.Rollback()
 
+	if apiErr := s.checkMode(ctx, tx, userID, mode); apiErr != nil {
+		return nil, apiErr
+	}
+
 	state, apiErr := s.getStateForUpdate(ctx, tx, userID, now)
 	if apiErr != nil {
 		return nil, apiErr
 	}
+	before := *state
 
-	if apiErr := s.ensureVersion(baseVersion, state, now); apiErr != nil {
+	if apiErr := s.ensureVersion(ctx, tx, baseVersion, state, now); apiErr != nil {
 		return nil, apiErr
 	}
 
-	if state.SessionID != nil {
-		remaining := s.currentRemainingSeconds(state, now)
-		if cancelErr := s.finishSession(ctx, tx, *state.SessionID, remaining, false, now); cancelErr != nil {
-			return nil, cancelErr
-		}
+	if apiErr := s.applySwitchMode(ctx, tx, stateSessions{s}, state, mode, now); apiErr != nil {
+		return nil, apiErr
 	}
-
-	state.Mode = mode
-	state.Status = model.StatusIdle
-	state.StartedAt = nil
-	state.SessionID = nil
-	state.RemainingSeconds = s.durationForMode(state)
-	state.UpdatedAt = now
-	state.Version++
-
-	if err := s.repo.UpdateStateTx(ctx, tx, state); err != nil {
-		return nil, apperrors.Internal("failed to update state")
+	if apiErr := s.saveTransition(ctx, tx, model.EventSwitchMode, &before, state, now); apiErr != nil {
+		return nil, apiErr
 	}
 
+	view, apiErr := s.stateView(ctx, tx, state, now)
+	if apiErr != nil {
+		return nil, apiErr
+	}
 	if commitErr := tx.Commit(); commitErr != nil {
 		return nil, apperrors.Internal("failed to commit transaction")
 	}
 
-	view := s.toStateView(state, now)
+	s.publish(state, view)
 	return &view, nil
 }
 
 func (s *PomodoroService) UpdateSettings(ctx context.Context, userID string, input UpdateSettingsInput) (*StateView, *apperrors.APIError) {
-	if input.FocusDurationSeconds <= 0 || input.ShortBreakDurationSeconds <= 0 || input.LongBreakDurationSeconds <= 0 {
-		return nil, apperrors.BadRequest("invalid_duration", "all durations must be positive seconds")
+	if apiErr := validateSettings(
+		input.FocusDurationSeconds,
+		input.ShortBreakDurationSeconds,
+		input.LongBreakDurationSeconds,
+		input.LongBreakInterval,
+	); apiErr != nil {
+		return nil, apiErr
 	}
 
 	now := time.Now().UTC()
@@ -280,43 +325,348 @@ func (s *PomodoroService) UpdateSettings(ctx context.Context, userID string, inp
 	if apiErr != nil {
 		return nil, apiErr
 	}
+	before := *state
 
-	if apiErr := s.ensureVersion(input.BaseVersion, state, now); apiErr != nil {
+	if apiErr := s.ensureVersion(ctx, tx, input.BaseVersion, state, now); apiErr != nil {
 		return nil, apiErr
 	}
 
 	state.FocusDurationSeconds = input.FocusDurationSeconds
 	state.ShortBreakDurationSeconds = input.ShortBreakDurationSeconds
 	state.LongBreakDurationSeconds = input.LongBreakDurationSeconds
+	if input.LongBreakInterval != nil {
+		state.LongBreakInterval = *input.LongBreakInterval
+	}
+	if input.AutoStartBreaks != nil {
+		state.AutoStartBreaks = *input.AutoStartBreaks
+	}
+	if input.AutoStartFocus != nil {
+		state.AutoStartFocus = *input.AutoStartFocus
+	}
 
 	if state.Status != model.StatusRunning {
-		state.RemainingSeconds = s.durationForMode(state)
+		state.RemainingSeconds, apiErr = s.durationForMode(ctx, tx, state)
+		if apiErr != nil {
+			return nil, apiErr
+		}
 	}
 
-	state.UpdatedAt = now
-	state.Version++
+	if apiErr := s.saveTransition(ctx, tx, model.EventUpdateSettings, &before, state, now); apiErr != nil {
+		return nil, apiErr
+	}
 
-	if err := s.repo.UpdateStateTx(ctx, tx, state); err != nil {
-		return nil, apperrors.Internal("failed to update state")
+	view, apiErr := s.stateView(ctx, tx, state, now)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		return nil, apperrors.Internal("failed to commit transaction")
 	}
 
+	s.publish(state, view)
+	return &view, nil
+}
+
+func (s *PomodoroService) SetCurrentTask(ctx context.Context, userID string, taskID *string, baseVersion int) (*StateView, *apperrors.APIError) {
+	now := time.Now().UTC()
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	state, apiErr := s.getStateForUpdate(ctx, tx, userID, now)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	before := *state
+
+	if apiErr := s.ensureVersion(ctx, tx, baseVersion, state, now); apiErr != nil {
+		return nil, apiErr
+	}
+
+	if taskID != nil {
+		_, err := s.taskRepo.GetByIDTx(ctx, tx, userID, *taskID)
+		if err == repository.ErrNotFound {
+			return nil, apperrors.NotFound("task_not_found", "task not found")
+		}
+		if err != nil {
+			return nil, apperrors.Internal("failed to get task")
+		}
+	}
+
+	// The running session keeps the task it was started with; the new task
+	// applies from the next session onwards.
+	state.CurrentTaskID = taskID
+	if apiErr := s.saveTransition(ctx, tx, model.EventSetTask, &before, state, now); apiErr != nil {
+		return nil, apiErr
+	}
+
+	view, apiErr := s.stateView(ctx, tx, state, now)
+	if apiErr != nil {
+		return nil, apiErr
+	}
 	if commitErr := tx.Commit(); commitErr != nil {
 		return nil, apperrors.Internal("failed to commit transaction")
 	}
 
-	view := s.toStateView(state, now)
+	s.publish(state, view)
 	return &view, nil
 }
 
-func (s *PomodoroService) GetHistory(ctx context.Context, userID string, limit int) ([]model.PomodoroSession, *apperrors.APIError) {
-	if limit <= 0 || limit > 200 {
-		limit = 50
+// DeleteTask removes a task under the state lock. When it is the timer's
+// current task, the state drops it as a set_task transition, so clients
+// holding the old version see a conflict and subscribers get the change.
+func (s *PomodoroService) DeleteTask(ctx context.Context, userID, taskID string) *apperrors.APIError {
+	now := time.Now().UTC()
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	state, err := s.repo.GetStateTx(ctx, tx, userID)
+	if err == repository.ErrNotFound {
+		return apperrors.NotFound("state_not_found", "pomodoro state not found")
+	}
+	if err != nil {
+		return apperrors.Internal("failed to get state")
+	}
+	loadedVersion := state.Version
+	if apiErr := s.normalizeCompletedSession(ctx, tx, state, now); apiErr != nil {
+		return apiErr
+	}
+	before := *state
+
+	err = s.taskRepo.DeleteTx(ctx, tx, userID, taskID)
+	if err == repository.ErrNotFound {
+		return apperrors.NotFound("task_not_found", "task not found")
+	}
+	if err != nil {
+		return apperrors.Internal("failed to delete task")
+	}
+
+	if state.CurrentTaskID != nil && *state.CurrentTaskID == taskID {
+		state.CurrentTaskID = nil
+		if apiErr := s.saveTransition(ctx, tx, model.EventSetTask, &before, state, now); apiErr != nil {
+			return apiErr
+		}
+	}
+
+	view, apiErr := s.stateView(ctx, tx, state, now)
+	if apiErr != nil {
+		return apiErr
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		return apperrors.Internal("failed to commit transaction")
+	}
+
+	if state.Version != loadedVersion {
+		s.publish(state, view)
+	}
+	return nil
+}
+
+// DeleteMode removes a custom mode unless the timer is on it. The state row
+// stays locked until the mode is gone, so a concurrent switch to the mode
+// either lands first and blocks the delete or finds the mode missing.
+func (s *PomodoroService) DeleteMode(ctx context.Context, userID string, mode *model.Mode) *apperrors.APIError {
+	now := time.Now().UTC()
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	state, err := s.repo.GetStateTx(ctx, tx, userID)
+	if err == repository.ErrNotFound {
+		return apperrors.NotFound("state_not_found", "pomodoro state not found")
 	}
-	sessions, err := s.repo.ListSessions(ctx, userID, limit)
 	if err != nil {
-		return nil, apperrors.Internal("failed to get history")
+		return apperrors.Internal("failed to get state")
+	}
+	loadedVersion := state.Version
+	if apiErr := s.normalizeCompletedSession(ctx, tx, state, now); apiErr != nil {
+		return apiErr
+	}
+	if state.Mode == mode.Key {
+		return apperrors.Conflict("mode_in_use", "switch the timer to another mode before deleting this one", nil)
+	}
+
+	err = s.modeRepo.DeleteTx(ctx, tx, userID, mode.ID)
+	if err == repository.ErrNotFound {
+		return apperrors.NotFound("mode_not_found", "mode not found")
+	}
+	if err != nil {
+		return apperrors.Internal("failed to delete mode")
+	}
+
+	view, apiErr := s.stateView(ctx, tx, state, now)
+	if apiErr != nil {
+		return apiErr
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		return apperrors.Internal("failed to commit transaction")
+	}
+
+	if state.Version != loadedVersion {
+		s.publish(state, view)
+	}
+	return nil
+}
+
+// sessionTracker records the sessions behind a timer while the apply
+// helpers and advanceExpired drive its transitions. A personal timer keeps
+// its one session on the state; a room timer keeps one per member.
+type sessionTracker interface {
+	// start begins sessions for the current phase, or resumes paused ones.
+	start(ctx context.Context, tx *sql.Tx, state *model.PomodoroState, at time.Time) *apperrors.APIError
+	pause(ctx context.Context, tx *sql.Tx, state *model.PomodoroState, at time.Time) *apperrors.APIError
+	// finish ends the current sessions, if any, with remainingSeconds left.
+	finish(ctx context.Context, tx *sql.Tx, state *model.PomodoroState, remainingSeconds int, completed bool, at time.Time) *apperrors.APIError
+}
+
+// stateSessions tracks the session stored on a personal state.
+type stateSessions struct {
+	s *PomodoroService
+}
+
+func (t stateSessions) start(ctx context.Context, tx *sql.Tx, state *model.PomodoroState, at time.Time) *apperrors.APIError {
+	if state.SessionID == nil {
+		return t.s.createSession(ctx, tx, state, at)
+	}
+	return t.s.resumeSession(ctx, tx, *state.SessionID, at)
+}
+
+func (t stateSessions) pause(ctx context.Context, tx *sql.Tx, state *model.PomodoroState, at time.Time) *apperrors.APIError {
+	if state.SessionID == nil {
+		return nil
+	}
+	return t.s.pauseSession(ctx, tx, *state.SessionID, at)
+}
+
+func (t stateSessions) finish(
+	ctx context.Context,
+	tx *sql.Tx,
+	state *model.PomodoroState,
+	remainingSeconds int,
+	completed bool,
+	at time.Time,
+) *apperrors.APIError {
+	if state.SessionID == nil {
+		return nil
+	}
+	if apiErr := t.s.finishSession(ctx, tx, *state.SessionID, remainingSeconds, completed, at); apiErr != nil {
+		return apiErr
+	}
+	state.SessionID = nil
+	return nil
+}
+
+// The apply helpers perform a transition as of at, which is now for live
+// requests and the command time for replayed offline commands. The caller
+// records the transition; applyStart and applyPause report false when the
+// state already is what was asked for.
+
+func (s *PomodoroService) applyStart(
+	ctx context.Context,
+	tx *sql.Tx,
+	sessions sessionTracker,
+	state *model.PomodoroState,
+	at time.Time,
+) (bool, *apperrors.APIError) {
+	if state.Status == model.StatusRunning {
+		return false, nil
+	}
+
+	if state.Status == model.StatusIdle {
+		duration, apiErr := s.durationForMode(ctx, tx, state)
+		if apiErr != nil {
+			return false, apiErr
+		}
+		state.RemainingSeconds = duration
+	}
+	if apiErr := sessions.start(ctx, tx, state, at); apiErr != nil {
+		return false, apiErr
+	}
+
+	state.Status = model.StatusRunning
+	state.StartedAt = &at
+	return true, nil
+}
+
+func (s *PomodoroService) applyPause(
+	ctx context.Context,
+	tx *sql.Tx,
+	sessions sessionTracker,
+	state *model.PomodoroState,
+	at time.Time,
+) (bool, *apperrors.APIError) {
+	if state.Status != model.StatusRunning {
+		return false, nil
+	}
+
+	if apiErr := sessions.pause(ctx, tx, state, at); apiErr != nil {
+		return false, apiErr
+	}
+
+	state.RemainingSeconds = s.currentRemainingSeconds(state, at)
+	state.Status = model.StatusPaused
+	state.StartedAt = nil
+	return true, nil
+}
+
+func (s *PomodoroService) applyReset(
+	ctx context.Context,
+	tx *sql.Tx,
+	sessions sessionTracker,
+	state *model.PomodoroState,
+	at time.Time,
+) *apperrors.APIError {
+	if apiErr := s.cancelCurrentSession(ctx, tx, sessions, state, at); apiErr != nil {
+		return apiErr
+	}
+	state.Status = model.StatusIdle
+	state.StartedAt = nil
+	duration, apiErr := s.durationForMode(ctx, tx, state)
+	if apiErr != nil {
+		return apiErr
+	}
+	state.RemainingSeconds = duration
+	return nil
+}
+
+func (s *PomodoroService) applySwitchMode(
+	ctx context.Context,
+	tx *sql.Tx,
+	sessions sessionTracker,
+	state *model.PomodoroState,
+	mode string,
+	at time.Time,
+) *apperrors.APIError {
+	if apiErr := s.cancelCurrentSession(ctx, tx, sessions, state, at); apiErr != nil {
+		return apiErr
 	}
-	return sessions, nil
+	state.Mode = mode
+	state.Status = model.StatusIdle
+	state.StartedAt = nil
+	duration, apiErr := s.durationForMode(ctx, tx, state)
+	if apiErr != nil {
+		return apiErr
+	}
+	state.RemainingSeconds = duration
+	return nil
+}
+
+func (s *PomodoroService) cancelCurrentSession(
+	ctx context.Context,
+	tx *sql.Tx,
+	sessions sessionTracker,
+	state *model.PomodoroState,
+	at time.Time,
+) *apperrors.APIError {
+	remaining := s.currentRemainingSeconds(state, at)
+	return sessions.finish(ctx, tx, state, remaining, false, at)
 }
 
 func (s *PomodoroService) getStateForUpdate(ctx context.Context, tx *sql.Tx, userID string, now time.Time) (*model.PomodoroState, *apperrors.APIError) {
@@ -334,42 +684,258 @@ func (s *PomodoroService) getStateForUpdate(ctx context.Context, tx *sql.Tx, use
 	return state, nil
 }
 
+// normalizeCompletedSession settles a running state whose deadline has
+// passed: the session is completed at its deadline, the cycle advances to
+// the next phase and, when auto-start is enabled for that phase, a new
+// session begins exactly where the previous one ended.
 func (s *PomodoroService) normalizeCompletedSession(ctx context.Context, tx *sql.Tx, state *model.PomodoroState, now time.Time) *apperrors.APIError {
-	if state.Status != model.StatusRunning || state.StartedAt == nil {
-		return nil
-	}
+	return s.settleUntil(ctx, tx, state, now, now)
+}
 
-	if s.currentRemainingSeconds(state, now) > 0 {
+// settleUntil is normalizeCompletedSession for deadlines up to until, which
+// lies before now when offline commands are replayed.
+func (s *PomodoroService) settleUntil(ctx context.Context, tx *sql.Tx, state *model.PomodoroState, until, now time.Time) *apperrors.APIError {
+	events, apiErr := s.advanceExpired(ctx, tx, stateSessions{s}, state, until)
+	if apiErr != nil {
+		return apiErr
+	}
+	if len(events) == 0 {
 		return nil
 	}
 
-	if state.SessionID != nil {
-		if err := s.finishSession(ctx, tx, *state.SessionID, 0, true, now); err != nil {
-			return err
+	// The server, not a device, finished the session.
+	state.UpdatedAt = now
+	state.UpdatedByDeviceID = nil
+	state.Version++
+	return s.persistState(ctx, tx, state, events, now)
+}
+
+// advanceExpired completes every phase of a running timer whose deadline is
+// not after until and returns a complete event for each, or auto_stop for
+// the last one when maxAutoAdvancePhases cut the cycle short. The caller saves
+// the state when any phase completed.
+func (s *PomodoroService) advanceExpired(
+	ctx context.Context,
+	tx *sql.Tx,
+	sessions sessionTracker,
+	state *model.PomodoroState,
+	until time.Time,
+) ([]model.PomodoroEvent, *apperrors.APIError) {
+	events := make([]model.PomodoroEvent, 0)
+	for state.Status == model.StatusRunning && state.StartedAt != nil {
+		if s.currentRemainingSeconds(state, until) > 0 {
+			break
+		}
+		before := *state
+		endedAt := state.StartedAt.Add(time.Duration(state.RemainingSeconds) * time.Second)
+
+		if err := sessions.finish(ctx, tx, state, 0, true, endedAt); err != nil {
+			return nil, err
+		}
+
+		focus, apiErr := s.countsAsFocus(ctx, tx, state.UserID, state.Mode)
+		if apiErr != nil {
+			return nil, apiErr
 		}
+		s.advanceCycle(state, focus)
+		duration, apiErr := s.durationForMode(ctx, tx, state)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		state.RemainingSeconds = duration
+
+		eventType := model.EventComplete
+		autoStart := s.shouldAutoStart(state)
+		if autoStart && len(events)+1 >= maxAutoAdvancePhases {
+			autoStart = false
+			eventType = model.EventAutoStop
+			log.Printf("pomodoro: stopped timer %s after %d unattended phases", state.UserID, maxAutoAdvancePhases)
+		}
+		if autoStart {
+			if err := sessions.start(ctx, tx, state, endedAt); err != nil {
+				return nil, err
+			}
+			state.StartedAt = &endedAt
+		} else {
+			state.Status = model.StatusIdle
+			state.StartedAt = nil
+		}
+		events = append(events, s.newEvent(eventType, &before, state, endedAt))
 	}
+	return events, nil
+}
 
-	state.Status = model.StatusIdle
-	state.StartedAt = nil
-	state.SessionID = nil
-	state.RemainingSeconds = s.durationForMode(state)
+// advanceCycle moves on from a completed phase. A mode that counts as focus
+// is followed by a break like focus itself; any other mode by focus.
+func (s *PomodoroService) advanceCycle(state *model.PomodoroState, countsAsFocus bool) {
+	if !countsAsFocus {
+		state.Mode = model.ModeFocus
+		return
+	}
+
+	state.CompletedFocusCount++
+	interval := state.LongBreakInterval
+	if interval <= 0 {
+		interval = model.DefaultLongBreakInterval
+	}
+	if state.CompletedFocusCount%interval == 0 {
+		state.Mode = model.ModeLongBreak
+	} else {
+		state.Mode = model.ModeShortBreak
+	}
+}
+
+func (s *PomodoroService) shouldAutoStart(state *model.PomodoroState) bool {
+	if state.Mode == model.ModeFocus {
+		return state.AutoStartFocus
+	}
+	return state.AutoStartBreaks
+}
+
+func (s *PomodoroService) createSession(ctx context.Context, tx *sql.Tx, state *model.PomodoroState, startedAt time.Time) *apperrors.APIError {
+	sessionID, apiErr := s.insertSession(ctx, tx, state.UserID, state.CurrentTaskID, state, startedAt)
+	if apiErr != nil {
+		return apiErr
+	}
+	state.SessionID = &sessionID
+	return nil
+}
+
+// insertSession starts a session of userID for the current phase of state.
+func (s *PomodoroService) insertSession(
+	ctx context.Context,
+	tx *sql.Tx,
+	userID string,
+	taskID *string,
+	state *model.PomodoroState,
+	startedAt time.Time,
+) (string, *apperrors.APIError) {
+	// The focus flag is kept with the session so that later changes to a
+	// custom mode do not rewrite history.
+	focus, apiErr := s.countsAsFocus(ctx, tx, userID, state.Mode)
+	if apiErr != nil {
+		return "", apiErr
+	}
+	session := model.PomodoroSession{
+		ID:                     uuid.NewString(),
+		UserID:                 userID,
+		TaskID:                 taskID,
+		Mode:                   state.Mode,
+		CountsAsFocus:          focus,
+		PlannedDurationSeconds: state.RemainingSeconds,
+		ActualDurationSeconds:  0,
+		StartedAt:              startedAt,
+		Status:                 model.SessionStatusRunning,
+		CreatedAt:              startedAt,
+		UpdatedAt:              startedAt,
+	}
+	if err := s.repo.InsertSessionTx(ctx, tx, &session); err != nil {
+		return "", apperrors.Internal("failed to create focus session")
+	}
+	if apiErr := s.enqueueWebhooks(ctx, tx, model.WebhookEventSessionStarted, &session, startedAt); apiErr != nil {
+		return "", apiErr
+	}
+	return session.ID, nil
+}
+
+// saveTransition records a client-initiated change: the version is bumped,
+// the calling device is stamped on the state and an event is appended in
+// the same transaction as the state write.
+func (s *PomodoroService) saveTransition(
+	ctx context.Context,
+	tx *sql.Tx,
+	eventType string,
+	before *model.PomodoroState,
+	state *model.PomodoroState,
+	now time.Time,
+) *apperrors.APIError {
+	return s.saveTransitionAt(ctx, tx, eventType, before, state, now, now)
+}
+
+// saveTransitionAt is saveTransition for a change that took effect at
+// occurredAt rather than now.
+func (s *PomodoroService) saveTransitionAt(
+	ctx context.Context,
+	tx *sql.Tx,
+	eventType string,
+	before *model.PomodoroState,
+	state *model.PomodoroState,
+	occurredAt time.Time,
+	now time.Time,
+) *apperrors.APIError {
 	state.UpdatedAt = now
+	state.UpdatedByDeviceID = deviceIDFromContext(ctx)
 	state.Version++
+	return s.persistState(ctx, tx, state, []model.PomodoroEvent{s.newEvent(eventType, before, state, occurredAt)}, now)
+}
 
+func (s *PomodoroService) persistState(
+	ctx context.Context,
+	tx *sql.Tx,
+	state *model.PomodoroState,
+	events []model.PomodoroEvent,
+	now time.Time,
+) *apperrors.APIError {
 	if err := s.repo.UpdateStateTx(ctx, tx, state); err != nil {
-		return apperrors.Internal("failed to persist completed state")
+		return apperrors.Internal("failed to update state")
+	}
+	for i := range events {
+		events[i].Version = state.Version
+		events[i].DeviceID = state.UpdatedByDeviceID
+		events[i].CreatedAt = now
+		if err := s.repo.InsertEventTx(ctx, tx, &events[i]); err != nil {
+			return apperrors.Internal("failed to record state event")
+		}
 	}
 	return nil
 }
 
-func (s *PomodoroService) ensureVersion(baseVersion int, state *model.PomodoroState, now time.Time) *apperrors.APIError {
+// newEvent describes a transition; the session is the one the transition
+// acted on, falling back to the session it started.
+func (s *PomodoroService) newEvent(eventType string, before, after *model.PomodoroState, occurredAt time.Time) model.PomodoroEvent {
+	sessionID := before.SessionID
+	if sessionID == nil {
+		sessionID = after.SessionID
+	}
+	return model.PomodoroEvent{
+		UserID:     after.UserID,
+		Type:       eventType,
+		FromStatus: before.Status,
+		ToStatus:   after.Status,
+		FromMode:   before.Mode,
+		ToMode:     after.Mode,
+		SessionID:  sessionID,
+		OccurredAt: occurredAt,
+	}
+}
+
+func (s *PomodoroService) ensureVersion(
+	ctx context.Context,
+	tx *sql.Tx,
+	baseVersion int,
+	state *model.PomodoroState,
+	now time.Time,
+) *apperrors.APIError {
 	if baseVersion <= 0 || baseVersion == state.Version {
 		return nil
 	}
-	view := s.toStateView(state, now)
-	return apperrors.Conflict("state_conflict", "state changed on another device", map[string]interface{}{
-		"state": view,
-	})
+	details := map[string]interface{}{
+		"state": s.toStateView(state, now),
+	}
+	if state.UpdatedByDeviceID != nil {
+		device, err := s.deviceRepo.GetByIDTx(ctx, tx, state.UserID, *state.UpdatedByDeviceID)
+		if err != nil && err != repository.ErrNotFound {
+			return apperrors.Internal("failed to read device")
+		}
+		if err == nil {
+			details["device"] = map[string]interface{}{
+				"id":       device.ID,
+				"name":     device.Name,
+				"platform": device.Platform,
+			}
+		}
+	}
+	return apperrors.Conflict("state_conflict", "state changed on another device", details)
 }
 
 func (s *PomodoroService) currentRemainingSeconds(state *model.PomodoroState, now time.Time) int {
@@ -388,17 +954,6 @@ func (s *PomodoroService) currentRemainingSeconds(state *model.PomodoroState, no
 	return remaining
 }
 
-func (s *PomodoroService) durationForMode(state *model.PomodoroState) int {
-	switch state.Mode {
-	case model.ModeShortBreak:
-		return state.ShortBreakDurationSeconds
-	case model.ModeLongBreak:
-		return state.LongBreakDurationSeconds
-	default:
-		return state.FocusDurationSeconds
-	}
-}
-
 func (s *PomodoroService) finishSession(
 	ctx context.Context,
 	tx *sql.Tx,
@@ -414,7 +969,7 @@ func (s *PomodoroService) finishSession(
 	if err != nil {
 		return apperrors.Internal("failed to read session")
 	}
-	if session.Status != "running" {
+	if session.Status != model.SessionStatusRunning {
 		return nil
 	}
 
@@ -429,10 +984,15 @@ func (s *PomodoroService) finishSession(
 		actual = session.PlannedDurationSeconds
 	}
 
+	// A session cancelled while paused ends its open pause as well.
+	if apiErr := s.closePause(ctx, tx, session, now); apiErr != nil {
+		return apiErr
+	}
+
 	if completed {
-		session.Status = "completed"
+		session.Status = model.SessionStatusCompleted
 	} else {
-		session.Status = "cancelled"
+		session.Status = model.SessionStatusCancelled
 	}
 	session.ActualDurationSeconds = actual
 	session.EndedAt = &now
@@ -441,6 +1001,76 @@ func (s *PomodoroService) finishSession(
 	if err := s.repo.UpdateSessionTx(ctx, tx, session); err != nil {
 		return apperrors.Internal("failed to update session")
 	}
+	eventType := model.WebhookEventSessionCancelled
+	if completed {
+		eventType = model.WebhookEventSessionCompleted
+	}
+	if apiErr := s.enqueueWebhooks(ctx, tx, eventType, session, now); apiErr != nil {
+		return apiErr
+	}
+
+	if completed && session.TaskID != nil && session.CountsAsFocus {
+		if err := s.taskRepo.IncrementCompletedTx(ctx, tx, *session.TaskID, now); err != nil {
+			return apperrors.Internal("failed to update task progress")
+		}
+	}
+	return nil
+}
+
+func (s *PomodoroService) pauseSession(ctx context.Context, tx *sql.Tx, sessionID string, now time.Time) *apperrors.APIError {
+	session, err := s.repo.GetSessionTx(ctx, tx, sessionID)
+	if err == repository.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return apperrors.Internal("failed to read session")
+	}
+
+	pause := model.SessionPause{ID: uuid.NewString(), SessionID: sessionID, PausedAt: now}
+	if err := s.repo.InsertPauseTx(ctx, tx, session.UserID, &pause); err != nil {
+		return apperrors.Internal("failed to record pause")
+	}
+
+	session.PauseCount++
+	session.UpdatedAt = now
+	if err := s.repo.UpdateSessionTx(ctx, tx, session); err != nil {
+		return apperrors.Internal("failed to update session")
+	}
+	return s.enqueueWebhooks(ctx, tx, model.WebhookEventSessionPaused, session, now)
+}
+
+func (s *PomodoroService) resumeSession(ctx context.Context, tx *sql.Tx, sessionID string, now time.Time) *apperrors.APIError {
+	session, err := s.repo.GetSessionTx(ctx, tx, sessionID)
+	if err == repository.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return apperrors.Internal("failed to read session")
+	}
+
+	if apiErr := s.closePause(ctx, tx, session, now); apiErr != nil {
+		return apiErr
+	}
+	session.UpdatedAt = now
+	if err := s.repo.UpdateSessionTx(ctx, tx, session); err != nil {
+		return apperrors.Internal("failed to update session")
+	}
+	return s.enqueueWebhooks(ctx, tx, model.WebhookEventSessionResumed, session, now)
+}
+
+// closePause ends the session's open pause, if any, and adds its length to
+// the session's paused time. The caller persists the session.
+func (s *PomodoroService) closePause(ctx context.Context, tx *sql.Tx, session *model.PomodoroSession, now time.Time) *apperrors.APIError {
+	pause, err := s.repo.ResumePauseTx(ctx, tx, session.ID, now)
+	if err == repository.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return apperrors.Internal("failed to resume pause")
+	}
+	if paused := int(now.Sub(pause.PausedAt).Seconds()); paused > 0 {
+		session.PausedSeconds += paused
+	}
 	return nil
 }
 
@@ -453,9 +1083,15 @@ func (s *PomodoroService) toStateView(state *model.PomodoroState, now time.Time)
 		FocusDurationSeconds:      state.FocusDurationSeconds,
 		ShortBreakDurationSeconds: state.ShortBreakDurationSeconds,
 		LongBreakDurationSeconds:  state.LongBreakDurationSeconds,
+		CompletedFocusCount:       state.CompletedFocusCount,
+		LongBreakInterval:         state.LongBreakInterval,
+		AutoStartBreaks:           state.AutoStartBreaks,
+		AutoStartFocus:            state.AutoStartFocus,
+		CurrentTaskID:             state.CurrentTaskID,
 		SessionID:                 state.SessionID,
 		Version:                   state.Version,
 		UpdatedAt:                 state.UpdatedAt,
+		UpdatedByDeviceID:         state.UpdatedByDeviceID,
 		ServerTime:                now,
 	}
 
@@ -469,6 +1105,18 @@ func (s *PomodoroService) toStateView(state *model.PomodoroState, now time.Time)
 	return view
 }
 
-func isValidMode(mode string) bool {
-	return mode == model.ModeFocus || mode == model.ModeShortBreak || mode == model.ModeLongBreak
+func validateSettings(focusSeconds, shortBreakSeconds, longBreakSeconds int, longBreakInterval *int) *apperrors.APIError {
+	if focusSeconds <= 0 || shortBreakSeconds <= 0 || longBreakSeconds <= 0 {
+		return apperrors.BadRequest("invalid_duration", "all durations must be positive seconds")
+	}
+	if longBreakInterval != nil && *longBreakInterval <= 0 {
+		return apperrors.BadRequest("invalid_long_break_interval", "longBreakInterval must be a positive number")
+	}
+	return nil
+}
+
+func isValidSessionStatus(status string) bool {
+	return status == model.SessionStatusRunning ||
+		status == model.SessionStatusCompleted ||
+		status == model.SessionStatusCancelled
 }
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/model"
+	"pomodoro/backend/internal/repository"
+)
+
+const (
+	dateLayout       = "2006-01-02"
+	defaultStatsDays = 30
+	maxStatsDays     = 366
+	statsWeeks       = 12
+	statsMonths      = 12
+	defaultStatsTZ   = "UTC"
+)
+
+type StatsQuery struct {
+	TimeZone string
+	Days     int
+}
+
+type PeriodTotal struct {
+	Start                  string `json:"start"`
+	FocusSeconds           int    `json:"focusSeconds"`
+	CompletedFocusSessions int    `json:"completedFocusSessions"`
+}
+
+type ModeStats struct {
+	Sessions         int     `json:"sessions"`
+	Completed        int     `json:"completed"`
+	Cancelled        int     `json:"cancelled"`
+	ActualSeconds    int     `json:"actualSeconds"`
+	CompletionRate   float64 `json:"completionRate"`
+	CancellationRate float64 `json:"cancellationRate"`
+}
+
+type StreakStats struct {
+	CurrentDays int `json:"currentDays"`
+	LongestDays int `json:"longestDays"`
+}
+
+type StatsView struct {
+	TimeZone         string               `json:"timeZone"`
+	Today            PeriodTotal          `json:"today"`
+	ThisWeek         PeriodTotal          `json:"thisWeek"`
+	ThisMonth        PeriodTotal          `json:"thisMonth"`
+	Daily            []PeriodTotal        `json:"daily"`
+	Weekly           []PeriodTotal        `json:"weekly"`
+	Monthly          []PeriodTotal        `json:"monthly"`
+	CompletionRate   float64              `json:"completionRate"`
+	CancellationRate float64              `json:"cancellationRate"`
+	Streak           StreakStats          `json:"streak"`
+	ByMode           map[string]ModeStats `json:"byMode"`
+	GeneratedAt      time.Time            `json:"generatedAt"`
+}
+
+// GetStats reads only the range the response covers: the longest of the
+// daily, weekly and monthly series, summed per local date by the database.
+// Streaks are the exception and look at the whole history. Day, week and
+// month boundaries follow the user's wall clock including daylight saving
+// changes.
+func (s *PomodoroService) GetStats(ctx context.Context, userID string, query StatsQuery) (*StatsView, *apperrors.APIError) {
+	loc, apiErr := loadTimeZone(query.TimeZone)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	days := query.Days
+	if days <= 0 {
+		days = defaultStatsDays
+	}
+	if days > maxStatsDays {
+		days = maxStatsDays
+	}
+
+	// Reading the state settles a session that ran out since the last
+	// request, so it is counted as completed rather than running.
+	if _, apiErr := s.GetState(ctx, userID); apiErr != nil {
+		return nil, apiErr
+	}
+
+	now := time.Now().UTC()
+	today := civilDate(now.In(loc))
+	dailyStart := today.AddDate(0, 0, 1-days)
+	weekStart := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	monthStart := today.AddDate(0, 0, 1-today.Day())
+
+	first := dailyStart
+	for _, start := range []time.Time{
+		weekStart.AddDate(0, 0, -7*(statsWeeks-1)),
+		monthStart.AddDate(0, 1-statsMonths, 0),
+	} {
+		if start.Before(first) {
+			first = start
+		}
+	}
+	from := localMidnight(first, loc)
+	to := localMidnight(today.AddDate(0, 0, 1), loc)
+
+	dailyFocus, err := s.repo.SumDailyFocus(ctx, userID, from, to, offsetSpans(loc, from, to))
+	if err != nil {
+		return nil, apperrors.Internal("failed to aggregate sessions")
+	}
+	modeTotals, err := s.repo.SumSessionsByMode(ctx, userID, localMidnight(dailyStart, loc), to)
+	if err != nil {
+		return nil, apperrors.Internal("failed to aggregate sessions")
+	}
+
+	streak, apiErr := s.focusStreak(ctx, userID, loc, today, to)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	dailyTotals := indexDailyFocus(dailyFocus)
+
+	byMode := make(map[string]ModeStats)
+	var focusCompleted, focusCancelled int
+	for _, total := range modeTotals {
+		modeStats := byMode[total.Mode]
+		modeStats.Sessions += total.Sessions
+		modeStats.ActualSeconds += total.ActualSeconds
+		switch total.Status {
+		case model.SessionStatusCompleted:
+			modeStats.Completed += total.Sessions
+			if total.CountsAsFocus {
+				focusCompleted += total.Sessions
+			}
+		case model.SessionStatusCancelled:
+			modeStats.Cancelled += total.Sessions
+			if total.CountsAsFocus {
+				focusCancelled += total.Sessions
+			}
+		}
+		byMode[total.Mode] = modeStats
+	}
+
+	for mode, modeStats := range byMode {
+		modeStats.CompletionRate, modeStats.CancellationRate = finishRates(modeStats.Completed, modeStats.Cancelled)
+		byMode[mode] = modeStats
+	}
+
+	view := StatsView{
+		TimeZone:    loc.String(),
+		Daily:       make([]PeriodTotal, 0, days),
+		Weekly:      make([]PeriodTotal, 0, statsWeeks),
+		Monthly:     make([]PeriodTotal, 0, statsMonths),
+		ByMode:      byMode,
+		Streak:      streak,
+		GeneratedAt: now,
+	}
+	view.CompletionRate, view.CancellationRate = finishRates(focusCompleted, focusCancelled)
+
+	for i := days - 1; i >= 0; i-- {
+		view.Daily = append(view.Daily, sumPeriod(dailyTotals, today.AddDate(0, 0, -i), 1))
+	}
+	for i := statsWeeks - 1; i >= 0; i-- {
+		view.Weekly = append(view.Weekly, sumPeriod(dailyTotals, weekStart.AddDate(0, 0, -7*i), 7))
+	}
+	for i := statsMonths - 1; i >= 0; i-- {
+		start := monthStart.AddDate(0, -i, 0)
+		view.Monthly = append(view.Monthly, sumPeriod(dailyTotals, start, daysInMonth(start)))
+	}
+
+	view.Today = view.Daily[len(view.Daily)-1]
+	view.ThisWeek = view.Weekly[len(view.Weekly)-1]
+	view.ThisMonth = view.Monthly[len(view.Monthly)-1]
+	return &view, nil
+}
+
+func loadTimeZone(name string) (*time.Location, *apperrors.APIError) {
+	if name == "" {
+		name = defaultStatsTZ
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, apperrors.BadRequest("invalid_time_zone", "tz must be an IANA time zone name")
+	}
+	return loc, nil
+}
+
+// civilDate maps a wall-clock time to noon UTC of the same calendar date so
+// that date arithmetic is never skewed by DST transitions.
+func civilDate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+}
+
+// localMidnight is the instant a civil date begins in loc.
+func localMidnight(date time.Time, loc *time.Location) time.Time {
+	year, month, day := date.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc).UTC()
+}
+
+// offsetSpans splits [from, to) wherever loc changes its UTC offset.
+func offsetSpans(loc *time.Location, from, to time.Time) []repository.OffsetSpan {
+	spans := make([]repository.OffsetSpan, 0, 3)
+	for at := from; at.Before(to); {
+		local := at.In(loc)
+		_, offset := local.Zone()
+		spans = append(spans, repository.OffsetSpan{Start: at, OffsetSeconds: offset})
+		_, end := local.ZoneBounds()
+		if end.IsZero() || !end.After(at) {
+			break
+		}
+		at = end.UTC()
+	}
+	return spans
+}
+
+func daysInMonth(start time.Time) int {
+	return int(start.AddDate(0, 1, 0).Sub(start).Hours() / 24)
+}
+
+func sumPeriod(dailyTotals map[string]*PeriodTotal, start time.Time, days int) PeriodTotal {
+	period := PeriodTotal{Start: start.Format(dateLayout)}
+	for i := 0; i < days; i++ {
+		if total := dailyTotals[start.AddDate(0, 0, i).Format(dateLayout)]; total != nil {
+			period.FocusSeconds += total.FocusSeconds
+			period.CompletedFocusSessions += total.CompletedFocusSessions
+		}
+	}
+	return period
+}
+
+// focusStreak computes the streaks over the user's whole history rather than
+// the range of the series, so streaks longer than that range are counted in
+// full. to is the end of today.
+func (s *PomodoroService) focusStreak(
+	ctx context.Context,
+	userID string,
+	loc *time.Location,
+	today time.Time,
+	to time.Time,
+) (StreakStats, *apperrors.APIError) {
+	first, err := s.repo.FirstCompletedFocusAt(ctx, userID)
+	if err != nil {
+		return StreakStats{}, apperrors.Internal("failed to aggregate sessions")
+	}
+	if first == nil || !first.Before(to) {
+		return StreakStats{}, nil
+	}
+	from := localMidnight(civilDate(first.In(loc)), loc)
+	days, err := s.repo.SumDailyFocus(ctx, userID, from, to, offsetSpans(loc, from, to))
+	if err != nil {
+		return StreakStats{}, apperrors.Internal("failed to aggregate sessions")
+	}
+	return computeStreak(indexDailyFocus(days), today), nil
+}
+
+func indexDailyFocus(days []repository.DailyFocus) map[string]*PeriodTotal {
+	dailyTotals := make(map[string]*PeriodTotal, len(days))
+	for _, day := range days {
+		dailyTotals[day.Date] = &PeriodTotal{
+			Start:                  day.Date,
+			FocusSeconds:           day.Seconds,
+			CompletedFocusSessions: day.Sessions,
+		}
+	}
+	return dailyTotals
+}
+
+// computeStreak counts consecutive days with at least one completed focus
+// session. Today without a session yet does not break the current streak.
+func computeStreak(dailyTotals map[string]*PeriodTotal, today time.Time) StreakStats {
+	streak := StreakStats{}
+	if len(dailyTotals) == 0 {
+		return streak
+	}
+
+	day := today
+	if dailyTotals[day.Format(dateLayout)] == nil {
+		day = day.AddDate(0, 0, -1)
+	}
+	for dailyTotals[day.Format(dateLayout)] != nil {
+		streak.CurrentDays++
+		day = day.AddDate(0, 0, -1)
+	}
+
+	for key := range dailyTotals {
+		start, err := time.Parse(dateLayout, key)
+		if err != nil {
+			continue
+		}
+		start = civilDate(start)
+		if dailyTotals[start.AddDate(0, 0, -1).Format(dateLayout)] != nil {
+			continue
+		}
+		length := 0
+		for d := start; dailyTotals[d.Format(dateLayout)] != nil; d = d.AddDate(0, 0, 1) {
+			length++
+		}
+		if length > streak.LongestDays {
+			streak.LongestDays = length
+		}
+	}
+	return streak
+}
+
+func finishRates(completed, cancelled int) (float64, float64) {
+	finished := completed + cancelled
+	if finished == 0 {
+		return 0, 0
+	}
+	return float64(completed) / float64(finished), float64(cancelled) / float64(finished)
+}
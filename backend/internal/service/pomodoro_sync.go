@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/model"
+	"pomodoro/backend/internal/repository"
+)
+
+const maxSyncCommands = 100
+
+const (
+	SyncCommandStart = "start"
+	SyncCommandPause = "pause"
+	SyncCommandReset = "reset"
+	SyncCommandMode  = "mode"
+)
+
+const (
+	SyncApplied    = "applied"
+	SyncNoop       = "noop"
+	SyncSuperseded = "superseded"
+	SyncRejected   = "rejected"
+)
+
+// SyncCommand is an action a device recorded while offline. At is the
+// device's clock when the action was taken.
+type SyncCommand struct {
+	ID   string
+	Type string
+	Mode string
+	At   time.Time
+}
+
+// SyncInput carries the device's clock at upload time in SentAt, which is
+// used to correct every command time for clock skew.
+type SyncInput struct {
+	SentAt   *time.Time
+	Commands []SyncCommand
+}
+
+type SyncCommandResult struct {
+	ID          string    `json:"id,omitempty"`
+	Type        string    `json:"type"`
+	Status      string    `json:"status"`
+	EffectiveAt time.Time `json:"effectiveAt"`
+	Version     int       `json:"version"`
+	Code        string    `json:"code,omitempty"`
+	Message     string    `json:"message,omitempty"`
+}
+
+type SyncResult struct {
+	State   StateView           `json:"state"`
+	Results []SyncCommandResult `json:"results"`
+}
+
+// Sync replays offline commands in order against the stored state, in one
+// transaction and without a baseVersion check. The merge rules are:
+//
+//   - a command takes effect at its time shifted by the skew between SentAt
+//     and the server clock, clamped to now and to the previous command's time
+//     so the batch stays ordered;
+//   - timers that ran out before a command are completed first, exactly as
+//     if the state had been read at that moment;
+//   - a command older than the latest recorded transition is superseded,
+//     since the server already holds a later change;
+//   - start on a running timer and pause on a stopped one are no-ops.
+func (s *PomodoroService) Sync(ctx context.Context, userID string, input SyncInput) (*SyncResult, *apperrors.APIError) {
+	if len(input.Commands) == 0 {
+		return nil, apperrors.BadRequest("empty_sync", "commands must not be empty")
+	}
+	if len(input.Commands) > maxSyncCommands {
+		return nil, apperrors.BadRequest("too_many_commands", fmt.Sprintf("at most %d commands can be synced at once", maxSyncCommands))
+	}
+
+	now := time.Now().UTC()
+	var skew time.Duration
+	if input.SentAt != nil {
+		skew = now.Sub(*input.SentAt)
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	// The state is settled command by command below, so it is read without
+	// normalizing it to now first.
+	state, err := s.repo.GetStateTx(ctx, tx, userID)
+	if err == repository.ErrNotFound {
+		return nil, apperrors.NotFound("state_not_found", "pomodoro state not found")
+	}
+	if err != nil {
+		return nil, apperrors.Internal("failed to get state")
+	}
+	loadedVersion := state.Version
+
+	// States older than the event log fall back to their last write; the
+	// initial state at version 1 was never changed by anyone.
+	var lastChange time.Time
+	if state.Version > 1 {
+		lastChange = state.UpdatedAt
+	}
+	lastEvent, err := s.repo.LastEventTimeTx(ctx, tx, userID)
+	if err != nil {
+		return nil, apperrors.Internal("failed to read state events")
+	}
+	if lastEvent != nil {
+		lastChange = *lastEvent
+	}
+
+	results := make([]SyncCommandResult, 0, len(input.Commands))
+	var previous time.Time
+	for _, command := range input.Commands {
+		at := command.At.Add(skew).UTC()
+		if at.After(now) {
+			at = now
+		}
+		if at.Before(previous) {
+			at = previous
+		}
+		previous = at
+
+		result := SyncCommandResult{ID: command.ID, Type: command.Type, EffectiveAt: at}
+		if apiErr := s.validateSyncCommand(ctx, tx, userID, command); apiErr != nil {
+			if apiErr.Status == http.StatusInternalServerError {
+				return nil, apiErr
+			}
+			result.Status = SyncRejected
+			result.Code = apiErr.Code
+			result.Message = apiErr.Message
+			result.Version = state.Version
+			results = append(results, result)
+			continue
+		}
+
+		if apiErr := s.settleUntil(ctx, tx, state, at, now); apiErr != nil {
+			return nil, apiErr
+		}
+
+		if at.Before(lastChange) {
+			result.Status = SyncSuperseded
+		} else {
+			applied, apiErr := s.replayCommand(ctx, tx, state, command, at, now)
+			if apiErr != nil {
+				return nil, apiErr
+			}
+			result.Status = SyncNoop
+			if applied {
+				result.Status = SyncApplied
+				lastChange = at
+			}
+		}
+		result.Version = state.Version
+		results = append(results, result)
+	}
+
+	if apiErr := s.normalizeCompletedSession(ctx, tx, state, now); apiErr != nil {
+		return nil, apiErr
+	}
+
+	view, apiErr := s.stateView(ctx, tx, state, now)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		return nil, apperrors.Internal("failed to commit transaction")
+	}
+
+	if state.Version != loadedVersion {
+		s.publish(state, view)
+	}
+	return &SyncResult{State: view, Results: results}, nil
+}
+
+// replayCommand runs the same transition as the live endpoint for the
+// command and records it as having occurred at at.
+func (s *PomodoroService) replayCommand(
+	ctx context.Context,
+	tx *sql.Tx,
+	state *model.PomodoroState,
+	command SyncCommand,
+	at time.Time,
+	now time.Time,
+) (bool, *apperrors.APIError) {
+	before := *state
+	changed := true
+	var eventType string
+	var apiErr *apperrors.APIError
+	switch command.Type {
+	case SyncCommandStart:
+		eventType = model.EventStart
+		changed, apiErr = s.applyStart(ctx, tx, stateSessions{s}, state, at)
+	case SyncCommandPause:
+		eventType = model.EventPause
+		changed, apiErr = s.applyPause(ctx, tx, stateSessions{s}, state, at)
+	case SyncCommandReset:
+		eventType = model.EventReset
+		apiErr = s.applyReset(ctx, tx, stateSessions{s}, state, at)
+	case SyncCommandMode:
+		eventType = model.EventSwitchMode
+		apiErr = s.applySwitchMode(ctx, tx, stateSessions{s}, state, command.Mode, at)
+	}
+	if apiErr != nil || !changed {
+		return false, apiErr
+	}
+
+	if apiErr := s.saveTransitionAt(ctx, tx, eventType, &before, state, at, now); apiErr != nil {
+		return false, apiErr
+	}
+	return true, nil
+}
+
+func (s *PomodoroService) validateSyncCommand(ctx context.Context, tx *sql.Tx, userID string, command SyncCommand) *apperrors.APIError {
+	switch command.Type {
+	case SyncCommandStart, SyncCommandPause, SyncCommandReset:
+	case SyncCommandMode:
+		if apiErr := s.checkMode(ctx, tx, userID, command.Mode); apiErr != nil {
+			return apiErr
+		}
+	default:
+		return apperrors.BadRequest("invalid_command", "type must be one of start, pause, reset, mode")
+	}
+	if command.At.IsZero() {
+		return apperrors.BadRequest("invalid_command_time", "at is required")
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/model"
+)
+
+// WebhookPayload is the JSON body of every webhook delivery. ID identifies
+// the event and is shared by its deliveries to different webhooks.
+type WebhookPayload struct {
+	ID         string                `json:"id"`
+	Type       string                `json:"type"`
+	OccurredAt time.Time             `json:"occurredAt"`
+	Session    model.PomodoroSession `json:"session"`
+}
+
+// enqueueWebhooks queues a session event for every active webhook of the
+// session's user that subscribes to it. It runs in the transaction that
+// changes the session, so the deliveries exist exactly when the change is
+// committed; the delivery worker sends them afterwards.
+func (s *PomodoroService) enqueueWebhooks(
+	ctx context.Context,
+	tx *sql.Tx,
+	eventType string,
+	session *model.PomodoroSession,
+	occurredAt time.Time,
+) *apperrors.APIError {
+	webhooks, err := s.webhookRepo.ListActiveTx(ctx, tx, session.UserID)
+	if err != nil {
+		return apperrors.Internal("failed to list webhooks")
+	}
+
+	var payload []byte
+	var eventID string
+	now := time.Now().UTC()
+	for _, webhook := range webhooks {
+		if !subscribesTo(webhook, eventType) {
+			continue
+		}
+		if payload == nil {
+			eventID = uuid.NewString()
+			payload, err = json.Marshal(WebhookPayload{
+				ID:         eventID,
+				Type:       eventType,
+				OccurredAt: occurredAt,
+				Session:    *session,
+			})
+			if err != nil {
+				return apperrors.Internal("failed to encode webhook payload")
+			}
+		}
+
+		delivery := model.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			UserID:        session.UserID,
+			EventID:       eventID,
+			EventType:     eventType,
+			Payload:       payload,
+			Status:        model.DeliveryStatusPending,
+			NextAttemptAt: &now,
+			CreatedAt:     now,
+		}
+		if err := s.webhookRepo.InsertDeliveryTx(ctx, tx, &delivery); err != nil {
+			return apperrors.Internal("failed to queue webhook delivery")
+		}
+	}
+	return nil
+}
+
+func subscribesTo(webhook model.Webhook, eventType string) bool {
+	for _, event := range webhook.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
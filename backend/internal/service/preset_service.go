@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/model"
+	"pomodoro/backend/internal/repository"
+)
+
+const maxPresetNameLength = 50
+
+type PresetService struct {
+	repo            repository.PresetStore
+	pomodoroService *PomodoroService
+}
+
+// PresetInput describes a whole preset; updates replace every field, so an
+// optional field left nil is cleared.
+type PresetInput struct {
+	Name                      string
+	FocusDurationSeconds      int
+	ShortBreakDurationSeconds int
+	LongBreakDurationSeconds  int
+	LongBreakInterval         *int
+	AutoStartBreaks           *bool
+	AutoStartFocus            *bool
+}
+
+func NewPresetService(repo repository.PresetStore, pomodoroService *PomodoroService) *PresetService {
+	return &PresetService{repo: repo, pomodoroService: pomodoroService}
+}
+
+func (s *PresetService) List(ctx context.Context, userID string) ([]model.Preset, *apperrors.APIError) {
+	presets, err := s.repo.List(ctx, userID)
+	if err != nil {
+		return nil, apperrors.Internal("failed to list presets")
+	}
+	return presets, nil
+}
+
+func (s *PresetService) Get(ctx context.Context, userID, presetID string) (*model.Preset, *apperrors.APIError) {
+	preset, err := s.repo.GetByID(ctx, userID, presetID)
+	if err == repository.ErrNotFound {
+		return nil, apperrors.NotFound("preset_not_found", "preset not found")
+	}
+	if err != nil {
+		return nil, apperrors.Internal("failed to get preset")
+	}
+	return preset, nil
+}
+
+func (s *PresetService) Create(ctx context.Context, userID string, input PresetInput) (*model.Preset, *apperrors.APIError) {
+	now := time.Now().UTC()
+	preset := model.Preset{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if apiErr := applyPresetInput(&preset, input); apiErr != nil {
+		return nil, apiErr
+	}
+
+	if err := s.repo.Create(ctx, &preset); err != nil {
+		if err == repository.ErrDuplicate {
+			return nil, presetNameExistsError()
+		}
+		return nil, apperrors.Internal("failed to create preset")
+	}
+	return &preset, nil
+}
+
+func (s *PresetService) Update(ctx context.Context, userID, presetID string, input PresetInput) (*model.Preset, *apperrors.APIError) {
+	preset, apiErr := s.Get(ctx, userID, presetID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := applyPresetInput(preset, input); apiErr != nil {
+		return nil, apiErr
+	}
+	preset.UpdatedAt = time.Now().UTC()
+
+	if err := s.repo.Update(ctx, preset); err != nil {
+		if err == repository.ErrDuplicate {
+			return nil, presetNameExistsError()
+		}
+		if err == repository.ErrNotFound {
+			return nil, apperrors.NotFound("preset_not_found", "preset not found")
+		}
+		return nil, apperrors.Internal("failed to update preset")
+	}
+	return preset, nil
+}
+
+func (s *PresetService) Delete(ctx context.Context, userID, presetID string) *apperrors.APIError {
+	err := s.repo.Delete(ctx, userID, presetID)
+	if err == repository.ErrNotFound {
+		return apperrors.NotFound("preset_not_found", "preset not found")
+	}
+	if err != nil {
+		return apperrors.Internal("failed to delete preset")
+	}
+	return nil
+}
+
+// Activate applies the preset like a settings update, so it is checked
+// against baseVersion and leaves the remaining time of a running session
+// alone.
+func (s *PresetService) Activate(ctx context.Context, userID, presetID string, baseVersion int) (*StateView, *apperrors.APIError) {
+	preset, apiErr := s.Get(ctx, userID, presetID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return s.pomodoroService.UpdateSettings(ctx, userID, UpdateSettingsInput{
+		BaseVersion:               baseVersion,
+		FocusDurationSeconds:      preset.FocusDurationSeconds,
+		ShortBreakDurationSeconds: preset.ShortBreakDurationSeconds,
+		LongBreakDurationSeconds:  preset.LongBreakDurationSeconds,
+		LongBreakInterval:         preset.LongBreakInterval,
+		AutoStartBreaks:           preset.AutoStartBreaks,
+		AutoStartFocus:            preset.AutoStartFocus,
+	})
+}
+
+func applyPresetInput(preset *model.Preset, input PresetInput) *apperrors.APIError {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return apperrors.BadRequest("invalid_name", "name is required")
+	}
+	if utf8.RuneCountInString(name) > maxPresetNameLength {
+		return apperrors.BadRequest("invalid_name", "name must be at most 50 characters")
+	}
+	if apiErr := validateSettings(
+		input.FocusDurationSeconds,
+		input.ShortBreakDurationSeconds,
+		input.LongBreakDurationSeconds,
+		input.LongBreakInterval,
+	); apiErr != nil {
+		return apiErr
+	}
+
+	preset.Name = name
+	preset.FocusDurationSeconds = input.FocusDurationSeconds
+	preset.ShortBreakDurationSeconds = input.ShortBreakDurationSeconds
+	preset.LongBreakDurationSeconds = input.LongBreakDurationSeconds
+	preset.LongBreakInterval = input.LongBreakInterval
+	preset.AutoStartBreaks = input.AutoStartBreaks
+	preset.AutoStartFocus = input.AutoStartFocus
+	return nil
+}
+
+func presetNameExistsError() *apperrors.APIError {
+	return apperrors.Conflict("preset_name_exists", "a preset with this name already exists", nil)
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiterPruneInterval is how often buckets that have refilled
+// completely are dropped, so idle keys do not accumulate.
+const rateLimiterPruneInterval = time.Minute
+
+// RateLimiter is a token bucket per key: each key may spend up to burst
+// requests at once, refilled at perMinute tokens a minute. It is
+// in-process, so every server instance enforces its own limits.
+type RateLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewRateLimiter returns nil when perMinute or burst is not positive, and a
+// nil limiter allows everything.
+func NewRateLimiter(perMinute, burst int) *RateLimiter {
+	if perMinute <= 0 || burst <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		rate:    float64(perMinute) / time.Minute.Seconds(),
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Allow spends a token for key. When none is left it reports how long until
+// the next one is available.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastPrune) >= rateLimiterPruneInterval {
+		l.prune(now)
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = l.refill(bucket, now)
+	bucket.updated = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+func (l *RateLimiter) refill(bucket *tokenBucket, now time.Time) float64 {
+	tokens := bucket.tokens + now.Sub(bucket.updated).Seconds()*l.rate
+	if tokens > l.burst {
+		return l.burst
+	}
+	return tokens
+}
+
+func (l *RateLimiter) prune(now time.Time) {
+	for key, bucket := range l.buckets {
+		if l.refill(bucket, now) >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastPrune = now
+}
@@ -0,0 +1,638 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/model"
+	"pomodoro/backend/internal/repository"
+)
+
+const (
+	maxRoomNameLength = 100
+	inviteCodeLength  = 10
+	// inviteCodeAlphabet leaves out characters that are easily confused when
+	// a code is read out, such as 0/O and 1/I.
+	inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	inviteCodeAttempts = 3
+)
+
+// RoomService runs the shared timer of a room with the transitions of
+// PomodoroService. Only the owner drives the timer; every member follows it
+// with a session of their own for each phase.
+type RoomService struct {
+	repo            repository.RoomStore
+	pomodoroService *PomodoroService
+}
+
+type CreateRoomInput struct {
+	Name string
+	// Durations left at zero use the defaults of a new personal timer.
+	FocusDurationSeconds      int
+	ShortBreakDurationSeconds int
+	LongBreakDurationSeconds  int
+	LongBreakInterval         *int
+	AutoStartBreaks           bool
+	AutoStartFocus            bool
+}
+
+type RoomStateView struct {
+	RoomID                    string     `json:"roomId"`
+	Mode                      string     `json:"mode"`
+	Status                    string     `json:"status"`
+	RemainingSeconds          int        `json:"remainingSeconds"`
+	FocusDurationSeconds      int        `json:"focusDurationSeconds"`
+	ShortBreakDurationSeconds int        `json:"shortBreakDurationSeconds"`
+	LongBreakDurationSeconds  int        `json:"longBreakDurationSeconds"`
+	CompletedFocusCount       int        `json:"completedFocusCount"`
+	LongBreakInterval         int        `json:"longBreakInterval"`
+	AutoStartBreaks           bool       `json:"autoStartBreaks"`
+	AutoStartFocus            bool       `json:"autoStartFocus"`
+	StartedAt                 *time.Time `json:"startedAt,omitempty"`
+	Version                   int        `json:"version"`
+	UpdatedAt                 time.Time  `json:"updatedAt"`
+	ServerTime                time.Time  `json:"serverTime"`
+}
+
+type roomTransition func(tx *sql.Tx, sessions sessionTracker, state *model.PomodoroState, now time.Time) (bool, *apperrors.APIError)
+
+func NewRoomService(repo repository.RoomStore, pomodoroService *PomodoroService) *RoomService {
+	return &RoomService{repo: repo, pomodoroService: pomodoroService}
+}
+
+func (s *RoomService) List(ctx context.Context, userID string) ([]model.Room, *apperrors.APIError) {
+	rooms, err := s.repo.ListForUser(ctx, userID)
+	if err != nil {
+		return nil, apperrors.Internal("failed to list rooms")
+	}
+	return rooms, nil
+}
+
+func (s *RoomService) Get(ctx context.Context, userID, roomID string) (*model.Room, []model.RoomMember, *apperrors.APIError) {
+	if _, apiErr := s.requireMember(ctx, roomID, userID); apiErr != nil {
+		return nil, nil, apiErr
+	}
+	room, err := s.repo.GetByID(ctx, roomID)
+	if err == repository.ErrNotFound {
+		return nil, nil, roomNotFoundError()
+	}
+	if err != nil {
+		return nil, nil, apperrors.Internal("failed to get room")
+	}
+	members, err := s.repo.ListMembers(ctx, roomID)
+	if err != nil {
+		return nil, nil, apperrors.Internal("failed to list room members")
+	}
+	return room, members, nil
+}
+
+func (s *RoomService) Create(ctx context.Context, userID string, input CreateRoomInput) (*model.Room, *RoomStateView, *apperrors.APIError) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, nil, apperrors.BadRequest("invalid_name", "name is required")
+	}
+	if utf8.RuneCountInString(name) > maxRoomNameLength {
+		return nil, nil, apperrors.BadRequest("invalid_name", "name must be at most 100 characters")
+	}
+
+	now := time.Now().UTC()
+	state := model.PomodoroState{
+		Mode:                      model.ModeFocus,
+		Status:                    model.StatusIdle,
+		FocusDurationSeconds:      orDefault(input.FocusDurationSeconds, model.DefaultFocusDurationSeconds),
+		ShortBreakDurationSeconds: orDefault(input.ShortBreakDurationSeconds, model.DefaultShortBreakDurationSeconds),
+		LongBreakDurationSeconds:  orDefault(input.LongBreakDurationSeconds, model.DefaultLongBreakDurationSeconds),
+		LongBreakInterval:         model.DefaultLongBreakInterval,
+		AutoStartBreaks:           input.AutoStartBreaks,
+		AutoStartFocus:            input.AutoStartFocus,
+		Version:                   1,
+		UpdatedAt:                 now,
+	}
+	if apiErr := validateSettings(
+		state.FocusDurationSeconds,
+		state.ShortBreakDurationSeconds,
+		state.LongBreakDurationSeconds,
+		input.LongBreakInterval,
+	); apiErr != nil {
+		return nil, nil, apiErr
+	}
+	if input.LongBreakInterval != nil {
+		state.LongBreakInterval = *input.LongBreakInterval
+	}
+	state.RemainingSeconds = state.FocusDurationSeconds
+
+	room := model.Room{
+		ID:        uuid.NewString(),
+		OwnerID:   userID,
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for attempt := 0; ; attempt++ {
+		code, err := newInviteCode()
+		if err != nil {
+			return nil, nil, apperrors.Internal("failed to generate invite code")
+		}
+		room.InviteCode = code
+
+		err = s.createRoom(ctx, &room, &state)
+		if err == nil {
+			break
+		}
+		if err != repository.ErrDuplicate || attempt+1 >= inviteCodeAttempts {
+			return nil, nil, apperrors.Internal("failed to create room")
+		}
+	}
+
+	view := newRoomStateView(room.ID, s.pomodoroService.toStateView(&state, now))
+	return &room, &view, nil
+}
+
+func (s *RoomService) createRoom(ctx context.Context, room *model.Room, state *model.PomodoroState) error {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.repo.CreateTx(ctx, tx, room, state); err != nil {
+		return err
+	}
+	owner := model.RoomMember{RoomID: room.ID, UserID: room.OwnerID, Role: model.RoomRoleOwner, JoinedAt: room.CreatedAt}
+	if err := s.repo.AddMemberTx(ctx, tx, &owner); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Join adds the user to the room with inviteCode. Joining a room twice is
+// not an error. A member who joins while the timer runs gets a session the
+// next time a phase starts or the owner resumes the timer.
+func (s *RoomService) Join(ctx context.Context, userID, inviteCode string) (*model.Room, *apperrors.APIError) {
+	code := strings.ToUpper(strings.TrimSpace(inviteCode))
+	if code == "" {
+		return nil, apperrors.BadRequest("invalid_invite_code", "inviteCode is required")
+	}
+	room, err := s.repo.GetByInviteCode(ctx, code)
+	if err == repository.ErrNotFound {
+		return nil, apperrors.NotFound("invite_not_found", "invite code is invalid")
+	}
+	if err != nil {
+		return nil, apperrors.Internal("failed to get room")
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	member := model.RoomMember{RoomID: room.ID, UserID: userID, Role: model.RoomRoleMember, JoinedAt: time.Now().UTC()}
+	if err := s.repo.AddMemberTx(ctx, tx, &member); err != nil {
+		if err == repository.ErrDuplicate {
+			return room, nil
+		}
+		return nil, apperrors.Internal("failed to join room")
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		return nil, apperrors.Internal("failed to commit transaction")
+	}
+	return room, nil
+}
+
+// RotateInviteCode replaces the invite code; the old one stops working.
+// Existing members stay in the room.
+func (s *RoomService) RotateInviteCode(ctx context.Context, userID, roomID string) (*model.Room, *apperrors.APIError) {
+	if _, apiErr := s.requireOwner(ctx, roomID, userID); apiErr != nil {
+		return nil, apiErr
+	}
+
+	now := time.Now().UTC()
+	for attempt := 0; ; attempt++ {
+		code, err := newInviteCode()
+		if err != nil {
+			return nil, apperrors.Internal("failed to generate invite code")
+		}
+		err = s.repo.UpdateInviteCode(ctx, roomID, code, now)
+		if err == nil {
+			break
+		}
+		if err == repository.ErrNotFound {
+			return nil, roomNotFoundError()
+		}
+		if err != repository.ErrDuplicate || attempt+1 >= inviteCodeAttempts {
+			return nil, apperrors.Internal("failed to update invite code")
+		}
+	}
+
+	room, err := s.repo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, apperrors.Internal("failed to get room")
+	}
+	return room, nil
+}
+
+// RemoveMember lets a member leave the room, or the owner remove a member.
+// The member's session of the current phase is cancelled.
+func (s *RoomService) RemoveMember(ctx context.Context, userID, roomID, memberID string) *apperrors.APIError {
+	caller, apiErr := s.requireMember(ctx, roomID, userID)
+	if apiErr != nil {
+		return apiErr
+	}
+	if memberID != userID && caller.Role != model.RoomRoleOwner {
+		return roomOwnerRequiredError()
+	}
+	target, err := s.repo.GetMember(ctx, roomID, memberID)
+	if err == repository.ErrNotFound {
+		return apperrors.NotFound("member_not_found", "room member not found")
+	}
+	if err != nil {
+		return apperrors.Internal("failed to get room member")
+	}
+	if target.Role == model.RoomRoleOwner {
+		return apperrors.BadRequest("owner_cannot_leave", "the owner cannot leave the room; delete it instead")
+	}
+
+	now := time.Now().UTC()
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	state, sessions, apiErr := s.getStateForUpdate(ctx, tx, roomID, now)
+	if apiErr != nil {
+		return apiErr
+	}
+	leaving := sessions.only(memberID)
+	remaining := s.pomodoroService.currentRemainingSeconds(state, now)
+	if apiErr := leaving.finish(ctx, tx, state, remaining, false, now); apiErr != nil {
+		return apiErr
+	}
+	if err := s.repo.RemoveMemberTx(ctx, tx, roomID, memberID); err != nil {
+		if err == repository.ErrNotFound {
+			return apperrors.NotFound("member_not_found", "room member not found")
+		}
+		return apperrors.Internal("failed to remove room member")
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		return apperrors.Internal("failed to commit transaction")
+	}
+	s.schedule(roomID, state)
+	return nil
+}
+
+// Delete removes the room after cancelling the sessions of the current
+// phase; completed sessions stay in each member's history.
+func (s *RoomService) Delete(ctx context.Context, userID, roomID string) *apperrors.APIError {
+	if _, apiErr := s.requireOwner(ctx, roomID, userID); apiErr != nil {
+		return apiErr
+	}
+
+	now := time.Now().UTC()
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	state, sessions, apiErr := s.getStateForUpdate(ctx, tx, roomID, now)
+	if apiErr != nil {
+		return apiErr
+	}
+	if apiErr := s.pomodoroService.cancelCurrentSession(ctx, tx, sessions, state, now); apiErr != nil {
+		return apiErr
+	}
+	if err := s.repo.DeleteTx(ctx, tx, roomID); err != nil {
+		if err == repository.ErrNotFound {
+			return roomNotFoundError()
+		}
+		return apperrors.Internal("failed to delete room")
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		return apperrors.Internal("failed to commit transaction")
+	}
+	s.pomodoroService.finalizer.ScheduleRoom(roomID, nil)
+	return nil
+}
+
+func (s *RoomService) GetState(ctx context.Context, userID, roomID string) (*RoomStateView, *apperrors.APIError) {
+	if _, apiErr := s.requireMember(ctx, roomID, userID); apiErr != nil {
+		return nil, apiErr
+	}
+
+	now := time.Now().UTC()
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	state, _, apiErr := s.getStateForUpdate(ctx, tx, roomID, now)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		return nil, apperrors.Internal("failed to commit transaction")
+	}
+	s.schedule(roomID, state)
+
+	view := newRoomStateView(roomID, s.pomodoroService.toStateView(state, now))
+	return &view, nil
+}
+
+func (s *RoomService) Start(ctx context.Context, userID, roomID string, baseVersion int) (*RoomStateView, *apperrors.APIError) {
+	return s.transition(ctx, userID, roomID, baseVersion, func(tx *sql.Tx, sessions sessionTracker, state *model.PomodoroState, now time.Time) (bool, *apperrors.APIError) {
+		return s.pomodoroService.applyStart(ctx, tx, sessions, state, now)
+	})
+}
+
+func (s *RoomService) Pause(ctx context.Context, userID, roomID string, baseVersion int) (*RoomStateView, *apperrors.APIError) {
+	return s.transition(ctx, userID, roomID, baseVersion, func(tx *sql.Tx, sessions sessionTracker, state *model.PomodoroState, now time.Time) (bool, *apperrors.APIError) {
+		return s.pomodoroService.applyPause(ctx, tx, sessions, state, now)
+	})
+}
+
+func (s *RoomService) Reset(ctx context.Context, userID, roomID string, baseVersion int) (*RoomStateView, *apperrors.APIError) {
+	return s.transition(ctx, userID, roomID, baseVersion, func(tx *sql.Tx, sessions sessionTracker, state *model.PomodoroState, now time.Time) (bool, *apperrors.APIError) {
+		return true, s.pomodoroService.applyReset(ctx, tx, sessions, state, now)
+	})
+}
+
+func (s *RoomService) SwitchMode(ctx context.Context, userID, roomID, mode string, baseVersion int) (*RoomStateView, *apperrors.APIError) {
+	// Members have their own custom modes, so a shared timer keeps to the
+	// built-in ones.
+	if !isBuiltInMode(mode) {
+		return nil, apperrors.BadRequest("invalid_mode", "mode must be one of focus, short_break, long_break")
+	}
+	return s.transition(ctx, userID, roomID, baseVersion, func(tx *sql.Tx, sessions sessionTracker, state *model.PomodoroState, now time.Time) (bool, *apperrors.APIError) {
+		return true, s.pomodoroService.applySwitchMode(ctx, tx, sessions, state, mode, now)
+	})
+}
+
+// transition applies an owner command to the room timer under the same
+// baseVersion check as the personal timer.
+func (s *RoomService) transition(ctx context.Context, userID, roomID string, baseVersion int, apply roomTransition) (*RoomStateView, *apperrors.APIError) {
+	if _, apiErr := s.requireOwner(ctx, roomID, userID); apiErr != nil {
+		return nil, apiErr
+	}
+
+	now := time.Now().UTC()
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	state, sessions, apiErr := s.getStateForUpdate(ctx, tx, roomID, now)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if baseVersion > 0 && baseVersion != state.Version {
+		details := map[string]interface{}{
+			"state": newRoomStateView(roomID, s.pomodoroService.toStateView(state, now)),
+		}
+		return nil, apperrors.Conflict("state_conflict", "room timer changed", details)
+	}
+
+	changed, apiErr := apply(tx, sessions, state, now)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if changed {
+		if apiErr := s.saveState(ctx, tx, roomID, state, now); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		return nil, apperrors.Internal("failed to commit transaction")
+	}
+	s.schedule(roomID, state)
+
+	view := newRoomStateView(roomID, s.pomodoroService.toStateView(state, now))
+	return &view, nil
+}
+
+// getStateForUpdate loads the room timer and settles phases that ran out,
+// completing every member's session at the phase deadline.
+func (s *RoomService) getStateForUpdate(ctx context.Context, tx *sql.Tx, roomID string, now time.Time) (*model.PomodoroState, *roomSessions, *apperrors.APIError) {
+	state, err := s.repo.GetStateTx(ctx, tx, roomID)
+	if err == repository.ErrNotFound {
+		return nil, nil, roomNotFoundError()
+	}
+	if err != nil {
+		return nil, nil, apperrors.Internal("failed to get room state")
+	}
+	members, err := s.repo.ListMembersTx(ctx, tx, roomID)
+	if err != nil {
+		return nil, nil, apperrors.Internal("failed to list room members")
+	}
+
+	sessions := &roomSessions{s: s, roomID: roomID, members: members}
+	events, apiErr := s.pomodoroService.advanceExpired(ctx, tx, sessions, state, now)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+	if len(events) > 0 {
+		if apiErr := s.saveState(ctx, tx, roomID, state, now); apiErr != nil {
+			return nil, nil, apiErr
+		}
+	}
+	return state, sessions, nil
+}
+
+// finalize settles the room timer for the session finalizer and schedules
+// its next deadline, if any.
+func (s *RoomService) finalize(ctx context.Context, roomID string) *apperrors.APIError {
+	now := time.Now().UTC()
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	state, _, apiErr := s.getStateForUpdate(ctx, tx, roomID, now)
+	if apiErr != nil {
+		if apiErr.Status == http.StatusNotFound {
+			// A deleted room has no timer left to finish.
+			return nil
+		}
+		return apiErr
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		return apperrors.Internal("failed to commit transaction")
+	}
+	s.schedule(roomID, state)
+	return nil
+}
+
+// schedule hands the deadline of a committed room timer to the finalizer.
+func (s *RoomService) schedule(roomID string, state *model.PomodoroState) {
+	s.pomodoroService.finalizer.ScheduleRoom(roomID, runningDeadline(state))
+}
+
+func (s *RoomService) saveState(ctx context.Context, tx *sql.Tx, roomID string, state *model.PomodoroState, now time.Time) *apperrors.APIError {
+	state.UpdatedAt = now
+	state.Version++
+	if err := s.repo.UpdateStateTx(ctx, tx, roomID, state); err != nil {
+		return apperrors.Internal("failed to update room state")
+	}
+	return nil
+}
+
+// requireMember hides rooms the user does not belong to behind a 404.
+func (s *RoomService) requireMember(ctx context.Context, roomID, userID string) (*model.RoomMember, *apperrors.APIError) {
+	member, err := s.repo.GetMember(ctx, roomID, userID)
+	if err == repository.ErrNotFound {
+		return nil, roomNotFoundError()
+	}
+	if err != nil {
+		return nil, apperrors.Internal("failed to get room member")
+	}
+	return member, nil
+}
+
+func (s *RoomService) requireOwner(ctx context.Context, roomID, userID string) (*model.RoomMember, *apperrors.APIError) {
+	member, apiErr := s.requireMember(ctx, roomID, userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if member.Role != model.RoomRoleOwner {
+		return nil, roomOwnerRequiredError()
+	}
+	return member, nil
+}
+
+// roomSessions gives each member of a room a session of their own for every
+// phase of the room timer.
+type roomSessions struct {
+	s       *RoomService
+	roomID  string
+	members []model.RoomMember
+}
+
+func (t *roomSessions) start(ctx context.Context, tx *sql.Tx, state *model.PomodoroState, at time.Time) *apperrors.APIError {
+	for i := range t.members {
+		member := &t.members[i]
+		if member.SessionID != nil {
+			if apiErr := t.s.pomodoroService.resumeSession(ctx, tx, *member.SessionID, at); apiErr != nil {
+				return apiErr
+			}
+			continue
+		}
+		sessionID, apiErr := t.s.pomodoroService.insertSession(ctx, tx, member.UserID, nil, state, at)
+		if apiErr != nil {
+			return apiErr
+		}
+		if err := t.s.repo.SetMemberSessionTx(ctx, tx, t.roomID, member.UserID, &sessionID); err != nil {
+			return apperrors.Internal("failed to update room member")
+		}
+		member.SessionID = &sessionID
+	}
+	return nil
+}
+
+func (t *roomSessions) pause(ctx context.Context, tx *sql.Tx, state *model.PomodoroState, at time.Time) *apperrors.APIError {
+	for _, member := range t.members {
+		if member.SessionID == nil {
+			continue
+		}
+		if apiErr := t.s.pomodoroService.pauseSession(ctx, tx, *member.SessionID, at); apiErr != nil {
+			return apiErr
+		}
+	}
+	return nil
+}
+
+func (t *roomSessions) finish(
+	ctx context.Context,
+	tx *sql.Tx,
+	state *model.PomodoroState,
+	remainingSeconds int,
+	completed bool,
+	at time.Time,
+) *apperrors.APIError {
+	for i := range t.members {
+		member := &t.members[i]
+		if member.SessionID == nil {
+			continue
+		}
+		if apiErr := t.s.pomodoroService.finishSession(ctx, tx, *member.SessionID, remainingSeconds, completed, at); apiErr != nil {
+			return apiErr
+		}
+		if err := t.s.repo.SetMemberSessionTx(ctx, tx, t.roomID, member.UserID, nil); err != nil {
+			return apperrors.Internal("failed to update room member")
+		}
+		member.SessionID = nil
+	}
+	return nil
+}
+
+// only narrows the tracker to one member.
+func (t *roomSessions) only(userID string) *roomSessions {
+	narrowed := &roomSessions{s: t.s, roomID: t.roomID}
+	for _, member := range t.members {
+		if member.UserID == userID {
+			narrowed.members = append(narrowed.members, member)
+		}
+	}
+	return narrowed
+}
+
+func newRoomStateView(roomID string, view StateView) RoomStateView {
+	return RoomStateView{
+		RoomID:                    roomID,
+		Mode:                      view.Mode,
+		Status:                    view.Status,
+		RemainingSeconds:          view.RemainingSeconds,
+		FocusDurationSeconds:      view.FocusDurationSeconds,
+		ShortBreakDurationSeconds: view.ShortBreakDurationSeconds,
+		LongBreakDurationSeconds:  view.LongBreakDurationSeconds,
+		CompletedFocusCount:       view.CompletedFocusCount,
+		LongBreakInterval:         view.LongBreakInterval,
+		AutoStartBreaks:           view.AutoStartBreaks,
+		AutoStartFocus:            view.AutoStartFocus,
+		StartedAt:                 view.StartedAt,
+		Version:                   view.Version,
+		UpdatedAt:                 view.UpdatedAt,
+		ServerTime:                view.ServerTime,
+	}
+}
+
+func newInviteCode() (string, error) {
+	buf := make([]byte, inviteCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = inviteCodeAlphabet[int(b)%len(inviteCodeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+func orDefault(value, fallback int) int {
+	if value == 0 {
+		return fallback
+	}
+	return value
+}
+
+func roomNotFoundError() *apperrors.APIError {
+	return apperrors.NotFound("room_not_found", "room not found")
+}
+
+func roomOwnerRequiredError() *apperrors.APIError {
+	return apperrors.New(http.StatusForbidden, "room_owner_required", "only the room owner can do this")
+}
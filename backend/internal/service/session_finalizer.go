@@ -0,0 +1,265 @@
+package service
+
+import (
+	"container/heap"
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/model"
+	"pomodoro/backend/internal/repository"
+)
+
+// SessionFinalizer holds the deadline of every running timer, personal or
+// room, so sessions complete when they end rather than on the next request.
+// It is in-process: each server instance finalizes the timers
+// it has seen change and rescans the database now and then for the rest.
+//
+// Reads and writes still settle expired timers on their own. Both paths
+// settle under the state row lock and only act on a deadline that has
+// passed, so whichever runs first completes the session and the other
+// finds nothing left to do.
+type SessionFinalizer struct {
+	mu      sync.Mutex
+	entries map[timerKey]*deadlineEntry
+	queue   deadlineQueue
+	wake    chan struct{}
+}
+
+// timerKey names a timer: the personal timer of a user, or a room timer
+// when room is set.
+type timerKey struct {
+	id   string
+	room bool
+}
+
+type deadlineEntry struct {
+	key      timerKey
+	deadline time.Time
+	index    int
+}
+
+// deadlineQueue is a min-heap of deadlines.
+type deadlineQueue []*deadlineEntry
+
+func (q deadlineQueue) Len() int           { return len(q) }
+func (q deadlineQueue) Less(i, j int) bool { return q[i].deadline.Before(q[j].deadline) }
+
+func (q deadlineQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *deadlineQueue) Push(x interface{}) {
+	entry := x.(*deadlineEntry)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *deadlineQueue) Pop() interface{} {
+	old := *q
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return entry
+}
+
+func NewSessionFinalizer() *SessionFinalizer {
+	return &SessionFinalizer{
+		entries: make(map[timerKey]*deadlineEntry),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Schedule sets when the timer of userID runs out; a nil deadline means it
+// is not running. A nil finalizer ignores every call.
+func (f *SessionFinalizer) Schedule(userID string, deadline *time.Time) {
+	f.schedule(timerKey{id: userID}, deadline)
+}
+
+// ScheduleRoom is Schedule for the timer of a room.
+func (f *SessionFinalizer) ScheduleRoom(roomID string, deadline *time.Time) {
+	f.schedule(timerKey{id: roomID, room: true}, deadline)
+}
+
+func (f *SessionFinalizer) schedule(key timerKey, deadline *time.Time) {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	entry, ok := f.entries[key]
+	switch {
+	case deadline == nil && ok:
+		heap.Remove(&f.queue, entry.index)
+		delete(f.entries, key)
+	case deadline != nil && ok:
+		entry.deadline = *deadline
+		heap.Fix(&f.queue, entry.index)
+	case deadline != nil:
+		entry = &deadlineEntry{key: key, deadline: *deadline}
+		heap.Push(&f.queue, entry)
+		f.entries[key] = entry
+	}
+	f.mu.Unlock()
+
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next reports the earliest deadline.
+func (f *SessionFinalizer) next() (time.Time, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.queue) == 0 {
+		return time.Time{}, false
+	}
+	return f.queue[0].deadline, true
+}
+
+// popDue removes and returns the timers whose deadline is not after now.
+func (f *SessionFinalizer) popDue(now time.Time) []timerKey {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]timerKey, 0)
+	for len(f.queue) > 0 && !f.queue[0].deadline.After(now) {
+		entry := heap.Pop(&f.queue).(*deadlineEntry)
+		delete(f.entries, entry.key)
+		keys = append(keys, entry.key)
+	}
+	return keys
+}
+
+// RunFinalizer completes running sessions at their deadlines until ctx is
+// done. A finalization in progress when ctx ends runs to completion, so
+// returning means no transaction is left open. Running timers are reloaded
+// from the database every rescanInterval, which picks up timers started
+// through another server instance and retries failed finalizations. Room
+// timers are settled through rooms.
+func (s *PomodoroService) RunFinalizer(ctx context.Context, rescanInterval time.Duration, rooms *RoomService) {
+	if s.finalizer == nil {
+		return
+	}
+
+	s.rescanDeadlines(ctx, rooms)
+	rescan := time.NewTicker(rescanInterval)
+	defer rescan.Stop()
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		var due <-chan time.Time
+		if deadline, ok := s.finalizer.next(); ok {
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(time.Until(deadline))
+			due = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.finalizer.wake:
+		case <-rescan.C:
+			s.rescanDeadlines(ctx, rooms)
+		case <-due:
+			for _, key := range s.finalizer.popDue(time.Now()) {
+				if key.room {
+					if apiErr := rooms.finalize(context.WithoutCancel(ctx), key.id); apiErr != nil {
+						log.Printf("finalizer: settle timer of room %s: %s", key.id, apiErr.Message)
+					}
+					continue
+				}
+				if apiErr := s.finalize(context.WithoutCancel(ctx), key.id); apiErr != nil {
+					log.Printf("finalizer: settle timer of user %s: %s", key.id, apiErr.Message)
+				}
+			}
+		}
+	}
+}
+
+func (s *PomodoroService) rescanDeadlines(ctx context.Context, rooms *RoomService) {
+	states, err := s.repo.ListRunningStates(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("finalizer: list running timers: %v", err)
+		}
+		return
+	}
+	for i := range states {
+		s.finalizer.Schedule(states[i].UserID, runningDeadline(&states[i]))
+	}
+
+	running, err := rooms.repo.ListRunningStates(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("finalizer: list running rooms: %v", err)
+		}
+		return
+	}
+	for i := range running {
+		s.finalizer.ScheduleRoom(running[i].RoomID, runningDeadline(&running[i].State))
+	}
+}
+
+// finalize settles the timer of userID the way a read would. When the
+// deadline has not passed yet, because the timer was changed since it was
+// scheduled, the current deadline is scheduled again.
+func (s *PomodoroService) finalize(ctx context.Context, userID string) *apperrors.APIError {
+	now := time.Now().UTC()
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return apperrors.Internal("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	state, err := s.repo.GetStateTx(ctx, tx, userID)
+	if err == repository.ErrNotFound {
+		// A deleted account has no timer left to finish.
+		return nil
+	}
+	if err != nil {
+		return apperrors.Internal("failed to get state")
+	}
+
+	loadedVersion := state.Version
+	if apiErr := s.normalizeCompletedSession(ctx, tx, state, now); apiErr != nil {
+		return apiErr
+	}
+	if state.Version == loadedVersion {
+		s.finalizer.Schedule(userID, runningDeadline(state))
+		return nil
+	}
+
+	view, apiErr := s.stateView(ctx, tx, state, now)
+	if apiErr != nil {
+		return apiErr
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		return apperrors.Internal("failed to commit transaction")
+	}
+
+	s.publish(state, view)
+	return nil
+}
+
+// runningDeadline is when the current phase of a running timer ends, or nil
+// when the timer is not running.
+func runningDeadline(state *model.PomodoroState) *time.Time {
+	if state.Status != model.StatusRunning || state.StartedAt == nil {
+		return nil
+	}
+	deadline := state.StartedAt.Add(time.Duration(state.RemainingSeconds) * time.Second)
+	return &deadline
+}
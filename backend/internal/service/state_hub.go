@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"pomodoro/backend/internal/repository"
+)
+
+const (
+	stateSubscriberBuffer = 8
+	stateRelayTimeout     = 5 * time.Second
+)
+
+// StateHub fans out state snapshots to every live subscriber of a user.
+// Each server instance keeps its own subscribers; with a relay, snapshots
+// published on one instance also reach the subscribers of the others.
+type StateHub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan StateView]struct{}
+	closed      bool
+
+	relay  repository.StateRelay
+	origin string
+}
+
+// relayedState is what travels through the relay. Origin tells an instance
+// its own snapshots apart, which it has already delivered.
+type relayedState struct {
+	Origin string    `json:"origin"`
+	UserID string    `json:"userId"`
+	View   StateView `json:"view"`
+}
+
+func NewStateHub() *StateHub {
+	return &StateHub{subscribers: make(map[string]map[chan StateView]struct{})}
+}
+
+// NewRelayedStateHub shares snapshots with the other instances behind
+// relay; RunRelay must run for those of the others to arrive.
+func NewRelayedStateHub(relay repository.StateRelay) *StateHub {
+	hub := NewStateHub()
+	hub.relay = relay
+	hub.origin = uuid.NewString()
+	return hub
+}
+
+func (h *StateHub) Subscribe(userID string) (<-chan StateView, func()) {
+	ch := make(chan StateView, stateSubscriberBuffer)
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		close(ch)
+		return ch, func() {}
+	}
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan StateView]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if _, ok := h.subscribers[userID][ch]; !ok {
+				// Close already closed it.
+				return
+			}
+			delete(h.subscribers[userID], ch)
+			if len(h.subscribers[userID]) == 0 {
+				delete(h.subscribers, userID)
+			}
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
+
+// Publish hands view to the relay, if any, and to the local subscribers.
+func (h *StateHub) Publish(userID string, view StateView) {
+	h.deliver(userID, view)
+	if h.relay == nil {
+		return
+	}
+
+	payload, err := json.Marshal(relayedState{Origin: h.origin, UserID: userID, View: view})
+	if err != nil {
+		log.Printf("state relay: encode state of user %s: %v", userID, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), stateRelayTimeout)
+	defer cancel()
+	if err := h.relay.Notify(ctx, payload); err != nil {
+		log.Printf("state relay: publish state of user %s: %v", userID, err)
+	}
+}
+
+// RunRelay delivers the snapshots published on other instances until ctx
+// is done. Arriving out of order with local ones is harmless: streams skip
+// any snapshot not newer than the last they sent.
+func (h *StateHub) RunRelay(ctx context.Context) {
+	if h.relay == nil {
+		return
+	}
+
+	err := h.relay.Listen(ctx, func(payload []byte) {
+		var message relayedState
+		if err := json.Unmarshal(payload, &message); err != nil {
+			log.Printf("state relay: decode state: %v", err)
+			return
+		}
+		if message.Origin != h.origin {
+			h.deliver(message.UserID, message.View)
+		}
+	})
+	if err != nil {
+		log.Printf("state relay: %v", err)
+	}
+}
+
+// deliver never blocks: a subscriber that falls behind loses its oldest
+// pending snapshot, which is safe because every snapshot is a full state.
+func (h *StateHub) deliver(userID string, view StateView) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers[userID] {
+		select {
+		case ch <- view:
+			continue
+		default:
+		}
+
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- view:
+		default:
+		}
+	}
+}
+
+// Close closes every subscription, now and from then on, which ends the
+// event streams and sockets reading them. It is meant for server shutdown,
+// which does not wait for such long-lived requests by itself.
+func (h *StateHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for userID, subscribers := range h.subscribers {
+		for ch := range subscribers {
+			close(ch)
+		}
+		delete(h.subscribers, userID)
+	}
+}
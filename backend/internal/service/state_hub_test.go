@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryRelay broadcasts to every Listen call, like NOTIFY does to every
+// listening connection, the sender's included.
+type memoryRelay struct {
+	mu        sync.Mutex
+	listeners []chan []byte
+	joined    sync.WaitGroup
+}
+
+func (r *memoryRelay) Notify(_ context.Context, payload []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, listener := range r.listeners {
+		listener <- payload
+	}
+	return nil
+}
+
+func (r *memoryRelay) Listen(ctx context.Context, receive func(payload []byte)) error {
+	listener := make(chan []byte, 16)
+	r.mu.Lock()
+	r.listeners = append(r.listeners, listener)
+	r.mu.Unlock()
+	r.joined.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case payload := <-listener:
+			receive(payload)
+		}
+	}
+}
+
+func TestRelayedStateHub(t *testing.T) {
+	relay := &memoryRelay{}
+	relay.joined.Add(2)
+	first := NewRelayedStateHub(relay)
+	second := NewRelayedStateHub(relay)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, hub := range []*StateHub{first, second} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hub.RunRelay(ctx)
+		}()
+	}
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	relay.joined.Wait()
+
+	local, unsubscribeLocal := first.Subscribe("user-1")
+	defer unsubscribeLocal()
+	remote, unsubscribeRemote := second.Subscribe("user-1")
+	defer unsubscribeRemote()
+	other, unsubscribeOther := second.Subscribe("user-2")
+	defer unsubscribeOther()
+
+	first.Publish("user-1", StateView{UserID: "user-1", Version: 2})
+
+	for name, ch := range map[string]<-chan StateView{"local": local, "remote": remote} {
+		select {
+		case view := <-ch:
+			if view.Version != 2 {
+				t.Fatalf("%s subscriber got version %d, want 2", name, view.Version)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s subscriber got no state", name)
+		}
+	}
+
+	// The publishing hub already delivered its own snapshot, and no other
+	// user hears about it.
+	select {
+	case view := <-local:
+		t.Fatalf("local subscriber got version %d twice", view.Version)
+	case view := <-other:
+		t.Fatalf("other user got state of version %d", view.Version)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
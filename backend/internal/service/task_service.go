@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/model"
+	"pomodoro/backend/internal/repository"
+)
+
+const maxTaskTitleLength = 200
+
+type TaskService struct {
+	repo            repository.TaskStore
+	pomodoroService *PomodoroService
+}
+
+type CreateTaskInput struct {
+	Title              string
+	Notes              string
+	EstimatedPomodoros int
+}
+
+type UpdateTaskInput struct {
+	Title              *string
+	Notes              *string
+	Status             *string
+	EstimatedPomodoros *int
+}
+
+func NewTaskService(repo repository.TaskStore, pomodoroService *PomodoroService) *TaskService {
+	return &TaskService{repo: repo, pomodoroService: pomodoroService}
+}
+
+func (s *TaskService) List(ctx context.Context, userID, status string) ([]model.Task, *apperrors.APIError) {
+	if status != "" && !isValidTaskStatus(status) {
+		return nil, apperrors.BadRequest("invalid_status", "status must be one of open, done")
+	}
+	tasks, err := s.repo.List(ctx, userID, status)
+	if err != nil {
+		return nil, apperrors.Internal("failed to list tasks")
+	}
+	return tasks, nil
+}
+
+func (s *TaskService) Get(ctx context.Context, userID, taskID string) (*model.Task, *apperrors.APIError) {
+	task, err := s.repo.GetByID(ctx, userID, taskID)
+	if err == repository.ErrNotFound {
+		return nil, apperrors.NotFound("task_not_found", "task not found")
+	}
+	if err != nil {
+		return nil, apperrors.Internal("failed to get task")
+	}
+	return task, nil
+}
+
+func (s *TaskService) Create(ctx context.Context, userID string, input CreateTaskInput) (*model.Task, *apperrors.APIError) {
+	title, apiErr := normalizeTaskTitle(input.Title)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if input.EstimatedPomodoros < 0 {
+		return nil, apperrors.BadRequest("invalid_estimate", "estimatedPomodoros must not be negative")
+	}
+
+	now := time.Now().UTC()
+	task := model.Task{
+		ID:                 uuid.NewString(),
+		UserID:             userID,
+		Title:              title,
+		Notes:              strings.TrimSpace(input.Notes),
+		Status:             model.TaskStatusOpen,
+		EstimatedPomodoros: input.EstimatedPomodoros,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	if err := s.repo.Create(ctx, &task); err != nil {
+		return nil, apperrors.Internal("failed to create task")
+	}
+	return &task, nil
+}
+
+func (s *TaskService) Update(ctx context.Context, userID, taskID string, input UpdateTaskInput) (*model.Task, *apperrors.APIError) {
+	task, apiErr := s.Get(ctx, userID, taskID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if input.Title != nil {
+		title, apiErr := normalizeTaskTitle(*input.Title)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		task.Title = title
+	}
+	if input.Notes != nil {
+		task.Notes = strings.TrimSpace(*input.Notes)
+	}
+	if input.Status != nil {
+		if !isValidTaskStatus(*input.Status) {
+			return nil, apperrors.BadRequest("invalid_status", "status must be one of open, done")
+		}
+		task.Status = *input.Status
+	}
+	if input.EstimatedPomodoros != nil {
+		if *input.EstimatedPomodoros < 0 {
+			return nil, apperrors.BadRequest("invalid_estimate", "estimatedPomodoros must not be negative")
+		}
+		task.EstimatedPomodoros = *input.EstimatedPomodoros
+	}
+	task.UpdatedAt = time.Now().UTC()
+
+	if err := s.repo.Update(ctx, task); err != nil {
+		if err == repository.ErrNotFound {
+			return nil, apperrors.NotFound("task_not_found", "task not found")
+		}
+		return nil, apperrors.Internal("failed to update task")
+	}
+	return task, nil
+}
+
+func (s *TaskService) Delete(ctx context.Context, userID, taskID string) *apperrors.APIError {
+	return s.pomodoroService.DeleteTask(ctx, userID, taskID)
+}
+
+func normalizeTaskTitle(title string) (string, *apperrors.APIError) {
+	trimmed := strings.TrimSpace(title)
+	if trimmed == "" {
+		return "", apperrors.BadRequest("invalid_title", "title is required")
+	}
+	if utf8.RuneCountInString(trimmed) > maxTaskTitleLength {
+		return "", apperrors.BadRequest("invalid_title", "title must be at most 200 characters")
+	}
+	return trimmed, nil
+}
+
+func isValidTaskStatus(status string) bool {
+	return status == model.TaskStatusOpen || status == model.TaskStatusDone
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pomodoro/backend/internal/model"
+	"pomodoro/backend/internal/repository"
+)
+
+const (
+	WebhookEventHeader     = "X-Pomodoro-Event"
+	WebhookDeliveryHeader  = "X-Pomodoro-Delivery"
+	WebhookTimestampHeader = "X-Pomodoro-Timestamp"
+	WebhookSignatureHeader = "X-Pomodoro-Signature"
+
+	webhookUserAgent  = "Pomodoro-Webhooks/1.0"
+	webhookBatchSize  = 20
+	maxWebhookError   = 500
+	maxWebhookReadout = 64 << 10
+	// webhookLeaseMargin keeps a claimed delivery from being picked up again
+	// while its request may still be running.
+	webhookLeaseMargin = 30 * time.Second
+)
+
+// RunDeliveries sends queued webhook deliveries until ctx is done. A request
+// in flight when ctx ends is finished and recorded before it returns.
+// Deliveries are claimed before they are sent, so several server instances
+// can run the worker against the same database.
+func (s *WebhookService) RunDeliveries(ctx context.Context) {
+	ticker := time.NewTicker(s.settings.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.deliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *WebhookService) deliverDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		due, err := s.repo.ListDueDeliveries(ctx, time.Now().UTC(), webhookBatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("webhooks: list due deliveries: %v", err)
+			}
+			return
+		}
+		for _, item := range due {
+			if ctx.Err() != nil {
+				return
+			}
+			s.attempt(context.WithoutCancel(ctx), item)
+		}
+		if len(due) < webhookBatchSize {
+			return
+		}
+	}
+}
+
+// attempt sends one delivery and records the outcome: delivered on a 2xx
+// response, otherwise retried with exponential backoff until the attempts
+// run out. Deliveries of a disabled webhook fail without being sent.
+func (s *WebhookService) attempt(ctx context.Context, item repository.DueDelivery) {
+	delivery := item.Delivery
+	now := time.Now().UTC()
+	if err := s.repo.ClaimDelivery(ctx, &delivery, now.Add(s.settings.Timeout+webhookLeaseMargin)); err != nil {
+		if err != repository.ErrNotFound {
+			log.Printf("webhooks: claim delivery %d: %v", delivery.ID, err)
+		}
+		return
+	}
+
+	var responseStatus *int
+	var sendErr error
+	if item.Active {
+		responseStatus, sendErr = s.send(ctx, item.URL, item.Secret, &delivery, now)
+	} else {
+		sendErr = fmt.Errorf("webhook is disabled")
+	}
+
+	finishedAt := time.Now().UTC()
+	delivery.LastAttemptAt = &finishedAt
+	delivery.ResponseStatus = responseStatus
+	delivery.NextAttemptAt = nil
+	switch {
+	case sendErr == nil:
+		delivery.Status = model.DeliveryStatusDelivered
+		delivery.DeliveredAt = &finishedAt
+		delivery.LastError = nil
+	case !item.Active || delivery.Attempts >= s.settings.MaxAttempts:
+		delivery.Status = model.DeliveryStatusFailed
+		delivery.LastError = webhookError(sendErr)
+	default:
+		nextAttemptAt := finishedAt.Add(s.retryDelay(delivery.Attempts))
+		delivery.NextAttemptAt = &nextAttemptAt
+		delivery.LastError = webhookError(sendErr)
+	}
+
+	if err := s.repo.RecordAttempt(ctx, &delivery); err != nil {
+		log.Printf("webhooks: record attempt of delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// send posts the payload signed with the webhook secret. The signature is
+// the hex HMAC-SHA256 of "<timestamp>.<body>", so a receiver can reject
+// replayed requests by their timestamp.
+func (s *WebhookService) send(
+	ctx context.Context,
+	url string,
+	secret string,
+	delivery *model.WebhookDelivery,
+	at time.Time,
+) (*int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, err
+	}
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", webhookUserAgent)
+	request.Header.Set(WebhookEventHeader, delivery.EventType)
+	request.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	request.Header.Set(WebhookTimestampHeader, timestamp)
+	request.Header.Set(WebhookSignatureHeader, "sha256="+signWebhook(secret, timestamp, delivery.Payload))
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, maxWebhookReadout))
+
+	status := response.StatusCode
+	if status < http.StatusOK || status >= http.StatusMultipleChoices {
+		return &status, fmt.Errorf("receiver responded with status %d", status)
+	}
+	return &status, nil
+}
+
+// retryDelay is the wait after the given number of failed attempts.
+func (s *WebhookService) retryDelay(attempts int) time.Duration {
+	delay := s.settings.RetryBase
+	for i := 1; i < attempts && delay < s.settings.RetryMax; i++ {
+		delay *= 2
+	}
+	if delay > s.settings.RetryMax {
+		return s.settings.RetryMax
+	}
+	return delay
+}
+
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func webhookError(err error) *string {
+	message := err.Error()
+	if len(message) > maxWebhookError {
+		message = message[:maxWebhookError]
+	}
+	return &message
+}
@@ -0,0 +1,387 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+
+	apperrors "pomodoro/backend/internal/errors"
+	"pomodoro/backend/internal/model"
+	"pomodoro/backend/internal/repository"
+)
+
+const (
+	maxWebhooksPerUser      = 10
+	maxWebhookURLLength     = 2048
+	defaultDeliveryLogLimit = 50
+	maxDeliveryLogLimit     = 200
+)
+
+// webhookEvents lists the events a webhook can subscribe to, in the order
+// they are stored.
+var webhookEvents = []string{
+	model.WebhookEventSessionStarted,
+	model.WebhookEventSessionPaused,
+	model.WebhookEventSessionResumed,
+	model.WebhookEventSessionCompleted,
+	model.WebhookEventSessionCancelled,
+}
+
+var errPrivateWebhookAddress = errors.New("webhook host resolves to a loopback, private or otherwise non-public address")
+
+// WebhookDeliverySettings tunes the delivery worker. A failed attempt is
+// retried after RetryBase, doubling each time up to RetryMax, until
+// MaxAttempts attempts were made. AllowPrivateNetworks lifts the
+// restriction to public hosts, for local development and tests.
+type WebhookDeliverySettings struct {
+	PollInterval         time.Duration
+	Timeout              time.Duration
+	MaxAttempts          int
+	RetryBase            time.Duration
+	RetryMax             time.Duration
+	AllowPrivateNetworks bool
+}
+
+type WebhookService struct {
+	repo     repository.WebhookStore
+	client   *http.Client
+	settings WebhookDeliverySettings
+}
+
+// WebhookInput describes a whole webhook; Active defaults to true.
+type WebhookInput struct {
+	URL    string
+	Events []string
+	Active *bool
+}
+
+type DeliveryLogQuery struct {
+	Limit  int
+	Cursor string
+	Status string
+}
+
+type DeliveryLogPage struct {
+	Deliveries []model.WebhookDelivery `json:"deliveries"`
+	NextCursor *string                 `json:"nextCursor"`
+}
+
+// NewWebhookService builds the delivery client. Unless private networks are
+// allowed, it refuses to connect to any non-public address; the check runs
+// on the resolved address of every connection, so a public name pointing
+// at an internal host is refused as well. No proxy is used, since the check
+// would only see the proxy.
+func NewWebhookService(repo repository.WebhookStore, settings WebhookDeliverySettings) *WebhookService {
+	dialer := &net.Dialer{Timeout: settings.Timeout, KeepAlive: 30 * time.Second}
+	if !settings.AllowPrivateNetworks {
+		dialer.Control = refusePrivateAddress
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &WebhookService{
+		repo: repo,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   settings.Timeout,
+			// A redirect counts as a failed delivery rather than sending the
+			// signed payload somewhere else.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		settings: settings,
+	}
+}
+
+func (s *WebhookService) List(ctx context.Context, userID string) ([]model.Webhook, *apperrors.APIError) {
+	webhooks, err := s.repo.List(ctx, userID)
+	if err != nil {
+		return nil, apperrors.Internal("failed to list webhooks")
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, nil
+}
+
+func (s *WebhookService) Get(ctx context.Context, userID, webhookID string) (*model.Webhook, *apperrors.APIError) {
+	webhook, apiErr := s.load(ctx, userID, webhookID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// Create returns the webhook with its signing secret, which is not shown
+// again.
+func (s *WebhookService) Create(ctx context.Context, userID string, input WebhookInput) (*model.Webhook, *apperrors.APIError) {
+	webhook := model.Webhook{ID: uuid.NewString(), UserID: userID}
+	if apiErr := s.applyWebhookInput(&webhook, input); apiErr != nil {
+		return nil, apiErr
+	}
+
+	existing, err := s.repo.List(ctx, userID)
+	if err != nil {
+		return nil, apperrors.Internal("failed to list webhooks")
+	}
+	if len(existing) >= maxWebhooksPerUser {
+		return nil, apperrors.Conflict("webhook_limit_reached", "at most 10 webhooks per account", nil)
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return nil, apperrors.Internal("failed to generate secret")
+	}
+	now := time.Now().UTC()
+	webhook.Secret = secret
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+	if err := s.repo.Create(ctx, &webhook); err != nil {
+		return nil, apperrors.Internal("failed to create webhook")
+	}
+	return &webhook, nil
+}
+
+func (s *WebhookService) Update(ctx context.Context, userID, webhookID string, input WebhookInput) (*model.Webhook, *apperrors.APIError) {
+	webhook, apiErr := s.load(ctx, userID, webhookID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := s.applyWebhookInput(webhook, input); apiErr != nil {
+		return nil, apiErr
+	}
+
+	webhook.UpdatedAt = time.Now().UTC()
+	if err := s.repo.Update(ctx, webhook); err != nil {
+		if err == repository.ErrNotFound {
+			return nil, webhookNotFoundError()
+		}
+		return nil, apperrors.Internal("failed to update webhook")
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+func (s *WebhookService) Delete(ctx context.Context, userID, webhookID string) *apperrors.APIError {
+	if err := s.repo.Delete(ctx, userID, webhookID); err != nil {
+		if err == repository.ErrNotFound {
+			return webhookNotFoundError()
+		}
+		return apperrors.Internal("failed to delete webhook")
+	}
+	return nil
+}
+
+// RotateSecret replaces the signing secret; deliveries sent from now on,
+// including retries, are signed with the new one.
+func (s *WebhookService) RotateSecret(ctx context.Context, userID, webhookID string) (*model.Webhook, *apperrors.APIError) {
+	webhook, apiErr := s.load(ctx, userID, webhookID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return nil, apperrors.Internal("failed to generate secret")
+	}
+	now := time.Now().UTC()
+	if err := s.repo.UpdateSecret(ctx, userID, webhookID, secret, now); err != nil {
+		if err == repository.ErrNotFound {
+			return nil, webhookNotFoundError()
+		}
+		return nil, apperrors.Internal("failed to rotate webhook secret")
+	}
+	webhook.Secret = secret
+	webhook.UpdatedAt = now
+	return webhook, nil
+}
+
+// ListDeliveries pages through the delivery log of a webhook, newest first.
+func (s *WebhookService) ListDeliveries(
+	ctx context.Context,
+	userID string,
+	webhookID string,
+	query DeliveryLogQuery,
+) (*DeliveryLogPage, *apperrors.APIError) {
+	if _, apiErr := s.load(ctx, userID, webhookID); apiErr != nil {
+		return nil, apiErr
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultDeliveryLogLimit
+	}
+	if limit > maxDeliveryLogLimit {
+		limit = maxDeliveryLogLimit
+	}
+
+	filter := repository.DeliveryFilter{Limit: limit + 1}
+	switch query.Status {
+	case "", model.DeliveryStatusPending, model.DeliveryStatusDelivered, model.DeliveryStatusFailed:
+		filter.Status = query.Status
+	default:
+		return nil, apperrors.BadRequest("invalid_status", "status must be one of pending, delivered, failed")
+	}
+	if query.Cursor != "" {
+		beforeID, err := strconv.ParseInt(query.Cursor, 10, 64)
+		if err != nil || beforeID <= 0 {
+			return nil, apperrors.BadRequest("invalid_cursor", "cursor is malformed")
+		}
+		filter.BeforeID = beforeID
+	}
+
+	deliveries, err := s.repo.ListDeliveries(ctx, userID, webhookID, filter)
+	if err != nil {
+		return nil, apperrors.Internal("failed to list webhook deliveries")
+	}
+
+	page := DeliveryLogPage{Deliveries: deliveries}
+	if len(deliveries) > limit {
+		page.Deliveries = deliveries[:limit]
+		nextCursor := strconv.FormatInt(page.Deliveries[limit-1].ID, 10)
+		page.NextCursor = &nextCursor
+	}
+	return &page, nil
+}
+
+func (s *WebhookService) load(ctx context.Context, userID, webhookID string) (*model.Webhook, *apperrors.APIError) {
+	webhook, err := s.repo.GetByID(ctx, userID, webhookID)
+	if err == repository.ErrNotFound {
+		return nil, webhookNotFoundError()
+	}
+	if err != nil {
+		return nil, apperrors.Internal("failed to get webhook")
+	}
+	return webhook, nil
+}
+
+// applyWebhookInput validates input and copies it onto webhook. Events are
+// stored without duplicates in the order of webhookEvents.
+func (s *WebhookService) applyWebhookInput(webhook *model.Webhook, input WebhookInput) *apperrors.APIError {
+	rawURL := strings.TrimSpace(input.URL)
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" ||
+		len(rawURL) > maxWebhookURLLength {
+		return apperrors.BadRequest("invalid_url", "url must be an absolute http or https URL")
+	}
+	if !s.settings.AllowPrivateNetworks && !isPublicWebhookHost(parsed.Hostname()) {
+		return apperrors.BadRequest("invalid_url", "url must name a public host, not localhost or an IP address")
+	}
+
+	if len(input.Events) == 0 {
+		return apperrors.BadRequest("invalid_events", "events must list at least one event")
+	}
+	requested := make(map[string]bool, len(input.Events))
+	for _, event := range input.Events {
+		requested[event] = true
+	}
+	events := make([]string, 0, len(requested))
+	for _, event := range webhookEvents {
+		if requested[event] {
+			events = append(events, event)
+			delete(requested, event)
+		}
+	}
+	if len(requested) > 0 {
+		return apperrors.BadRequest("invalid_events", "events must be among "+strings.Join(webhookEvents, ", "))
+	}
+
+	webhook.URL = rawURL
+	webhook.Events = events
+	webhook.Active = input.Active == nil || *input.Active
+	return nil
+}
+
+// isPublicWebhookHost rejects literal IP addresses and localhost names up
+// front; where other names point is checked when connecting.
+func isPublicWebhookHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	_, err := netip.ParseAddr(host)
+	return err != nil
+}
+
+// refusePrivateAddress is a net.Dialer Control that only lets connections
+// to globally routable unicast addresses through.
+func refusePrivateAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !isGlobalAddress(addr) {
+		return errPrivateWebhookAddress
+	}
+	return nil
+}
+
+var (
+	// nat64Prefix and sixToFourPrefix carry an IPv4 address, which is what
+	// the connection ends up reaching.
+	nat64Prefix     = netip.MustParsePrefix("64:ff9b::/96")
+	sixToFourPrefix = netip.MustParsePrefix("2002::/16")
+
+	// nonGlobalPrefixes are the special-purpose ranges from the IANA
+	// registries that netip has no predicate for.
+	nonGlobalPrefixes = []netip.Prefix{
+		netip.MustParsePrefix("0.0.0.0/8"),
+		netip.MustParsePrefix("100.64.0.0/10"),
+		netip.MustParsePrefix("192.0.0.0/24"),
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("198.18.0.0/15"),
+		netip.MustParsePrefix("198.51.100.0/24"),
+		netip.MustParsePrefix("203.0.113.0/24"),
+		netip.MustParsePrefix("240.0.0.0/4"),
+		netip.MustParsePrefix("::/96"),
+		netip.MustParsePrefix("64:ff9b:1::/48"),
+		netip.MustParsePrefix("100::/64"),
+		netip.MustParsePrefix("2001::/23"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}
+)
+
+// isGlobalAddress reports whether addr is a globally routable unicast
+// address, looking through IPv4-mapped, NAT64 and 6to4 forms.
+func isGlobalAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if nat64Prefix.Contains(addr) {
+		bytes := addr.As16()
+		return isGlobalAddress(netip.AddrFrom4([4]byte(bytes[12:16])))
+	}
+	if sixToFourPrefix.Contains(addr) {
+		bytes := addr.As16()
+		return isGlobalAddress(netip.AddrFrom4([4]byte(bytes[2:6])))
+	}
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonGlobalPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+func webhookNotFoundError() *apperrors.APIError {
+	return apperrors.NotFound("webhook_not_found", "webhook not found")
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"pomodoro/backend/internal/model"
+)
+
+func TestWebhookURLValidation(t *testing.T) {
+	restricted := NewWebhookService(nil, WebhookDeliverySettings{Timeout: time.Second})
+	permissive := NewWebhookService(nil, WebhookDeliverySettings{Timeout: time.Second, AllowPrivateNetworks: true})
+
+	tests := []struct {
+		url        string
+		restricted bool
+		permissive bool
+	}{
+		{"https://hooks.example.com/pomodoro", true, true},
+		{"http://hooks.example.com:8080/pomodoro", true, true},
+		{"ftp://hooks.example.com/pomodoro", false, false},
+		{"/relative", false, false},
+		{"http://localhost:8080/hook", false, true},
+		{"http://LOCALHOST./hook", false, true},
+		{"http://api.localhost/hook", false, true},
+		{"http://127.0.0.1/hook", false, true},
+		{"http://10.0.0.5/hook", false, true},
+		{"http://169.254.169.254/latest/meta-data", false, true},
+		{"http://[::1]:9000/hook", false, true},
+		{"http://[::ffff:127.0.0.1]/hook", false, true},
+		{"https://93.184.216.34/hook", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			input := WebhookInput{URL: tt.url, Events: []string{model.WebhookEventSessionStarted}}
+			for _, check := range []struct {
+				service *WebhookService
+				valid   bool
+			}{{restricted, tt.restricted}, {permissive, tt.permissive}} {
+				var webhook model.Webhook
+				apiErr := check.service.applyWebhookInput(&webhook, input)
+				if (apiErr == nil) != check.valid {
+					t.Fatalf("allow private %v: expected valid %v, got %v", check.service.settings.AllowPrivateNetworks, check.valid, apiErr)
+				}
+			}
+		})
+	}
+}
+
+func TestRefusePrivateAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"93.184.216.34:443", true},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", true},
+		{"127.0.0.1:80", false},
+		{"127.8.9.10:80", false},
+		{"[::1]:80", false},
+		{"10.1.2.3:80", false},
+		{"172.16.0.1:80", false},
+		{"192.168.1.1:80", false},
+		{"[fd00::1]:80", false},
+		{"169.254.169.254:80", false},
+		{"[fe80::1]:80", false},
+		{"0.0.0.0:80", false},
+		{"[::]:80", false},
+		{"224.0.0.1:80", false},
+		{"[ff02::1]:80", false},
+		{"[::ffff:10.0.0.1]:80", false},
+		{"100.64.0.1:80", false},
+		{"100.127.255.254:80", false},
+		{"192.0.0.8:80", false},
+		{"198.18.0.1:80", false},
+		{"198.19.255.254:80", false},
+		{"192.0.2.1:80", false},
+		{"203.0.113.7:80", false},
+		{"240.0.0.1:80", false},
+		{"255.255.255.255:80", false},
+		{"[::ffff:100.64.0.1]:80", false},
+		{"[::ffff:198.18.0.1]:80", false},
+		{"[64:ff9b::7f00:1]:80", false},
+		{"[64:ff9b::6440:1]:80", false},
+		{"[64:ff9b::c000:8]:80", false},
+		{"[64:ff9b::c612:1]:80", false},
+		{"[64:ff9b::5db8:d822]:443", true},
+		{"[64:ff9b:1::a00:1]:80", false},
+		{"[2002:a00:1::1]:80", false},
+		{"[2002:5db8:d822::1]:443", true},
+		{"[2001:db8::1]:80", false},
+		{"[2001::1]:80", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := refusePrivateAddress("tcp", tt.address, nil)
+			if (err == nil) != tt.allowed {
+				t.Fatalf("expected allowed %v, got %v", tt.allowed, err)
+			}
+		})
+	}
+}
+
+func TestWebhookDeliveryRefusesPrivateAddress(t *testing.T) {
+	received := false
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		received = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(receiver.Close)
+
+	delivery := &model.WebhookDelivery{ID: 1, EventType: model.WebhookEventSessionStarted, Payload: []byte(`{}`)}
+
+	restricted := NewWebhookService(nil, WebhookDeliverySettings{Timeout: time.Second})
+	if _, err := restricted.send(context.Background(), receiver.URL, "secret", delivery, time.Now()); !errors.Is(err, errPrivateWebhookAddress) {
+		t.Fatalf("expected the loopback receiver to be refused, got %v", err)
+	}
+	if received {
+		t.Fatal("expected no request to reach the loopback receiver")
+	}
+
+	permissive := NewWebhookService(nil, WebhookDeliverySettings{Timeout: time.Second, AllowPrivateNetworks: true})
+	status, err := permissive.send(context.Background(), receiver.URL, "secret", delivery, time.Now())
+	if err != nil || status == nil || *status != http.StatusNoContent {
+		t.Fatalf("expected delivery when private networks are allowed, got %v", err)
+	}
+}
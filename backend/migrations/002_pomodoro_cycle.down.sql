@@ -0,0 +1,4 @@
+ALTER TABLE pomodoro_states DROP COLUMN auto_start_focus;
+ALTER TABLE pomodoro_states DROP COLUMN auto_start_breaks;
+ALTER TABLE pomodoro_states DROP COLUMN long_break_interval;
+ALTER TABLE pomodoro_states DROP COLUMN completed_focus_count;
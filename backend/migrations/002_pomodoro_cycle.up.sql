@@ -0,0 +1,4 @@
+ALTER TABLE pomodoro_states ADD COLUMN completed_focus_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE pomodoro_states ADD COLUMN long_break_interval INTEGER NOT NULL DEFAULT 4;
+ALTER TABLE pomodoro_states ADD COLUMN auto_start_breaks INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE pomodoro_states ADD COLUMN auto_start_focus INTEGER NOT NULL DEFAULT 0;
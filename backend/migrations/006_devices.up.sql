@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS devices (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL,
+  name TEXT NOT NULL,
+  platform TEXT NOT NULL DEFAULT '',
+  last_ip TEXT NOT NULL DEFAULT '',
+  last_seen_at TEXT NOT NULL,
+  created_at TEXT NOT NULL,
+  revoked_at TEXT,
+  FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_devices_user
+ON devices(user_id);
+
+ALTER TABLE refresh_tokens ADD COLUMN device_id TEXT REFERENCES devices(id) ON DELETE CASCADE;
+ALTER TABLE pomodoro_states ADD COLUMN updated_by_device_id TEXT REFERENCES devices(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_device
+ON refresh_tokens(device_id);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS pomodoro_events (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id TEXT NOT NULL,
+  type TEXT NOT NULL,
+  from_status TEXT NOT NULL,
+  to_status TEXT NOT NULL,
+  from_mode TEXT NOT NULL,
+  to_mode TEXT NOT NULL,
+  session_id TEXT,
+  version INTEGER NOT NULL,
+  device_id TEXT,
+  occurred_at TEXT NOT NULL,
+  created_at TEXT NOT NULL,
+  FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY(device_id) REFERENCES devices(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_pomodoro_events_user_id
+ON pomodoro_events(user_id, id DESC);
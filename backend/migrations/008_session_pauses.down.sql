@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_session_pauses_session;
+DROP TABLE IF EXISTS session_pauses;
+
+ALTER TABLE pomodoro_sessions DROP COLUMN paused_seconds;
+ALTER TABLE pomodoro_sessions DROP COLUMN pause_count;
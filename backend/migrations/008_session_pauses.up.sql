@@ -0,0 +1,15 @@
+ALTER TABLE pomodoro_sessions ADD COLUMN pause_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE pomodoro_sessions ADD COLUMN paused_seconds INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS session_pauses (
+  id TEXT PRIMARY KEY,
+  session_id TEXT NOT NULL,
+  user_id TEXT NOT NULL,
+  paused_at TEXT NOT NULL,
+  resumed_at TEXT,
+  FOREIGN KEY(session_id) REFERENCES pomodoro_sessions(id) ON DELETE CASCADE,
+  FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_pauses_session
+ON session_pauses(session_id, paused_at);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS presets (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL,
+  name TEXT NOT NULL,
+  focus_duration_seconds INTEGER NOT NULL,
+  short_break_duration_seconds INTEGER NOT NULL,
+  long_break_duration_seconds INTEGER NOT NULL,
+  long_break_interval INTEGER,
+  auto_start_breaks INTEGER,
+  auto_start_focus INTEGER,
+  created_at TEXT NOT NULL,
+  updated_at TEXT NOT NULL,
+  UNIQUE(user_id, name),
+  FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
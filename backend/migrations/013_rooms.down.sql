@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_room_members_user_id;
+DROP TABLE IF EXISTS room_members;
+DROP TABLE IF EXISTS rooms;
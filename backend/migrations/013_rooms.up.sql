@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS rooms (
+  id TEXT PRIMARY KEY,
+  owner_id TEXT NOT NULL,
+  name TEXT NOT NULL,
+  invite_code TEXT NOT NULL UNIQUE,
+  mode TEXT NOT NULL CHECK (mode IN ('focus', 'short_break', 'long_break')),
+  status TEXT NOT NULL CHECK (status IN ('idle', 'running', 'paused')),
+  remaining_seconds INTEGER NOT NULL,
+  focus_duration_seconds INTEGER NOT NULL,
+  short_break_duration_seconds INTEGER NOT NULL,
+  long_break_duration_seconds INTEGER NOT NULL,
+  completed_focus_count INTEGER NOT NULL DEFAULT 0,
+  long_break_interval INTEGER NOT NULL DEFAULT 4,
+  auto_start_breaks INTEGER NOT NULL DEFAULT 0,
+  auto_start_focus INTEGER NOT NULL DEFAULT 0,
+  started_at TEXT,
+  version INTEGER NOT NULL DEFAULT 1,
+  created_at TEXT NOT NULL,
+  updated_at TEXT NOT NULL,
+  FOREIGN KEY(owner_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS room_members (
+  room_id TEXT NOT NULL,
+  user_id TEXT NOT NULL,
+  role TEXT NOT NULL CHECK (role IN ('owner', 'member')),
+  session_id TEXT,
+  joined_at TEXT NOT NULL,
+  PRIMARY KEY(room_id, user_id),
+  FOREIGN KEY(room_id) REFERENCES rooms(id) ON DELETE CASCADE,
+  FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY(session_id) REFERENCES pomodoro_sessions(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_room_members_user_id
+ON room_members(user_id);
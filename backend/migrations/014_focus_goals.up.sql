@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS focus_goals (
+  user_id TEXT PRIMARY KEY,
+  time_zone TEXT NOT NULL DEFAULT 'UTC',
+  daily_pomodoros INTEGER,
+  daily_focus_seconds INTEGER,
+  weekly_pomodoros INTEGER,
+  weekly_focus_seconds INTEGER,
+  daily_max_focus_seconds INTEGER,
+  updated_at TEXT NOT NULL,
+  FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
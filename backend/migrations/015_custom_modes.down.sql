@@ -0,0 +1,85 @@
+-- Only the built-in modes fit the restored constraints: timers on a custom
+-- mode go back to an idle focus phase and custom-mode sessions are removed.
+UPDATE pomodoro_states
+SET mode = 'focus',
+    status = 'idle',
+    remaining_seconds = focus_duration_seconds,
+    started_at = NULL,
+    session_id = NULL
+WHERE mode NOT IN ('focus', 'short_break', 'long_break');
+
+DELETE FROM session_pauses
+WHERE session_id IN (SELECT id FROM pomodoro_sessions WHERE mode NOT IN ('focus', 'short_break', 'long_break'));
+UPDATE room_members
+SET session_id = NULL
+WHERE session_id IN (SELECT id FROM pomodoro_sessions WHERE mode NOT IN ('focus', 'short_break', 'long_break'));
+DELETE FROM pomodoro_sessions WHERE mode NOT IN ('focus', 'short_break', 'long_break');
+
+DROP TABLE IF EXISTS modes;
+
+CREATE TABLE pomodoro_states_new (
+  user_id TEXT PRIMARY KEY,
+  mode TEXT NOT NULL CHECK (mode IN ('focus', 'short_break', 'long_break')),
+  status TEXT NOT NULL CHECK (status IN ('idle', 'running', 'paused')),
+  remaining_seconds INTEGER NOT NULL,
+  focus_duration_seconds INTEGER NOT NULL DEFAULT 1500,
+  short_break_duration_seconds INTEGER NOT NULL DEFAULT 300,
+  long_break_duration_seconds INTEGER NOT NULL DEFAULT 900,
+  started_at TEXT,
+  session_id TEXT,
+  version INTEGER NOT NULL DEFAULT 1,
+  updated_at TEXT NOT NULL,
+  completed_focus_count INTEGER NOT NULL DEFAULT 0,
+  long_break_interval INTEGER NOT NULL DEFAULT 4,
+  auto_start_breaks INTEGER NOT NULL DEFAULT 0,
+  auto_start_focus INTEGER NOT NULL DEFAULT 0,
+  current_task_id TEXT REFERENCES tasks(id) ON DELETE SET NULL,
+  updated_by_device_id TEXT REFERENCES devices(id) ON DELETE SET NULL,
+  FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO pomodoro_states_new (
+  user_id, mode, status, remaining_seconds, focus_duration_seconds, short_break_duration_seconds,
+  long_break_duration_seconds, started_at, session_id, version, updated_at, completed_focus_count,
+  long_break_interval, auto_start_breaks, auto_start_focus, current_task_id, updated_by_device_id
+)
+SELECT
+  user_id, mode, status, remaining_seconds, focus_duration_seconds, short_break_duration_seconds,
+  long_break_duration_seconds, started_at, session_id, version, updated_at, completed_focus_count,
+  long_break_interval, auto_start_breaks, auto_start_focus, current_task_id, updated_by_device_id
+FROM pomodoro_states;
+
+DROP TABLE pomodoro_states;
+ALTER TABLE pomodoro_states_new RENAME TO pomodoro_states;
+
+CREATE TABLE pomodoro_sessions_new (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL,
+  mode TEXT NOT NULL CHECK (mode IN ('focus', 'short_break', 'long_break')),
+  planned_duration_seconds INTEGER NOT NULL,
+  actual_duration_seconds INTEGER NOT NULL DEFAULT 0,
+  started_at TEXT NOT NULL,
+  ended_at TEXT,
+  status TEXT NOT NULL CHECK (status IN ('running', 'completed', 'cancelled')),
+  created_at TEXT NOT NULL,
+  updated_at TEXT NOT NULL,
+  task_id TEXT REFERENCES tasks(id) ON DELETE SET NULL,
+  pause_count INTEGER NOT NULL DEFAULT 0,
+  paused_seconds INTEGER NOT NULL DEFAULT 0,
+  FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO pomodoro_sessions_new (
+  id, user_id, mode, planned_duration_seconds, actual_duration_seconds, started_at, ended_at,
+  status, created_at, updated_at, task_id, pause_count, paused_seconds
+)
+SELECT
+  id, user_id, mode, planned_duration_seconds, actual_duration_seconds, started_at, ended_at,
+  status, created_at, updated_at, task_id, pause_count, paused_seconds
+FROM pomodoro_sessions;
+
+DROP TABLE pomodoro_sessions;
+ALTER TABLE pomodoro_sessions_new RENAME TO pomodoro_sessions;
+
+CREATE INDEX IF NOT EXISTS idx_pomodoro_sessions_user_started_id
+ON pomodoro_sessions(user_id, started_at DESC, id DESC);
@@ -0,0 +1,2 @@
+-- Padded timestamps parse the same as before; there is nothing to undo.
+SELECT 1;
@@ -0,0 +1,73 @@
+-- Rows written before timestamps were stored with a fixed nine-digit
+-- fraction (RFC 3339 with trailing zeros trimmed) do not sort lexically in
+-- time order. Pad them to the fixed width: 2006-01-02T15:04:05.000000000Z.
+
+UPDATE users
+SET created_at = CASE
+  WHEN length(created_at) = 20 THEN substr(created_at, 1, 19) || '.000000000Z'
+  ELSE substr(created_at, 1, length(created_at) - 1) || substr('000000000', 1, 30 - length(created_at)) || 'Z'
+END
+WHERE created_at LIKE '____-__-__T__:__:__%Z' AND length(created_at) < 30;
+
+UPDATE users
+SET updated_at = CASE
+  WHEN length(updated_at) = 20 THEN substr(updated_at, 1, 19) || '.000000000Z'
+  ELSE substr(updated_at, 1, length(updated_at) - 1) || substr('000000000', 1, 30 - length(updated_at)) || 'Z'
+END
+WHERE updated_at LIKE '____-__-__T__:__:__%Z' AND length(updated_at) < 30;
+
+UPDATE pomodoro_states
+SET started_at = CASE
+  WHEN length(started_at) = 20 THEN substr(started_at, 1, 19) || '.000000000Z'
+  ELSE substr(started_at, 1, length(started_at) - 1) || substr('000000000', 1, 30 - length(started_at)) || 'Z'
+END
+WHERE started_at LIKE '____-__-__T__:__:__%Z' AND length(started_at) < 30;
+
+UPDATE pomodoro_states
+SET updated_at = CASE
+  WHEN length(updated_at) = 20 THEN substr(updated_at, 1, 19) || '.000000000Z'
+  ELSE substr(updated_at, 1, length(updated_at) - 1) || substr('000000000', 1, 30 - length(updated_at)) || 'Z'
+END
+WHERE updated_at LIKE '____-__-__T__:__:__%Z' AND length(updated_at) < 30;
+
+UPDATE pomodoro_sessions
+SET started_at = CASE
+  WHEN length(started_at) = 20 THEN substr(started_at, 1, 19) || '.000000000Z'
+  ELSE substr(started_at, 1, length(started_at) - 1) || substr('000000000', 1, 30 - length(started_at)) || 'Z'
+END
+WHERE started_at LIKE '____-__-__T__:__:__%Z' AND length(started_at) < 30;
+
+UPDATE pomodoro_sessions
+SET ended_at = CASE
+  WHEN length(ended_at) = 20 THEN substr(ended_at, 1, 19) || '.000000000Z'
+  ELSE substr(ended_at, 1, length(ended_at) - 1) || substr('000000000', 1, 30 - length(ended_at)) || 'Z'
+END
+WHERE ended_at LIKE '____-__-__T__:__:__%Z' AND length(ended_at) < 30;
+
+UPDATE pomodoro_sessions
+SET created_at = CASE
+  WHEN length(created_at) = 20 THEN substr(created_at, 1, 19) || '.000000000Z'
+  ELSE substr(created_at, 1, length(created_at) - 1) || substr('000000000', 1, 30 - length(created_at)) || 'Z'
+END
+WHERE created_at LIKE '____-__-__T__:__:__%Z' AND length(created_at) < 30;
+
+UPDATE pomodoro_sessions
+SET updated_at = CASE
+  WHEN length(updated_at) = 20 THEN substr(updated_at, 1, 19) || '.000000000Z'
+  ELSE substr(updated_at, 1, length(updated_at) - 1) || substr('000000000', 1, 30 - length(updated_at)) || 'Z'
+END
+WHERE updated_at LIKE '____-__-__T__:__:__%Z' AND length(updated_at) < 30;
+
+UPDATE tasks
+SET created_at = CASE
+  WHEN length(created_at) = 20 THEN substr(created_at, 1, 19) || '.000000000Z'
+  ELSE substr(created_at, 1, length(created_at) - 1) || substr('000000000', 1, 30 - length(created_at)) || 'Z'
+END
+WHERE created_at LIKE '____-__-__T__:__:__%Z' AND length(created_at) < 30;
+
+UPDATE tasks
+SET updated_at = CASE
+  WHEN length(updated_at) = 20 THEN substr(updated_at, 1, 19) || '.000000000Z'
+  ELSE substr(updated_at, 1, length(updated_at) - 1) || substr('000000000', 1, 30 - length(updated_at)) || 'Z'
+END
+WHERE updated_at LIKE '____-__-__T__:__:__%Z' AND length(updated_at) < 30;
@@ -0,0 +1,13 @@
+-- Whether a session counts as focus time is fixed when it is recorded, so
+-- deleting a custom mode or changing its countsAsFocus leaves past stats,
+-- streaks and goal progress alone. Existing sessions take the flag their
+-- mode has now.
+ALTER TABLE pomodoro_sessions ADD COLUMN counts_as_focus INTEGER NOT NULL DEFAULT 0;
+
+UPDATE pomodoro_sessions
+SET counts_as_focus = 1
+WHERE mode = 'focus'
+   OR mode IN (
+     SELECT modes.mode FROM modes
+     WHERE modes.user_id = pomodoro_sessions.user_id AND modes.counts_as_focus = 1
+   );
@@ -0,0 +1,36 @@
+// Package migrations embeds the SQL migration sets so binaries can migrate
+// without the migrations directory on disk.
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"pomodoro/backend/internal/db"
+)
+
+//go:embed *.sql postgres/*.sql
+var files embed.FS
+
+// Source reads migrations from dir when it exists and from the embedded
+// copy when dir is empty or absent, as it is next to a deployed binary.
+func Source(driver, dir string) (fs.FS, error) {
+	if dir != "" {
+		info, err := os.Stat(dir)
+		switch {
+		case err == nil && !info.IsDir():
+			return nil, fmt.Errorf("migrations path %s is not a directory", dir)
+		case err == nil:
+			return os.DirFS(dir), nil
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, err
+		}
+	}
+	if driver == db.DriverPostgres {
+		return fs.Sub(files, "postgres")
+	}
+	return files, nil
+}
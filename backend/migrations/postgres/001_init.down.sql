@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS pomodoro_sessions;
+DROP TABLE IF EXISTS pomodoro_states;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,5 @@
+ALTER TABLE pomodoro_sessions DROP COLUMN task_id;
+ALTER TABLE pomodoro_states DROP COLUMN current_task_id;
+
+DROP INDEX IF EXISTS idx_tasks_user_created;
+DROP TABLE IF EXISTS tasks;
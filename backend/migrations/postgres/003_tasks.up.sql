@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS tasks (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  title TEXT NOT NULL,
+  notes TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'done')),
+  estimated_pomodoros INTEGER NOT NULL DEFAULT 0,
+  completed_pomodoros INTEGER NOT NULL DEFAULT 0,
+  created_at TEXT NOT NULL,
+  updated_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_created
+ON tasks(user_id, created_at DESC);
+
+ALTER TABLE pomodoro_states ADD COLUMN current_task_id TEXT REFERENCES tasks(id) ON DELETE SET NULL;
+ALTER TABLE pomodoro_sessions ADD COLUMN task_id TEXT REFERENCES tasks(id) ON DELETE SET NULL;
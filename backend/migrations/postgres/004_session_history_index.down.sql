@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_pomodoro_sessions_user_started_id;
+
+CREATE INDEX IF NOT EXISTS idx_pomodoro_sessions_user_started
+ON pomodoro_sessions(user_id, started_at DESC);
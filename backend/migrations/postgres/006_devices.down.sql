@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_refresh_tokens_device;
+
+ALTER TABLE pomodoro_states DROP COLUMN updated_by_device_id;
+ALTER TABLE refresh_tokens DROP COLUMN device_id;
+
+DROP INDEX IF EXISTS idx_devices_user;
+DROP TABLE IF EXISTS devices;
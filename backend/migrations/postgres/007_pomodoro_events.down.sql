@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_pomodoro_events_user_id;
+DROP TABLE IF EXISTS pomodoro_events;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS pomodoro_events (
+  id BIGSERIAL PRIMARY KEY,
+  user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  type TEXT NOT NULL,
+  from_status TEXT NOT NULL,
+  to_status TEXT NOT NULL,
+  from_mode TEXT NOT NULL,
+  to_mode TEXT NOT NULL,
+  session_id TEXT,
+  version INTEGER NOT NULL,
+  device_id TEXT REFERENCES devices(id) ON DELETE SET NULL,
+  occurred_at TEXT NOT NULL,
+  created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_pomodoro_events_user_id
+ON pomodoro_events(user_id, id DESC);
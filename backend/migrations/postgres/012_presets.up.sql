@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS presets (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  focus_duration_seconds INTEGER NOT NULL,
+  short_break_duration_seconds INTEGER NOT NULL,
+  long_break_duration_seconds INTEGER NOT NULL,
+  long_break_interval INTEGER,
+  auto_start_breaks BOOLEAN,
+  auto_start_focus BOOLEAN,
+  created_at TEXT NOT NULL,
+  updated_at TEXT NOT NULL,
+  UNIQUE(user_id, name)
+);
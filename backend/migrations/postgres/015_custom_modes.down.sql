@@ -0,0 +1,18 @@
+-- Only the built-in modes fit the restored constraints: timers on a custom
+-- mode go back to an idle focus phase and custom-mode sessions are removed.
+UPDATE pomodoro_states
+SET mode = 'focus',
+    status = 'idle',
+    remaining_seconds = focus_duration_seconds,
+    started_at = NULL,
+    session_id = NULL
+WHERE mode NOT IN ('focus', 'short_break', 'long_break');
+
+DELETE FROM pomodoro_sessions WHERE mode NOT IN ('focus', 'short_break', 'long_break');
+
+DROP TABLE IF EXISTS modes;
+
+ALTER TABLE pomodoro_sessions
+  ADD CONSTRAINT pomodoro_sessions_mode_check CHECK (mode IN ('focus', 'short_break', 'long_break'));
+ALTER TABLE pomodoro_states
+  ADD CONSTRAINT pomodoro_states_mode_check CHECK (mode IN ('focus', 'short_break', 'long_break'));
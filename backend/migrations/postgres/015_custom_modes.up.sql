@@ -0,0 +1,17 @@
+-- Timers may switch to user-defined modes, so the mode columns lose their
+-- CHECK constraints.
+ALTER TABLE pomodoro_states DROP CONSTRAINT IF EXISTS pomodoro_states_mode_check;
+ALTER TABLE pomodoro_sessions DROP CONSTRAINT IF EXISTS pomodoro_sessions_mode_check;
+
+CREATE TABLE IF NOT EXISTS modes (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  mode TEXT NOT NULL,
+  name TEXT NOT NULL,
+  duration_seconds INTEGER NOT NULL,
+  color TEXT NOT NULL,
+  counts_as_focus BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TEXT NOT NULL,
+  updated_at TEXT NOT NULL,
+  UNIQUE(user_id, mode)
+);
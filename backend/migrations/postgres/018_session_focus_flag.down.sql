@@ -0,0 +1 @@
+ALTER TABLE pomodoro_sessions DROP COLUMN counts_as_focus;
@@ -6,16 +6,50 @@ type AuthInput = {
   password: string;
 };
 
+// The device ID survives logout so that signing in again from the same
+// browser reuses its device entry instead of creating a new one.
+const DEVICE_KEY = "pomodoro_device_id";
+const MAX_DEVICE_NAME_LENGTH = 100;
+
+function currentDevice() {
+  return {
+    id: localStorage.getItem(DEVICE_KEY) ?? "",
+    name: navigator.userAgent.slice(0, MAX_DEVICE_NAME_LENGTH),
+    platform: "web",
+  };
+}
+
+function rememberDevice(result: AuthResponse): AuthResponse {
+  if (result.device) {
+    localStorage.setItem(DEVICE_KEY, result.device.id);
+  }
+  return result;
+}
+
 export function register(input: AuthInput): Promise<AuthResponse> {
   return request<AuthResponse>("/auth/register", {
     method: "POST",
-    body: input,
-  });
+    body: { ...input, device: currentDevice() },
+  }).then(rememberDevice);
 }
 
 export function login(input: AuthInput): Promise<AuthResponse> {
   return request<AuthResponse>("/auth/login", {
     method: "POST",
-    body: input,
+    body: { ...input, device: currentDevice() },
+  }).then(rememberDevice);
+}
+
+export function refresh(refreshToken: string): Promise<AuthResponse> {
+  return request<AuthResponse>("/auth/refresh", {
+    method: "POST",
+    body: { refreshToken },
+  });
+}
+
+export function logout(token: string): Promise<void> {
+  return request<void>("/auth/logout", {
+    method: "POST",
+    token,
   });
 }
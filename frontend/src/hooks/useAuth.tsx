@@ -10,7 +10,7 @@ import {
 } from "react";
 import * as authApi from "../api/auth";
 import { ApiError } from "../api/client";
-import type { User } from "../types";
+import type { AuthResponse, User } from "../types";
 
 type AuthContextValue = {
   token: string | null;
@@ -24,21 +24,29 @@ type AuthContextValue = {
 };
 
 const TOKEN_KEY = "pomodoro_token";
+const TOKEN_EXPIRES_KEY = "pomodoro_token_expires_at";
+const REFRESH_TOKEN_KEY = "pomodoro_refresh_token";
 const USER_KEY = "pomodoro_user";
+// Refresh a little before the access token expires so in-flight requests
+// never carry an expired token.
+const REFRESH_LEEWAY_MS = 60 * 1000;
 
 const AuthContext = createContext<AuthContextValue | undefined>(undefined);
 
 export function AuthProvider({ children }: { children: ReactNode }) {
   const [token, setToken] = useState<string | null>(null);
+  const [tokenExpiresAt, setTokenExpiresAt] = useState<string | null>(null);
   const [user, setUser] = useState<User | null>(null);
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState<string | null>(null);
 
   useEffect(() => {
     const storedToken = localStorage.getItem(TOKEN_KEY);
+    const storedExpiresAt = localStorage.getItem(TOKEN_EXPIRES_KEY);
     const storedUser = localStorage.getItem(USER_KEY);
     if (storedToken) {
       setToken(storedToken);
+      setTokenExpiresAt(storedExpiresAt);
     }
     if (storedUser) {
       try {
@@ -49,20 +57,61 @@ export function AuthProvider({ children }: { children: ReactNode }) {
     }
   }, []);
 
-  const saveAuth = useCallback((nextToken: string, nextUser: User) => {
-    localStorage.setItem(TOKEN_KEY, nextToken);
-    localStorage.setItem(USER_KEY, JSON.stringify(nextUser));
-    setToken(nextToken);
-    setUser(nextUser);
+  const saveAuth = useCallback((result: AuthResponse) => {
+    localStorage.setItem(TOKEN_KEY, result.token);
+    localStorage.setItem(TOKEN_EXPIRES_KEY, result.expiresAt);
+    localStorage.setItem(REFRESH_TOKEN_KEY, result.refreshToken);
+    localStorage.setItem(USER_KEY, JSON.stringify(result.user));
+    setToken(result.token);
+    setTokenExpiresAt(result.expiresAt);
+    setUser(result.user);
   }, []);
 
+  const clearAuth = useCallback(() => {
+    localStorage.removeItem(TOKEN_KEY);
+    localStorage.removeItem(TOKEN_EXPIRES_KEY);
+    localStorage.removeItem(REFRESH_TOKEN_KEY);
+    localStorage.removeItem(USER_KEY);
+    setToken(null);
+    setTokenExpiresAt(null);
+    setUser(null);
+    setError(null);
+  }, []);
+
+  useEffect(() => {
+    if (!token) {
+      return;
+    }
+    const refreshToken = localStorage.getItem(REFRESH_TOKEN_KEY);
+    if (!refreshToken) {
+      // Tokens issued before refresh support cannot be renewed.
+      clearAuth();
+      return;
+    }
+
+    const expiresAtMs = tokenExpiresAt ? Date.parse(tokenExpiresAt) : Date.now();
+    const delay = Math.max(0, expiresAtMs - Date.now() - REFRESH_LEEWAY_MS);
+    const timer = window.setTimeout(() => {
+      authApi
+        .refresh(refreshToken)
+        .then(saveAuth)
+        .catch((err) => {
+          if (err instanceof ApiError && err.status === 401) {
+            clearAuth();
+          }
+        });
+    }, delay);
+
+    return () => window.clearTimeout(timer);
+  }, [token, tokenExpiresAt, saveAuth, clearAuth]);
+
   const login = useCallback(
     async (email: string, password: string) => {
       setLoading(true);
       setError(null);
       try {
         const result = await authApi.login({ email, password });
-        saveAuth(result.token, result.user);
+        saveAuth(result);
       } catch (err) {
         if (err instanceof ApiError) {
           setError(err.message);
@@ -83,7 +132,7 @@ export function AuthProvider({ children }: { children: ReactNode }) {
       setError(null);
       try {
         const result = await authApi.register({ email, password });
-        saveAuth(result.token, result.user);
+        saveAuth(result);
       } catch (err) {
         if (err instanceof ApiError) {
           setError(err.message);
@@ -99,12 +148,11 @@ export function AuthProvider({ children }: { children: ReactNode }) {
   );
 
   const logout = useCallback(() => {
-    localStorage.removeItem(TOKEN_KEY);
-    localStorage.removeItem(USER_KEY);
-    setToken(null);
-    setUser(null);
-    setError(null);
-  }, []);
+    if (token) {
+      authApi.logout(token).catch(() => undefined);
+    }
+    clearAuth();
+  }, [token, clearAuth]);
 
   const clearError = useCallback(() => setError(null), []);
 
@@ -5,7 +5,21 @@ export type User = {
 
 export type AuthResponse = {
   token: string;
+  expiresAt: string;
+  refreshToken: string;
+  refreshExpiresAt: string;
   user: User;
+  device?: Device;
+};
+
+export type Device = {
+  id: string;
+  userId: string;
+  name: string;
+  platform: string;
+  lastIp: string;
+  lastSeenAt: string;
+  createdAt: string;
 };
 
 export type PomodoroMode = "focus" | "short_break" | "long_break";
@@ -37,6 +51,16 @@ export type PomodoroSession = {
   startedAt: string;
   endedAt?: string;
   status: SessionStatus;
+  pauseCount: number;
+  pausedSeconds: number;
+  pauses?: SessionPause[];
   createdAt: string;
   updatedAt: string;
 };
+
+export type SessionPause = {
+  id: string;
+  sessionId: string;
+  pausedAt: string;
+  resumedAt?: string;
+};